@@ -4,5 +4,6 @@ describe 'bosh-dns-windows' do
   let(:release) { Bosh::Template::Test::ReleaseDir.new(File.join(File.dirname(__FILE__), '../..')) }
   let(:job) { release.job('bosh-dns-windows') }
 
-  it_behaves_like 'common config.json'
+  it_behaves_like 'common config.json', '/var/vcap/jobs/bosh-dns-windows/config'
+  it_behaves_like 'common health_server_config.json'
 end
@@ -10,7 +10,8 @@ describe 'bosh-dns' do
   let(:release) { Bosh::Template::Test::ReleaseDir.new(File.join(File.dirname(__FILE__), '../..')) }
   let(:job) { release.job('bosh-dns') }
 
-  it_behaves_like 'common config.json'
+  it_behaves_like 'common config.json', 'config'
+  it_behaves_like 'common health_server_config.json'
 
   describe 'bin/is-system-resolver' do
     let(:template) { job.template('bin/is-system-resolver') }
@@ -3,7 +3,7 @@ require 'json'
 require 'bosh/template/test'
 require 'yaml'
 
-shared_examples_for 'common config.json' do
+shared_examples_for 'common config.json' do |config_dir|
   describe 'config/config.json' do
     let(:template) { job.template('config/config.json') }
     let(:properties) { {} }
@@ -36,5 +36,188 @@ shared_examples_for 'common config.json' do
         end
       end
     end
+
+    context 'records_verification' do
+      it 'is disabled by default' do
+        expect(rendered['records_verification']).to eq(
+          'checksum_file' => '',
+          'signature_file' => '',
+          'public_key_file' => '',
+        )
+      end
+
+      context 'with a signature' do
+        let(:properties) do
+          {
+            'records_verification' => {
+              'signature_file' => '/var/vcap/instance/dns/records.json.sig',
+              'public_key' => 'PUBLIC KEY',
+            },
+          }
+        end
+
+        it 'points at the rendered public key' do
+          expect(rendered['records_verification']['signature_file']).to eq('/var/vcap/instance/dns/records.json.sig')
+          expect(rendered['records_verification']['public_key_file']).to eq("#{config_dir}/records_verification/public_key.pem")
+        end
+      end
+    end
+
+    context 'cache snapshot' do
+      it 'is disabled by default' do
+        expect(rendered['cache']['snapshot_file']).to eq('')
+      end
+
+      context 'configured' do
+        let(:properties) { { 'cache' => { 'enabled' => true, 'snapshot_file' => '/var/vcap/store/bosh-dns/cache.json' } } }
+
+        it 'writes the snapshot file' do
+          expect(rendered['cache']['enabled']).to eq(true)
+          expect(rendered['cache']['snapshot_file']).to eq('/var/vcap/store/bosh-dns/cache.json')
+        end
+      end
+    end
+
+    context 'health thresholds' do
+      it 'defaults to changing state with every result' do
+        expect(rendered['health']['rise_threshold']).to eq(1)
+        expect(rendered['health']['fall_threshold']).to eq(1)
+      end
+
+      context 'configured' do
+        let(:properties) { { 'health' => { 'rise_threshold' => 2, 'fall_threshold' => 3 } } }
+
+        it 'writes the thresholds' do
+          expect(rendered['health']['rise_threshold']).to eq(2)
+          expect(rendered['health']['fall_threshold']).to eq(3)
+        end
+      end
+    end
+
+    context 'health check back-off' do
+      it 'does not back off by default' do
+        expect(rendered['health']['max_backoff_factor']).to eq(1)
+      end
+
+      context 'configured' do
+        let(:properties) { { 'health' => { 'max_backoff_factor' => 4 } } }
+
+        it 'writes the max back-off factor' do
+          expect(rendered['health']['max_backoff_factor']).to eq(4)
+        end
+      end
+    end
+
+    context 'health gossip' do
+      it 'is disabled by default' do
+        expect(rendered['health']['gossip']['enabled']).to eq(false)
+        expect(rendered['health']['gossip']['port']).to eq(8854)
+        expect(rendered['health']['gossip']['interval']).to eq('1s')
+        expect(rendered['health']['gossip']['max_age']).to eq('10s')
+      end
+
+      it 'uses the health server certificate' do
+        expect(rendered['health']['gossip']['certificate_file']).to end_with('config/certs/health/server.crt')
+        expect(rendered['health']['gossip']['private_key_file']).to end_with('config/certs/health/server.key')
+        expect(rendered['health']['gossip']['ca_file']).to end_with('config/certs/health/server_ca.crt')
+      end
+
+      context 'configured' do
+        let(:properties) { { 'health' => { 'gossip' => { 'enabled' => true, 'port' => 9854, 'interval' => '2s', 'max_age' => '30s' } } } }
+
+        it 'writes the gossip configuration' do
+          expect(rendered['health']['gossip']['enabled']).to eq(true)
+          expect(rendered['health']['gossip']['port']).to eq(9854)
+          expect(rendered['health']['gossip']['interval']).to eq('2s')
+          expect(rendered['health']['gossip']['max_age']).to eq('30s')
+        end
+      end
+    end
+
+    context 'records_delta_file' do
+      it 'defaults to no delta file' do
+        expect(rendered['records_delta_file']).to eq('')
+      end
+
+      context 'when a delta file is configured' do
+        let(:properties) { { 'records_delta_file' => '/var/vcap/instance/dns/records.delta.json' } }
+
+        it 'renders it' do
+          expect(rendered['records_delta_file']).to eq('/var/vcap/instance/dns/records.delta.json')
+        end
+      end
+    end
+
+    context 'records_sources' do
+      it 'defaults to no additional sources' do
+        expect(rendered['records_sources']).to eq([])
+      end
+
+      context 'when additional sources are configured' do
+        let(:properties) do
+          {
+            'records_sources' => [
+              { 'path' => '/var/vcap/data/other/*.json', 'domains' => ['other'], 'precedence' => 5 },
+            ],
+          }
+        end
+
+        it 'renders them' do
+          expect(rendered['records_sources']).to eq([
+            { 'path' => '/var/vcap/data/other/*.json', 'domains' => ['other'], 'precedence' => 5 },
+          ])
+        end
+      end
+    end
+
+    context 'remote_records' do
+      it 'is disabled by default' do
+        expect(rendered['remote_records']).to eq({})
+      end
+
+      context 'when a url is configured' do
+        let(:properties) do
+          {
+            'remote_records' => {
+              'url' => 'https://records.example.com/records.json',
+              'poll_interval' => '1m',
+            },
+          }
+        end
+
+        it 'renders the client certificate paths' do
+          expect(rendered['remote_records']).to include(
+            'url' => 'https://records.example.com/records.json',
+            'server_name' => '',
+            'certificate_file' => "#{config_dir}/certs/remote_records/client.crt",
+            'private_key_file' => "#{config_dir}/certs/remote_records/client.key",
+            'ca_file' => "#{config_dir}/certs/remote_records/server_ca.crt",
+            'poll_interval' => '1m',
+          )
+        end
+      end
+    end
+  end
+end
+
+shared_examples_for 'common health_server_config.json' do
+  describe 'config/health_server_config.json' do
+    let(:template) { job.template('config/health_server_config.json') }
+    let(:properties) { {} }
+    let(:rendered) { JSON.parse(template.render(properties)) }
+
+    context 'health executable timeout' do
+      it 'defaults to 10s' do
+        expect(rendered['health_executable_timeout']).to eq('10s')
+      end
+
+      context 'configured' do
+        let(:properties) { { 'health' => { 'local_health_timeout' => '30s' } } }
+
+        it 'writes the timeout' do
+          expect(rendered['health_executable_timeout']).to eq('30s')
+        end
+      end
+    end
   end
 end
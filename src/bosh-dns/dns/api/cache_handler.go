@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+//counterfeiter:generate -o ./fakes/cache.go . Cache
+type Cache interface {
+	Entries() []*dns.Msg
+	Flush(match func(name string) bool) int
+}
+
+type CacheHandler struct {
+	caches []Cache
+}
+
+func NewCacheHandler(caches []Cache) *CacheHandler {
+	return &CacheHandler{
+		caches: caches,
+	}
+}
+
+func (h *CacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name != "" {
+		name = dns.Fqdn(strings.ToLower(name))
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, cache := range h.caches {
+		for _, msg := range cache.Entries() {
+			if name != "" && strings.ToLower(msg.Question[0].Name) != name {
+				continue
+			}
+
+			encoder.Encode(newCacheEntry(msg)) //nolint:errcheck
+		}
+	}
+}
+
+type CacheFlushHandler struct {
+	caches []Cache
+}
+
+func NewCacheFlushHandler(caches []Cache) *CacheFlushHandler {
+	return &CacheFlushHandler{
+		caches: caches,
+	}
+}
+
+func (h *CacheFlushHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	suffix := r.URL.Query().Get("suffix")
+
+	var match func(string) bool
+	switch {
+	case name != "" && suffix != "":
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("only one of name and suffix may be given")) //nolint:errcheck
+		return
+	case name != "":
+		name = dns.Fqdn(strings.ToLower(name))
+		match = func(n string) bool { return n == name }
+	case suffix != "":
+		suffix = dns.Fqdn(suffix)
+		match = func(n string) bool { return dns.IsSubDomain(suffix, n) }
+	default:
+		match = func(string) bool { return true }
+	}
+
+	flushed := 0
+	for _, cache := range h.caches {
+		flushed += cache.Flush(match)
+	}
+
+	json.NewEncoder(w).Encode(CacheFlushResult{Flushed: flushed}) //nolint:errcheck
+}
+
+func newCacheEntry(msg *dns.Msg) CacheEntry {
+	entry := CacheEntry{
+		Name:    msg.Question[0].Name,
+		Type:    dns.Type(msg.Question[0].Qtype).String(),
+		Rcode:   dns.RcodeToString[msg.Rcode],
+		Records: []string{},
+	}
+
+	first := true
+	for _, section := range [][]dns.RR{msg.Answer, msg.Ns} {
+		for _, rr := range section {
+			if first || rr.Header().Ttl < entry.TTL {
+				entry.TTL = rr.Header().Ttl
+				first = false
+			}
+		}
+	}
+
+	for _, rr := range msg.Answer {
+		entry.Records = append(entry.Records, rr.String())
+	}
+
+	return entry
+}
@@ -0,0 +1,157 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bosh-dns/dns/api"
+	"bosh-dns/dns/api/fakes"
+)
+
+var _ = Describe("CacheHandler", func() {
+	var (
+		fakeCache1 *fakes.FakeCache
+		fakeCache2 *fakes.FakeCache
+		handler    *api.CacheHandler
+
+		w *httptest.ResponseRecorder
+		r *http.Request
+	)
+
+	newMsg := func(name string, ttl uint32, ip string) *dns.Msg {
+		m := &dns.Msg{}
+		m.SetQuestion(name, dns.TypeA)
+		m.Response = true
+		m.Answer = []dns.RR{&dns.A{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl},
+			A:   net.ParseIP(ip),
+		}}
+		return m
+	}
+
+	decodeEntries := func() []api.CacheEntry {
+		entries := []api.CacheEntry{}
+		decoder := json.NewDecoder(w.Result().Body)
+		for decoder.More() {
+			var entry api.CacheEntry
+			Expect(decoder.Decode(&entry)).To(Succeed())
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	BeforeEach(func() {
+		fakeCache1 = &fakes.FakeCache{}
+		fakeCache1.EntriesReturns([]*dns.Msg{newMsg("example.com.", 30, "1.2.3.4")})
+		fakeCache2 = &fakes.FakeCache{}
+		fakeCache2.EntriesReturns([]*dns.Msg{newMsg("Other.Example.", 10, "5.6.7.8")})
+
+		handler = api.NewCacheHandler([]api.Cache{fakeCache1, fakeCache2})
+		w = httptest.NewRecorder()
+	})
+
+	It("lists the entries of all caches", func() {
+		r = httptest.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(w, r)
+
+		Expect(w.Result().StatusCode).To(Equal(http.StatusOK))
+		Expect(decodeEntries()).To(Equal([]api.CacheEntry{
+			{
+				Name:    "example.com.",
+				Type:    "A",
+				Rcode:   "NOERROR",
+				TTL:     30,
+				Records: []string{"example.com.\t30\tIN\tA\t1.2.3.4"},
+			},
+			{
+				Name:    "Other.Example.",
+				Type:    "A",
+				Rcode:   "NOERROR",
+				TTL:     10,
+				Records: []string{"Other.Example.\t10\tIN\tA\t5.6.7.8"},
+			},
+		}))
+	})
+
+	It("looks up a single name", func() {
+		r = httptest.NewRequest("GET", "/?name=other.example", nil)
+		handler.ServeHTTP(w, r)
+
+		entries := decodeEntries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Name).To(Equal("Other.Example."))
+	})
+})
+
+var _ = Describe("CacheFlushHandler", func() {
+	var (
+		fakeCache1 *fakes.FakeCache
+		fakeCache2 *fakes.FakeCache
+		handler    *api.CacheFlushHandler
+
+		w *httptest.ResponseRecorder
+	)
+
+	flushedResult := func() api.CacheFlushResult {
+		var result api.CacheFlushResult
+		Expect(json.NewDecoder(w.Result().Body).Decode(&result)).To(Succeed())
+		return result
+	}
+
+	BeforeEach(func() {
+		fakeCache1 = &fakes.FakeCache{}
+		fakeCache1.FlushReturns(2)
+		fakeCache2 = &fakes.FakeCache{}
+		fakeCache2.FlushReturns(1)
+
+		handler = api.NewCacheFlushHandler([]api.Cache{fakeCache1, fakeCache2})
+		w = httptest.NewRecorder()
+	})
+
+	It("only accepts POST requests", func() {
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		Expect(w.Result().StatusCode).To(Equal(http.StatusMethodNotAllowed))
+		Expect(fakeCache1.FlushCallCount()).To(Equal(0))
+	})
+
+	It("flushes everything when no name or suffix is given", func() {
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/", nil))
+		Expect(w.Result().StatusCode).To(Equal(http.StatusOK))
+		Expect(flushedResult()).To(Equal(api.CacheFlushResult{Flushed: 3}))
+
+		match := fakeCache1.FlushArgsForCall(0)
+		Expect(match("anything.")).To(BeTrue())
+		Expect(fakeCache2.FlushCallCount()).To(Equal(1))
+	})
+
+	It("flushes a single name", func() {
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/?name=Example.COM", nil))
+		Expect(w.Result().StatusCode).To(Equal(http.StatusOK))
+
+		match := fakeCache1.FlushArgsForCall(0)
+		Expect(match("example.com.")).To(BeTrue())
+		Expect(match("www.example.com.")).To(BeFalse())
+	})
+
+	It("flushes every name under a suffix", func() {
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/?suffix=example.com", nil))
+		Expect(w.Result().StatusCode).To(Equal(http.StatusOK))
+
+		match := fakeCache1.FlushArgsForCall(0)
+		Expect(match("example.com.")).To(BeTrue())
+		Expect(match("www.example.com.")).To(BeTrue())
+		Expect(match("notexample.com.")).To(BeFalse())
+	})
+
+	It("rejects requests with both a name and a suffix", func() {
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/?name=a.com&suffix=com", nil))
+		Expect(w.Result().StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(fakeCache1.FlushCallCount()).To(Equal(0))
+	})
+})
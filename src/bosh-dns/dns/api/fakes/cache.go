@@ -0,0 +1,173 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"bosh-dns/dns/api"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+type FakeCache struct {
+	EntriesStub        func() []*dns.Msg
+	entriesMutex       sync.RWMutex
+	entriesArgsForCall []struct {
+	}
+	entriesReturns struct {
+		result1 []*dns.Msg
+	}
+	entriesReturnsOnCall map[int]struct {
+		result1 []*dns.Msg
+	}
+	FlushStub        func(func(name string) bool) int
+	flushMutex       sync.RWMutex
+	flushArgsForCall []struct {
+		arg1 func(name string) bool
+	}
+	flushReturns struct {
+		result1 int
+	}
+	flushReturnsOnCall map[int]struct {
+		result1 int
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeCache) Entries() []*dns.Msg {
+	fake.entriesMutex.Lock()
+	ret, specificReturn := fake.entriesReturnsOnCall[len(fake.entriesArgsForCall)]
+	fake.entriesArgsForCall = append(fake.entriesArgsForCall, struct {
+	}{})
+	stub := fake.EntriesStub
+	fakeReturns := fake.entriesReturns
+	fake.recordInvocation("Entries", []interface{}{})
+	fake.entriesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeCache) EntriesCallCount() int {
+	fake.entriesMutex.RLock()
+	defer fake.entriesMutex.RUnlock()
+	return len(fake.entriesArgsForCall)
+}
+
+func (fake *FakeCache) EntriesCalls(stub func() []*dns.Msg) {
+	fake.entriesMutex.Lock()
+	defer fake.entriesMutex.Unlock()
+	fake.EntriesStub = stub
+}
+
+func (fake *FakeCache) EntriesReturns(result1 []*dns.Msg) {
+	fake.entriesMutex.Lock()
+	defer fake.entriesMutex.Unlock()
+	fake.EntriesStub = nil
+	fake.entriesReturns = struct {
+		result1 []*dns.Msg
+	}{result1}
+}
+
+func (fake *FakeCache) EntriesReturnsOnCall(i int, result1 []*dns.Msg) {
+	fake.entriesMutex.Lock()
+	defer fake.entriesMutex.Unlock()
+	fake.EntriesStub = nil
+	if fake.entriesReturnsOnCall == nil {
+		fake.entriesReturnsOnCall = make(map[int]struct {
+			result1 []*dns.Msg
+		})
+	}
+	fake.entriesReturnsOnCall[i] = struct {
+		result1 []*dns.Msg
+	}{result1}
+}
+
+func (fake *FakeCache) Flush(arg1 func(name string) bool) int {
+	fake.flushMutex.Lock()
+	ret, specificReturn := fake.flushReturnsOnCall[len(fake.flushArgsForCall)]
+	fake.flushArgsForCall = append(fake.flushArgsForCall, struct {
+		arg1 func(name string) bool
+	}{arg1})
+	stub := fake.FlushStub
+	fakeReturns := fake.flushReturns
+	fake.recordInvocation("Flush", []interface{}{arg1})
+	fake.flushMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeCache) FlushCallCount() int {
+	fake.flushMutex.RLock()
+	defer fake.flushMutex.RUnlock()
+	return len(fake.flushArgsForCall)
+}
+
+func (fake *FakeCache) FlushCalls(stub func(func(name string) bool) int) {
+	fake.flushMutex.Lock()
+	defer fake.flushMutex.Unlock()
+	fake.FlushStub = stub
+}
+
+func (fake *FakeCache) FlushArgsForCall(i int) func(name string) bool {
+	fake.flushMutex.RLock()
+	defer fake.flushMutex.RUnlock()
+	argsForCall := fake.flushArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCache) FlushReturns(result1 int) {
+	fake.flushMutex.Lock()
+	defer fake.flushMutex.Unlock()
+	fake.FlushStub = nil
+	fake.flushReturns = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *FakeCache) FlushReturnsOnCall(i int, result1 int) {
+	fake.flushMutex.Lock()
+	defer fake.flushMutex.Unlock()
+	fake.FlushStub = nil
+	if fake.flushReturnsOnCall == nil {
+		fake.flushReturnsOnCall = make(map[int]struct {
+			result1 int
+		})
+	}
+	fake.flushReturnsOnCall[i] = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *FakeCache) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeCache) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ api.Cache = new(FakeCache)
@@ -0,0 +1,186 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"bosh-dns/dns/api"
+	apia "bosh-dns/healthcheck/api"
+	"sync"
+)
+
+type FakeHealthChecks struct {
+	HealthCheckScheduleStub        func(string) (apia.HealthCheckSchedule, bool)
+	healthCheckScheduleMutex       sync.RWMutex
+	healthCheckScheduleArgsForCall []struct {
+		arg1 string
+	}
+	healthCheckScheduleReturns struct {
+		result1 apia.HealthCheckSchedule
+		result2 bool
+	}
+	healthCheckScheduleReturnsOnCall map[int]struct {
+		result1 apia.HealthCheckSchedule
+		result2 bool
+	}
+	HealthStateStringStub        func(string) string
+	healthStateStringMutex       sync.RWMutex
+	healthStateStringArgsForCall []struct {
+		arg1 string
+	}
+	healthStateStringReturns struct {
+		result1 string
+	}
+	healthStateStringReturnsOnCall map[int]struct {
+		result1 string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeHealthChecks) HealthCheckSchedule(arg1 string) (apia.HealthCheckSchedule, bool) {
+	fake.healthCheckScheduleMutex.Lock()
+	ret, specificReturn := fake.healthCheckScheduleReturnsOnCall[len(fake.healthCheckScheduleArgsForCall)]
+	fake.healthCheckScheduleArgsForCall = append(fake.healthCheckScheduleArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.HealthCheckScheduleStub
+	fakeReturns := fake.healthCheckScheduleReturns
+	fake.recordInvocation("HealthCheckSchedule", []interface{}{arg1})
+	fake.healthCheckScheduleMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHealthChecks) HealthCheckScheduleCallCount() int {
+	fake.healthCheckScheduleMutex.RLock()
+	defer fake.healthCheckScheduleMutex.RUnlock()
+	return len(fake.healthCheckScheduleArgsForCall)
+}
+
+func (fake *FakeHealthChecks) HealthCheckScheduleCalls(stub func(string) (apia.HealthCheckSchedule, bool)) {
+	fake.healthCheckScheduleMutex.Lock()
+	defer fake.healthCheckScheduleMutex.Unlock()
+	fake.HealthCheckScheduleStub = stub
+}
+
+func (fake *FakeHealthChecks) HealthCheckScheduleArgsForCall(i int) string {
+	fake.healthCheckScheduleMutex.RLock()
+	defer fake.healthCheckScheduleMutex.RUnlock()
+	argsForCall := fake.healthCheckScheduleArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHealthChecks) HealthCheckScheduleReturns(result1 apia.HealthCheckSchedule, result2 bool) {
+	fake.healthCheckScheduleMutex.Lock()
+	defer fake.healthCheckScheduleMutex.Unlock()
+	fake.HealthCheckScheduleStub = nil
+	fake.healthCheckScheduleReturns = struct {
+		result1 apia.HealthCheckSchedule
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecks) HealthCheckScheduleReturnsOnCall(i int, result1 apia.HealthCheckSchedule, result2 bool) {
+	fake.healthCheckScheduleMutex.Lock()
+	defer fake.healthCheckScheduleMutex.Unlock()
+	fake.HealthCheckScheduleStub = nil
+	if fake.healthCheckScheduleReturnsOnCall == nil {
+		fake.healthCheckScheduleReturnsOnCall = make(map[int]struct {
+			result1 apia.HealthCheckSchedule
+			result2 bool
+		})
+	}
+	fake.healthCheckScheduleReturnsOnCall[i] = struct {
+		result1 apia.HealthCheckSchedule
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecks) HealthStateString(arg1 string) string {
+	fake.healthStateStringMutex.Lock()
+	ret, specificReturn := fake.healthStateStringReturnsOnCall[len(fake.healthStateStringArgsForCall)]
+	fake.healthStateStringArgsForCall = append(fake.healthStateStringArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.HealthStateStringStub
+	fakeReturns := fake.healthStateStringReturns
+	fake.recordInvocation("HealthStateString", []interface{}{arg1})
+	fake.healthStateStringMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHealthChecks) HealthStateStringCallCount() int {
+	fake.healthStateStringMutex.RLock()
+	defer fake.healthStateStringMutex.RUnlock()
+	return len(fake.healthStateStringArgsForCall)
+}
+
+func (fake *FakeHealthChecks) HealthStateStringCalls(stub func(string) string) {
+	fake.healthStateStringMutex.Lock()
+	defer fake.healthStateStringMutex.Unlock()
+	fake.HealthStateStringStub = stub
+}
+
+func (fake *FakeHealthChecks) HealthStateStringArgsForCall(i int) string {
+	fake.healthStateStringMutex.RLock()
+	defer fake.healthStateStringMutex.RUnlock()
+	argsForCall := fake.healthStateStringArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHealthChecks) HealthStateStringReturns(result1 string) {
+	fake.healthStateStringMutex.Lock()
+	defer fake.healthStateStringMutex.Unlock()
+	fake.HealthStateStringStub = nil
+	fake.healthStateStringReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeHealthChecks) HealthStateStringReturnsOnCall(i int, result1 string) {
+	fake.healthStateStringMutex.Lock()
+	defer fake.healthStateStringMutex.Unlock()
+	fake.HealthStateStringStub = nil
+	if fake.healthStateStringReturnsOnCall == nil {
+		fake.healthStateStringReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.healthStateStringReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeHealthChecks) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeHealthChecks) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ api.HealthChecks = new(FakeHealthChecks)
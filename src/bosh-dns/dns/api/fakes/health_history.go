@@ -0,0 +1,109 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"bosh-dns/dns/api"
+	apia "bosh-dns/healthcheck/api"
+	"sync"
+)
+
+type FakeHealthHistory struct {
+	HealthTransitionsStub        func(string) []apia.HealthTransition
+	healthTransitionsMutex       sync.RWMutex
+	healthTransitionsArgsForCall []struct {
+		arg1 string
+	}
+	healthTransitionsReturns struct {
+		result1 []apia.HealthTransition
+	}
+	healthTransitionsReturnsOnCall map[int]struct {
+		result1 []apia.HealthTransition
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeHealthHistory) HealthTransitions(arg1 string) []apia.HealthTransition {
+	fake.healthTransitionsMutex.Lock()
+	ret, specificReturn := fake.healthTransitionsReturnsOnCall[len(fake.healthTransitionsArgsForCall)]
+	fake.healthTransitionsArgsForCall = append(fake.healthTransitionsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.HealthTransitionsStub
+	fakeReturns := fake.healthTransitionsReturns
+	fake.recordInvocation("HealthTransitions", []interface{}{arg1})
+	fake.healthTransitionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHealthHistory) HealthTransitionsCallCount() int {
+	fake.healthTransitionsMutex.RLock()
+	defer fake.healthTransitionsMutex.RUnlock()
+	return len(fake.healthTransitionsArgsForCall)
+}
+
+func (fake *FakeHealthHistory) HealthTransitionsCalls(stub func(string) []apia.HealthTransition) {
+	fake.healthTransitionsMutex.Lock()
+	defer fake.healthTransitionsMutex.Unlock()
+	fake.HealthTransitionsStub = stub
+}
+
+func (fake *FakeHealthHistory) HealthTransitionsArgsForCall(i int) string {
+	fake.healthTransitionsMutex.RLock()
+	defer fake.healthTransitionsMutex.RUnlock()
+	argsForCall := fake.healthTransitionsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHealthHistory) HealthTransitionsReturns(result1 []apia.HealthTransition) {
+	fake.healthTransitionsMutex.Lock()
+	defer fake.healthTransitionsMutex.Unlock()
+	fake.HealthTransitionsStub = nil
+	fake.healthTransitionsReturns = struct {
+		result1 []apia.HealthTransition
+	}{result1}
+}
+
+func (fake *FakeHealthHistory) HealthTransitionsReturnsOnCall(i int, result1 []apia.HealthTransition) {
+	fake.healthTransitionsMutex.Lock()
+	defer fake.healthTransitionsMutex.Unlock()
+	fake.HealthTransitionsStub = nil
+	if fake.healthTransitionsReturnsOnCall == nil {
+		fake.healthTransitionsReturnsOnCall = make(map[int]struct {
+			result1 []apia.HealthTransition
+		})
+	}
+	fake.healthTransitionsReturnsOnCall[i] = struct {
+		result1 []apia.HealthTransition
+	}{result1}
+}
+
+func (fake *FakeHealthHistory) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeHealthHistory) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ api.HealthHistory = new(FakeHealthHistory)
@@ -0,0 +1,100 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"bosh-dns/dns/api"
+	"bosh-dns/dns/server/record"
+	"sync"
+)
+
+type FakeRecordsConflicts struct {
+	ConflictsStub        func() []record.Conflict
+	conflictsMutex       sync.RWMutex
+	conflictsArgsForCall []struct {
+	}
+	conflictsReturns struct {
+		result1 []record.Conflict
+	}
+	conflictsReturnsOnCall map[int]struct {
+		result1 []record.Conflict
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRecordsConflicts) Conflicts() []record.Conflict {
+	fake.conflictsMutex.Lock()
+	ret, specificReturn := fake.conflictsReturnsOnCall[len(fake.conflictsArgsForCall)]
+	fake.conflictsArgsForCall = append(fake.conflictsArgsForCall, struct {
+	}{})
+	stub := fake.ConflictsStub
+	fakeReturns := fake.conflictsReturns
+	fake.recordInvocation("Conflicts", []interface{}{})
+	fake.conflictsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRecordsConflicts) ConflictsCallCount() int {
+	fake.conflictsMutex.RLock()
+	defer fake.conflictsMutex.RUnlock()
+	return len(fake.conflictsArgsForCall)
+}
+
+func (fake *FakeRecordsConflicts) ConflictsCalls(stub func() []record.Conflict) {
+	fake.conflictsMutex.Lock()
+	defer fake.conflictsMutex.Unlock()
+	fake.ConflictsStub = stub
+}
+
+func (fake *FakeRecordsConflicts) ConflictsReturns(result1 []record.Conflict) {
+	fake.conflictsMutex.Lock()
+	defer fake.conflictsMutex.Unlock()
+	fake.ConflictsStub = nil
+	fake.conflictsReturns = struct {
+		result1 []record.Conflict
+	}{result1}
+}
+
+func (fake *FakeRecordsConflicts) ConflictsReturnsOnCall(i int, result1 []record.Conflict) {
+	fake.conflictsMutex.Lock()
+	defer fake.conflictsMutex.Unlock()
+	fake.ConflictsStub = nil
+	if fake.conflictsReturnsOnCall == nil {
+		fake.conflictsReturnsOnCall = make(map[int]struct {
+			result1 []record.Conflict
+		})
+	}
+	fake.conflictsReturnsOnCall[i] = struct {
+		result1 []record.Conflict
+	}{result1}
+}
+
+func (fake *FakeRecordsConflicts) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRecordsConflicts) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ api.RecordsConflicts = new(FakeRecordsConflicts)
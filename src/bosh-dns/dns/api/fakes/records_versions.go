@@ -0,0 +1,168 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"bosh-dns/dns/api"
+	"bosh-dns/dns/server/record"
+	"sync"
+)
+
+type FakeRecordsVersions struct {
+	AcceptRecordsStub        func() ([]record.BlobVersion, error)
+	acceptRecordsMutex       sync.RWMutex
+	acceptRecordsArgsForCall []struct {
+	}
+	acceptRecordsReturns struct {
+		result1 []record.BlobVersion
+		result2 error
+	}
+	acceptRecordsReturnsOnCall map[int]struct {
+		result1 []record.BlobVersion
+		result2 error
+	}
+	HistoryStub        func() []record.BlobVersion
+	historyMutex       sync.RWMutex
+	historyArgsForCall []struct {
+	}
+	historyReturns struct {
+		result1 []record.BlobVersion
+	}
+	historyReturnsOnCall map[int]struct {
+		result1 []record.BlobVersion
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRecordsVersions) AcceptRecords() ([]record.BlobVersion, error) {
+	fake.acceptRecordsMutex.Lock()
+	ret, specificReturn := fake.acceptRecordsReturnsOnCall[len(fake.acceptRecordsArgsForCall)]
+	fake.acceptRecordsArgsForCall = append(fake.acceptRecordsArgsForCall, struct {
+	}{})
+	stub := fake.AcceptRecordsStub
+	fakeReturns := fake.acceptRecordsReturns
+	fake.recordInvocation("AcceptRecords", []interface{}{})
+	fake.acceptRecordsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRecordsVersions) AcceptRecordsCallCount() int {
+	fake.acceptRecordsMutex.RLock()
+	defer fake.acceptRecordsMutex.RUnlock()
+	return len(fake.acceptRecordsArgsForCall)
+}
+
+func (fake *FakeRecordsVersions) AcceptRecordsCalls(stub func() ([]record.BlobVersion, error)) {
+	fake.acceptRecordsMutex.Lock()
+	defer fake.acceptRecordsMutex.Unlock()
+	fake.AcceptRecordsStub = stub
+}
+
+func (fake *FakeRecordsVersions) AcceptRecordsReturns(result1 []record.BlobVersion, result2 error) {
+	fake.acceptRecordsMutex.Lock()
+	defer fake.acceptRecordsMutex.Unlock()
+	fake.AcceptRecordsStub = nil
+	fake.acceptRecordsReturns = struct {
+		result1 []record.BlobVersion
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRecordsVersions) AcceptRecordsReturnsOnCall(i int, result1 []record.BlobVersion, result2 error) {
+	fake.acceptRecordsMutex.Lock()
+	defer fake.acceptRecordsMutex.Unlock()
+	fake.AcceptRecordsStub = nil
+	if fake.acceptRecordsReturnsOnCall == nil {
+		fake.acceptRecordsReturnsOnCall = make(map[int]struct {
+			result1 []record.BlobVersion
+			result2 error
+		})
+	}
+	fake.acceptRecordsReturnsOnCall[i] = struct {
+		result1 []record.BlobVersion
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRecordsVersions) History() []record.BlobVersion {
+	fake.historyMutex.Lock()
+	ret, specificReturn := fake.historyReturnsOnCall[len(fake.historyArgsForCall)]
+	fake.historyArgsForCall = append(fake.historyArgsForCall, struct {
+	}{})
+	stub := fake.HistoryStub
+	fakeReturns := fake.historyReturns
+	fake.recordInvocation("History", []interface{}{})
+	fake.historyMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRecordsVersions) HistoryCallCount() int {
+	fake.historyMutex.RLock()
+	defer fake.historyMutex.RUnlock()
+	return len(fake.historyArgsForCall)
+}
+
+func (fake *FakeRecordsVersions) HistoryCalls(stub func() []record.BlobVersion) {
+	fake.historyMutex.Lock()
+	defer fake.historyMutex.Unlock()
+	fake.HistoryStub = stub
+}
+
+func (fake *FakeRecordsVersions) HistoryReturns(result1 []record.BlobVersion) {
+	fake.historyMutex.Lock()
+	defer fake.historyMutex.Unlock()
+	fake.HistoryStub = nil
+	fake.historyReturns = struct {
+		result1 []record.BlobVersion
+	}{result1}
+}
+
+func (fake *FakeRecordsVersions) HistoryReturnsOnCall(i int, result1 []record.BlobVersion) {
+	fake.historyMutex.Lock()
+	defer fake.historyMutex.Unlock()
+	fake.HistoryStub = nil
+	if fake.historyReturnsOnCall == nil {
+		fake.historyReturnsOnCall = make(map[int]struct {
+			result1 []record.BlobVersion
+		})
+	}
+	fake.historyReturnsOnCall[i] = struct {
+		result1 []record.BlobVersion
+	}{result1}
+}
+
+func (fake *FakeRecordsVersions) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRecordsVersions) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ api.RecordsVersions = new(FakeRecordsVersions)
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	healthapi "bosh-dns/healthcheck/api"
+)
+
+//counterfeiter:generate -o ./fakes/health_checks.go . HealthChecks
+type HealthChecks interface {
+	HealthStateString(ip string) string
+	HealthCheckSchedule(ip string) (healthapi.HealthCheckSchedule, bool)
+}
+
+// HealthChecksHandler serves when the instances matching the address query
+// parameter were last checked and when they are checked next. Instances that
+// have not been checked yet are left out.
+type HealthChecksHandler struct {
+	recordManager RecordManager
+	checks        HealthChecks
+}
+
+func NewHealthChecksHandler(recordManager RecordManager, checks HealthChecks) *HealthChecksHandler {
+	return &HealthChecksHandler{
+		recordManager: recordManager,
+		checks:        checks,
+	}
+}
+
+func (h *HealthChecksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ips, err := resolveIPs(h.recordManager, r.URL.Query().Get("address"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(err.Error())) //nolint:errcheck
+		return
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, ip := range ips {
+		schedule, found := h.checks.HealthCheckSchedule(ip)
+		if !found {
+			continue
+		}
+
+		encoder.Encode(HealthCheck{ //nolint:errcheck
+			IP:           ip,
+			HealthState:  h.checks.HealthStateString(ip),
+			LastCheck:    schedule.LastCheck,
+			NextCheck:    schedule.NextCheck,
+			Interval:     schedule.Interval.String(),
+			StableChecks: schedule.StableChecks,
+		})
+	}
+}
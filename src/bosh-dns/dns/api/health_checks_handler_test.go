@@ -0,0 +1,105 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bosh-dns/dns/api"
+	"bosh-dns/dns/api/fakes"
+	"bosh-dns/dns/server/record"
+	healthapi "bosh-dns/healthcheck/api"
+)
+
+var _ = Describe("HealthChecksHandler", func() {
+	var (
+		fakeHealthChecks  *fakes.FakeHealthChecks
+		fakeRecordManager *fakes.FakeRecordManager
+		handler           *api.HealthChecksHandler
+		at                time.Time
+
+		w *httptest.ResponseRecorder
+		r *http.Request
+	)
+
+	BeforeEach(func() {
+		at = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		fakeHealthChecks = &fakes.FakeHealthChecks{}
+		fakeRecordManager = &fakes.FakeRecordManager{}
+		fakeHealthChecks.HealthCheckScheduleStub = func(ip string) (healthapi.HealthCheckSchedule, bool) {
+			switch ip {
+			case "10.0.0.1":
+				return healthapi.HealthCheckSchedule{LastCheck: at, NextCheck: at.Add(35 * time.Second), Interval: 40 * time.Second, StableChecks: 13}, true
+			case "10.0.0.2":
+				return healthapi.HealthCheckSchedule{LastCheck: at, NextCheck: at.Add(9 * time.Second), Interval: 10 * time.Second}, true
+			}
+			return healthapi.HealthCheckSchedule{}, false
+		}
+		fakeHealthChecks.HealthStateStringStub = func(ip string) string {
+			if ip == "10.0.0.1" {
+				return "running"
+			}
+			return "failing"
+		}
+		fakeRecordManager.AllRecordsReturns([]record.Record{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}, {IP: "10.0.0.3"}, {IP: "10.0.0.1"}})
+
+		// URL path doesn't matter here since routing is handled elsewhere
+		r = httptest.NewRequest("GET", "/", nil)
+		w = httptest.NewRecorder()
+	})
+
+	JustBeforeEach(func() {
+		handler = api.NewHealthChecksHandler(fakeRecordManager, fakeHealthChecks)
+		handler.ServeHTTP(w, r)
+	})
+
+	decodeChecks := func() []api.HealthCheck {
+		response := w.Result()
+		Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+		checks := []api.HealthCheck{}
+		decoder := json.NewDecoder(response.Body)
+		for decoder.More() {
+			var check api.HealthCheck
+			Expect(decoder.Decode(&check)).To(Succeed())
+			checks = append(checks, check)
+		}
+
+		return checks
+	}
+
+	It("returns the schedule of every checked instance once", func() {
+		Expect(decodeChecks()).To(Equal([]api.HealthCheck{
+			{IP: "10.0.0.1", HealthState: "running", LastCheck: at, NextCheck: at.Add(35 * time.Second), Interval: "40s", StableChecks: 13},
+			{IP: "10.0.0.2", HealthState: "failing", LastCheck: at, NextCheck: at.Add(9 * time.Second), Interval: "10s"},
+		}))
+	})
+
+	Context("when the address is an IP", func() {
+		BeforeEach(func() {
+			r = httptest.NewRequest("GET", "/?address=10.0.0.2", nil)
+		})
+
+		It("returns the schedule of the IP", func() {
+			Expect(decodeChecks()).To(Equal([]api.HealthCheck{
+				{IP: "10.0.0.2", HealthState: "failing", LastCheck: at, NextCheck: at.Add(9 * time.Second), Interval: "10s"},
+			}))
+		})
+	})
+
+	Context("when the address cannot be resolved", func() {
+		BeforeEach(func() {
+			r = httptest.NewRequest("GET", "/?address=my-alias", nil)
+			fakeRecordManager.ResolveRecordsReturns(nil, errors.New("no records"))
+		})
+
+		It("returns unprocessable entity", func() {
+			Expect(w.Result().StatusCode).To(Equal(http.StatusUnprocessableEntity))
+		})
+	})
+})
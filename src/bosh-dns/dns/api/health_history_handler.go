@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/miekg/dns"
+
+	"bosh-dns/dns/server/record"
+	healthapi "bosh-dns/healthcheck/api"
+)
+
+//counterfeiter:generate -o ./fakes/health_history.go . HealthHistory
+type HealthHistory interface {
+	HealthTransitions(ip string) []healthapi.HealthTransition
+}
+
+// HealthHistoryHandler serves the recent health transitions of the instances
+// matching the address query parameter, which is either an IP or a name
+// resolving to instances. Without an address all instances are included.
+type HealthHistoryHandler struct {
+	recordManager RecordManager
+	history       HealthHistory
+}
+
+func NewHealthHistoryHandler(recordManager RecordManager, history HealthHistory) *HealthHistoryHandler {
+	return &HealthHistoryHandler{
+		recordManager: recordManager,
+		history:       history,
+	}
+}
+
+func (h *HealthHistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+
+	ips, err := resolveIPs(h.recordManager, address)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(err.Error())) //nolint:errcheck
+		return
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, ip := range ips {
+		for _, transition := range h.history.HealthTransitions(ip) {
+			encoder.Encode(HealthTransition{ //nolint:errcheck
+				IP:    ip,
+				Group: transition.Group,
+				From:  string(transition.From),
+				To:    string(transition.To),
+				At:    transition.At,
+			})
+		}
+	}
+}
+
+// resolveIPs returns the address when it is an IP, otherwise the IPs of the
+// instances the address resolves to, or of all instances when it is empty.
+func resolveIPs(recordManager RecordManager, address string) ([]string, error) {
+	if net.ParseIP(address) != nil {
+		return []string{address}, nil
+	}
+
+	var rs []record.Record
+	if address == "" {
+		rs = recordManager.AllRecords()
+	} else {
+		var err error
+		rs, err = recordManager.ResolveRecords(recordManager.ExpandAliases(dns.Fqdn(address)), false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var ips []string
+	seen := map[string]bool{}
+	for _, rcd := range rs {
+		if !seen[rcd.IP] {
+			seen[rcd.IP] = true
+			ips = append(ips, rcd.IP)
+		}
+	}
+
+	return ips, nil
+}
@@ -0,0 +1,129 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bosh-dns/dns/api"
+	"bosh-dns/dns/api/fakes"
+	"bosh-dns/dns/server/record"
+	healthapi "bosh-dns/healthcheck/api"
+)
+
+var _ = Describe("HealthHistoryHandler", func() {
+	var (
+		fakeHealthHistory *fakes.FakeHealthHistory
+		fakeRecordManager *fakes.FakeRecordManager
+		handler           *api.HealthHistoryHandler
+		at                time.Time
+
+		w *httptest.ResponseRecorder
+		r *http.Request
+	)
+
+	BeforeEach(func() {
+		at = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		fakeHealthHistory = &fakes.FakeHealthHistory{}
+		fakeRecordManager = &fakes.FakeRecordManager{}
+		fakeHealthHistory.HealthTransitionsStub = func(ip string) []healthapi.HealthTransition {
+			switch ip {
+			case "10.0.0.1":
+				return []healthapi.HealthTransition{
+					{From: "unchecked", To: healthapi.StatusRunning, At: at},
+					{Group: "1", From: healthapi.StatusRunning, To: healthapi.StatusFailing, At: at.Add(time.Minute)},
+				}
+			case "10.0.0.2":
+				return []healthapi.HealthTransition{
+					{From: "unchecked", To: healthapi.StatusFailing, At: at},
+				}
+			}
+			return []healthapi.HealthTransition{}
+		}
+		fakeRecordManager.AllRecordsReturns([]record.Record{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}, {IP: "10.0.0.1"}})
+
+		// URL path doesn't matter here since routing is handled elsewhere
+		r = httptest.NewRequest("GET", "/", nil)
+		w = httptest.NewRecorder()
+	})
+
+	JustBeforeEach(func() {
+		handler = api.NewHealthHistoryHandler(fakeRecordManager, fakeHealthHistory)
+		handler.ServeHTTP(w, r)
+	})
+
+	decodeTransitions := func() []api.HealthTransition {
+		response := w.Result()
+		Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+		transitions := []api.HealthTransition{}
+		decoder := json.NewDecoder(response.Body)
+		for decoder.More() {
+			var transition api.HealthTransition
+			Expect(decoder.Decode(&transition)).To(Succeed())
+			transitions = append(transitions, transition)
+		}
+
+		return transitions
+	}
+
+	It("returns the transitions of every instance once", func() {
+		Expect(decodeTransitions()).To(Equal([]api.HealthTransition{
+			{IP: "10.0.0.1", From: "unchecked", To: "running", At: at},
+			{IP: "10.0.0.1", Group: "1", From: "running", To: "failing", At: at.Add(time.Minute)},
+			{IP: "10.0.0.2", From: "unchecked", To: "failing", At: at},
+		}))
+	})
+
+	Context("when the address is an IP", func() {
+		BeforeEach(func() {
+			r = httptest.NewRequest("GET", "/?address=10.0.0.2", nil)
+		})
+
+		It("returns the transitions of the IP", func() {
+			Expect(decodeTransitions()).To(Equal([]api.HealthTransition{
+				{IP: "10.0.0.2", From: "unchecked", To: "failing", At: at},
+			}))
+			Expect(fakeRecordManager.ResolveRecordsCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the address is a name", func() {
+		BeforeEach(func() {
+			r = httptest.NewRequest("GET", "/?address=my-alias", nil)
+			fakeRecordManager.ExpandAliasesReturns([]string{"q-s0.my-group.my-network.my-deployment.bosh."})
+			fakeRecordManager.ResolveRecordsReturns([]record.Record{{IP: "10.0.0.2"}}, nil)
+		})
+
+		It("returns the transitions of the instances it resolves to", func() {
+			Expect(decodeTransitions()).To(Equal([]api.HealthTransition{
+				{IP: "10.0.0.2", From: "unchecked", To: "failing", At: at},
+			}))
+
+			Expect(fakeRecordManager.ExpandAliasesArgsForCall(0)).To(Equal("my-alias."))
+			domains, shouldTrack := fakeRecordManager.ResolveRecordsArgsForCall(0)
+			Expect(domains).To(Equal([]string{"q-s0.my-group.my-network.my-deployment.bosh."}))
+			Expect(shouldTrack).To(BeFalse())
+		})
+
+		Context("when the name cannot be resolved", func() {
+			BeforeEach(func() {
+				fakeRecordManager.ResolveRecordsReturns(nil, errors.New("no records"))
+			})
+
+			It("returns unprocessable entity", func() {
+				response := w.Result()
+				Expect(response.StatusCode).To(Equal(http.StatusUnprocessableEntity))
+				body, err := io.ReadAll(response.Body)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(body)).To(Equal("no records"))
+			})
+		})
+	})
+})
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"bosh-dns/dns/server/record"
+)
+
+//counterfeiter:generate -o ./fakes/records_versions.go . RecordsVersions
+type RecordsVersions interface {
+	History() []record.BlobVersion
+	AcceptRecords() ([]record.BlobVersion, error)
+}
+
+//counterfeiter:generate -o ./fakes/records_conflicts.go . RecordsConflicts
+type RecordsConflicts interface {
+	Conflicts() []record.Conflict
+}
+
+//counterfeiter:generate -o ./fakes/records_validation.go . RecordsValidation
+type RecordsValidation interface {
+	Validation() []record.Problem
+}
+
+type RecordsHistoryHandler struct {
+	versions RecordsVersions
+}
+
+func NewRecordsHistoryHandler(versions RecordsVersions) *RecordsHistoryHandler {
+	return &RecordsHistoryHandler{
+		versions: versions,
+	}
+}
+
+func (h *RecordsHistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+	for _, applied := range h.versions.History() {
+		encoder.Encode(newRecordsVersion(applied)) //nolint:errcheck
+	}
+}
+
+// RecordsAcceptHandler applies the current records files even when their
+// version is older than the applied one.
+type RecordsAcceptHandler struct {
+	versions RecordsVersions
+}
+
+func NewRecordsAcceptHandler(versions RecordsVersions) *RecordsAcceptHandler {
+	return &RecordsAcceptHandler{
+		versions: versions,
+	}
+}
+
+func (h *RecordsAcceptHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	applied, err := h.versions.AcceptRecords()
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(err.Error())) //nolint:errcheck
+		return
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, version := range applied {
+		encoder.Encode(newRecordsVersion(version)) //nolint:errcheck
+	}
+}
+
+type RecordsConflictsHandler struct {
+	conflicts RecordsConflicts
+}
+
+func NewRecordsConflictsHandler(conflicts RecordsConflicts) *RecordsConflictsHandler {
+	return &RecordsConflictsHandler{
+		conflicts: conflicts,
+	}
+}
+
+func (h *RecordsConflictsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+	for _, conflict := range h.conflicts.Conflicts() {
+		encoder.Encode(RecordsConflict{ //nolint:errcheck
+			Kind:    conflict.Kind,
+			Value:   conflict.Value,
+			Sources: conflict.Sources,
+		})
+	}
+}
+
+// RecordsValidationHandler lists the problems found in the applied records
+// files.
+type RecordsValidationHandler struct {
+	validation RecordsValidation
+}
+
+func NewRecordsValidationHandler(validation RecordsValidation) *RecordsValidationHandler {
+	return &RecordsValidationHandler{
+		validation: validation,
+	}
+}
+
+func (h *RecordsValidationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+	for _, problem := range h.validation.Validation() {
+		encoder.Encode(RecordsProblem{ //nolint:errcheck
+			Kind:    problem.Kind,
+			Source:  problem.Source,
+			Line:    problem.Line,
+			Record:  problem.Record,
+			Field:   problem.Field,
+			Message: problem.Message,
+		})
+	}
+}
+
+func newRecordsVersion(applied record.BlobVersion) RecordsVersion {
+	return RecordsVersion{
+		Source:    applied.Source,
+		Version:   applied.Version,
+		AppliedAt: applied.AppliedAt,
+		Records:   applied.Records,
+		Added:     applied.Added,
+		Removed:   applied.Removed,
+		Forced:    applied.Forced,
+	}
+}
@@ -0,0 +1,168 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bosh-dns/dns/api"
+	"bosh-dns/dns/api/fakes"
+	"bosh-dns/dns/server/record"
+)
+
+var _ = Describe("RecordsHistoryHandler", func() {
+	var (
+		fakeVersions *fakes.FakeRecordsVersions
+		handler      *api.RecordsHistoryHandler
+		appliedAt    time.Time
+
+		w *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		appliedAt = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		fakeVersions = &fakes.FakeRecordsVersions{}
+		fakeVersions.HistoryReturns([]record.BlobVersion{
+			{Source: "/records.json", Version: 7, AppliedAt: appliedAt, Records: 3, Added: 1, Removed: 2, Forced: true},
+			{Source: "/records.json", Version: 8, AppliedAt: appliedAt.Add(-time.Minute), Records: 4, Added: 4},
+		})
+
+		handler = api.NewRecordsHistoryHandler(fakeVersions)
+		w = httptest.NewRecorder()
+	})
+
+	It("lists the applied versions", func() {
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		Expect(w.Result().StatusCode).To(Equal(http.StatusOK))
+
+		versions := []api.RecordsVersion{}
+		decoder := json.NewDecoder(w.Result().Body)
+		for decoder.More() {
+			var version api.RecordsVersion
+			Expect(decoder.Decode(&version)).To(Succeed())
+			versions = append(versions, version)
+		}
+
+		Expect(versions).To(Equal([]api.RecordsVersion{
+			{Source: "/records.json", Version: 7, AppliedAt: appliedAt, Records: 3, Added: 1, Removed: 2, Forced: true},
+			{Source: "/records.json", Version: 8, AppliedAt: appliedAt.Add(-time.Minute), Records: 4, Added: 4},
+		}))
+	})
+})
+
+var _ = Describe("RecordsAcceptHandler", func() {
+	var (
+		fakeVersions *fakes.FakeRecordsVersions
+		handler      *api.RecordsAcceptHandler
+
+		w *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		fakeVersions = &fakes.FakeRecordsVersions{}
+		handler = api.NewRecordsAcceptHandler(fakeVersions)
+		w = httptest.NewRecorder()
+	})
+
+	It("only accepts POST requests", func() {
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		Expect(w.Result().StatusCode).To(Equal(http.StatusMethodNotAllowed))
+		Expect(fakeVersions.AcceptRecordsCallCount()).To(Equal(0))
+	})
+
+	It("returns the versions that were applied", func() {
+		appliedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		fakeVersions.AcceptRecordsReturns([]record.BlobVersion{
+			{Source: "/records.json", Version: 3, AppliedAt: appliedAt, Records: 1, Removed: 1, Forced: true},
+			{Source: "/other.json", Version: 9, AppliedAt: appliedAt, Records: 2, Forced: true},
+		}, nil)
+
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/", nil))
+		Expect(w.Result().StatusCode).To(Equal(http.StatusOK))
+
+		versions := []api.RecordsVersion{}
+		decoder := json.NewDecoder(w.Result().Body)
+		for decoder.More() {
+			var version api.RecordsVersion
+			Expect(decoder.Decode(&version)).To(Succeed())
+			versions = append(versions, version)
+		}
+
+		Expect(versions).To(Equal([]api.RecordsVersion{
+			{Source: "/records.json", Version: 3, AppliedAt: appliedAt, Records: 1, Removed: 1, Forced: true},
+			{Source: "/other.json", Version: 9, AppliedAt: appliedAt, Records: 2, Forced: true},
+		}))
+	})
+
+	It("reports records that cannot be applied", func() {
+		fakeVersions.AcceptRecordsReturns(nil, errors.New("fake-err"))
+
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/", nil))
+		Expect(w.Result().StatusCode).To(Equal(http.StatusUnprocessableEntity))
+
+		body, err := io.ReadAll(w.Result().Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(Equal("fake-err"))
+	})
+})
+
+var _ = Describe("RecordsConflictsHandler", func() {
+	It("lists the conflicts between records sources", func() {
+		fakeConflicts := &fakes.FakeRecordsConflicts{}
+		fakeConflicts.ConflictsReturns([]record.Conflict{
+			{Kind: record.DomainConflict, Value: "bosh.", Sources: []string{"/records.json", "/other.json"}},
+			{Kind: record.IPConflict, Value: "10.0.0.1", Sources: []string{"/records.json", "/other.json"}},
+		})
+
+		w := httptest.NewRecorder()
+		api.NewRecordsConflictsHandler(fakeConflicts).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		Expect(w.Result().StatusCode).To(Equal(http.StatusOK))
+
+		conflicts := []api.RecordsConflict{}
+		decoder := json.NewDecoder(w.Result().Body)
+		for decoder.More() {
+			var conflict api.RecordsConflict
+			Expect(decoder.Decode(&conflict)).To(Succeed())
+			conflicts = append(conflicts, conflict)
+		}
+
+		Expect(conflicts).To(Equal([]api.RecordsConflict{
+			{Kind: "domain", Value: "bosh.", Sources: []string{"/records.json", "/other.json"}},
+			{Kind: "ip", Value: "10.0.0.1", Sources: []string{"/records.json", "/other.json"}},
+		}))
+	})
+})
+
+var _ = Describe("RecordsValidationHandler", func() {
+	It("lists the problems of the records files", func() {
+		fakeValidation := &fakes.FakeRecordsValidation{}
+		fakeValidation.ValidationReturns([]record.Problem{
+			{Kind: record.UnbalancedRowProblem, Source: "/records.json", Line: 4, Record: 2, Message: "Found 6 fields of an expected 7 at record #2"},
+			{Kind: record.DuplicateIPProblem, Source: "/records.json", Field: "ip", Message: "IP 10.0.0.1 is used by instances a, b"},
+		})
+
+		w := httptest.NewRecorder()
+		api.NewRecordsValidationHandler(fakeValidation).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		Expect(w.Result().StatusCode).To(Equal(http.StatusOK))
+
+		problems := []api.RecordsProblem{}
+		decoder := json.NewDecoder(w.Result().Body)
+		for decoder.More() {
+			var problem api.RecordsProblem
+			Expect(decoder.Decode(&problem)).To(Succeed())
+			problems = append(problems, problem)
+		}
+
+		Expect(problems).To(Equal([]api.RecordsProblem{
+			{Kind: "unbalanced_row", Source: "/records.json", Line: 4, Record: 2, Message: "Found 6 fields of an expected 7 at record #2"},
+			{Kind: "duplicate_ip", Source: "/records.json", Field: "ip", Message: "IP 10.0.0.1 is used by instances a, b"},
+		}))
+	})
+})
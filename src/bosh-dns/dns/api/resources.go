@@ -1,5 +1,7 @@
 package api
 
+import "time"
+
 type InstanceRecord struct {
 	ID          string `json:"id"`
 	Group       string `json:"group"`
@@ -12,6 +14,23 @@ type InstanceRecord struct {
 	HealthState string `json:"health_state"`
 }
 
+type HealthTransition struct {
+	IP    string    `json:"ip"`
+	Group string    `json:"group,omitempty"`
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	At    time.Time `json:"at"`
+}
+
+type HealthCheck struct {
+	IP           string    `json:"ip"`
+	HealthState  string    `json:"health_state"`
+	LastCheck    time.Time `json:"last_check"`
+	NextCheck    time.Time `json:"next_check"`
+	Interval     string    `json:"interval"`
+	StableChecks int       `json:"stable_checks"`
+}
+
 type Group struct {
 	JobName     string `json:"job_name"`
 	LinkName    string `json:"link_name"`
@@ -19,3 +38,40 @@ type Group struct {
 	GroupID     string `json:"group_id"`
 	HealthState string `json:"health_state"`
 }
+
+type CacheEntry struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Rcode   string   `json:"rcode"`
+	TTL     uint32   `json:"ttl"`
+	Records []string `json:"records"`
+}
+
+type CacheFlushResult struct {
+	Flushed int `json:"flushed"`
+}
+
+type RecordsVersion struct {
+	Source    string    `json:"source"`
+	Version   uint64    `json:"version"`
+	AppliedAt time.Time `json:"applied_at"`
+	Records   int       `json:"records"`
+	Added     int       `json:"added"`
+	Removed   int       `json:"removed"`
+	Forced    bool      `json:"forced"`
+}
+
+type RecordsConflict struct {
+	Kind    string   `json:"kind"`
+	Value   string   `json:"value"`
+	Sources []string `json:"sources"`
+}
+
+type RecordsProblem struct {
+	Kind    string `json:"kind"`
+	Source  string `json:"source"`
+	Line    int    `json:"line,omitempty"`
+	Record  int    `json:"record,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
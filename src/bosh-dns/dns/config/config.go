@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -19,23 +20,27 @@ const (
 )
 
 type Config struct {
-	Address                  string       `json:"address"`
-	Port                     int          `json:"port"`
-	BindTimeout              DurationJSON `json:"timeout,omitempty"`
-	RecursorMaxRetries       int          `json:"recursor_max_retries,omitempty"`
-	RequestTimeout           DurationJSON `json:"request_timeout,omitempty"`
-	RecursorTimeout          DurationJSON `json:"recursor_timeout,omitempty"`
-	Recursors                []string     `json:"recursors,omitempty"`
-	DisableRecursors         bool         `json:"disable_recursors,omitempty"`
-	ConfigureSystemdResolved bool         `json:"configure_systemd_resolved,omitempty"`
-	ExcludedRecursors        []string     `json:"excluded_recursors,omitempty"`
-	RecordsFile              string       `json:"records_file,omitempty"`
-	RecursorSelection        string       `json:"recursor_selection"`
-	AliasFilesGlob           string       `json:"alias_files_glob,omitempty"`
-	HandlersFilesGlob        string       `json:"handlers_files_glob,omitempty"`
-	AddressesFilesGlob       string       `json:"addresses_files_glob,omitempty"`
-	UpcheckDomains           []string     `json:"upcheck_domains,omitempty"`
-	JobsDir                  string       `json:"jobs_dir,omitempty"`
+	Address                  string              `json:"address"`
+	Port                     int                 `json:"port"`
+	BindTimeout              DurationJSON        `json:"timeout,omitempty"`
+	RecursorMaxRetries       int                 `json:"recursor_max_retries,omitempty"`
+	RequestTimeout           DurationJSON        `json:"request_timeout,omitempty"`
+	RecursorTimeout          DurationJSON        `json:"recursor_timeout,omitempty"`
+	Recursors                []string            `json:"recursors,omitempty"`
+	DisableRecursors         bool                `json:"disable_recursors,omitempty"`
+	ConfigureSystemdResolved bool                `json:"configure_systemd_resolved,omitempty"`
+	ExcludedRecursors        []string            `json:"excluded_recursors,omitempty"`
+	RecordsFile              string              `json:"records_file,omitempty"`
+	RecordsDeltaFile         string              `json:"records_delta_file,omitempty"`
+	RecordsVerification      RecordsVerification `json:"records_verification,omitempty"`
+	RecordsSources           []RecordsSource     `json:"records_sources,omitempty"`
+	RemoteRecords            RemoteRecords       `json:"remote_records,omitempty"`
+	RecursorSelection        string              `json:"recursor_selection"`
+	AliasFilesGlob           string              `json:"alias_files_glob,omitempty"`
+	HandlersFilesGlob        string              `json:"handlers_files_glob,omitempty"`
+	AddressesFilesGlob       string              `json:"addresses_files_glob,omitempty"`
+	UpcheckDomains           []string            `json:"upcheck_domains,omitempty"`
+	JobsDir                  string              `json:"jobs_dir,omitempty"`
 
 	LogLevel string `json:"log_level,omitempty"`
 
@@ -90,8 +95,26 @@ type HealthConfig struct {
 	PrivateKeyFile          string       `json:"private_key_file"`
 	CAFile                  string       `json:"ca_file"`
 	CheckInterval           DurationJSON `json:"check_interval,omitempty"`
+	MaxBackoffFactor        int          `json:"max_backoff_factor,omitempty"`
 	MaxTrackedQueries       int          `json:"max_tracked_queries,omitempty"`
 	SynchronousCheckTimeout DurationJSON `json:"synchronous_check_timeout,omitempty"`
+	RiseThreshold           int          `json:"rise_threshold,omitempty"`
+	FallThreshold           int          `json:"fall_threshold,omitempty"`
+	Gossip                  GossipConfig `json:"gossip"`
+}
+
+// GossipConfig configures sharing health results with other bosh-dns
+// instances. Other instances connect with their health client certificate,
+// which has to be signed by CAFile; CertificateFile is presented to them.
+// Results older than MaxAge are neither used nor shared.
+type GossipConfig struct {
+	Enabled         bool         `json:"enabled"`
+	Port            int          `json:"port,omitempty"`
+	Interval        DurationJSON `json:"interval,omitempty"`
+	MaxAge          DurationJSON `json:"max_age,omitempty"`
+	CertificateFile string       `json:"certificate_file"`
+	PrivateKeyFile  string       `json:"private_key_file"`
+	CAFile          string       `json:"ca_file"`
 }
 
 type MetricsConfig struct {
@@ -101,7 +124,49 @@ type MetricsConfig struct {
 }
 
 type Cache struct {
-	Enabled bool `json:"enabled"`
+	Enabled      bool   `json:"enabled"`
+	SnapshotFile string `json:"snapshot_file,omitempty"`
+}
+
+// RecordsVerification configures the detached file that a new records file
+// is checked against before it is applied. SignatureFile holds a base64
+// encoded signature made with the private counterpart of PublicKeyFile;
+// ChecksumFile holds a hex encoded SHA-256 digest.
+type RecordsVerification struct {
+	SignatureFile string `json:"signature_file,omitempty"`
+	ChecksumFile  string `json:"checksum_file,omitempty"`
+	PublicKeyFile string `json:"public_key_file,omitempty"`
+}
+
+func (v RecordsVerification) Enabled() bool {
+	return v.SignatureFile != "" || v.ChecksumFile != ""
+}
+
+// RecordsSource is a records file, or a glob of them, that is served next to
+// RecordsFile. Only records under Domains are taken from it when Domains is
+// set. When sources provide the same domain, the one with the highest
+// Precedence wins; RecordsFile has a precedence of 0.
+type RecordsSource struct {
+	Path       string   `json:"path"`
+	Domains    []string `json:"domains,omitempty"`
+	Precedence int      `json:"precedence,omitempty"`
+}
+
+// RemoteRecords fetches the records file from an HTTPS URL with mutual TLS
+// instead of reading RecordsFile. Every fetched records file is copied to
+// FallbackFile, which is served until the URL can be reached after a restart.
+type RemoteRecords struct {
+	URL             string       `json:"url,omitempty"`
+	ServerName      string       `json:"server_name,omitempty"`
+	CAFile          string       `json:"ca_file,omitempty"`
+	CertificateFile string       `json:"certificate_file,omitempty"`
+	PrivateKeyFile  string       `json:"private_key_file,omitempty"`
+	PollInterval    DurationJSON `json:"poll_interval,omitempty"`
+	FallbackFile    string       `json:"fallback_file,omitempty"`
+}
+
+func (r RemoteRecords) Enabled() bool {
+	return r.URL != ""
 }
 
 type InternalUpcheckDomain struct {
@@ -157,12 +222,22 @@ func NewDefaultConfig() Config {
 			MaxTrackedQueries:       2000,
 			CheckInterval:           DurationJSON(20 * time.Second),
 			SynchronousCheckTimeout: DurationJSON(time.Second),
+			RiseThreshold:           1,
+			FallThreshold:           1,
+			Gossip: GossipConfig{
+				Port:     8854,
+				Interval: DurationJSON(time.Second),
+				MaxAge:   DurationJSON(10 * time.Second),
+			},
 		},
 		Metrics: MetricsConfig{
 			Enabled: false,
 			Address: "127.0.0.1",
 			Port:    53088,
 		},
+		RemoteRecords: RemoteRecords{
+			PollInterval: DurationJSON(10 * time.Second),
+		},
 		LogLevel: boshlog.AsString(boshlog.LevelDebug),
 	}
 }
@@ -192,6 +267,39 @@ func LoadFromFile(configFilePath string) (Config, error) {
 		return Config{}, err
 	}
 
+	if c.RecordsVerification.SignatureFile != "" && c.RecordsVerification.ChecksumFile != "" {
+		return Config{}, errors.New("records_verification accepts either a signature_file or a checksum_file, not both")
+	}
+
+	if c.RecordsVerification.SignatureFile != "" && c.RecordsVerification.PublicKeyFile == "" {
+		return Config{}, errors.New("records_verification.public_key_file is required to verify a signature_file")
+	}
+
+	for i, source := range c.RecordsSources {
+		if source.Path == "" {
+			return Config{}, fmt.Errorf("records_sources[%d].path is required", i)
+		}
+	}
+
+	if c.RemoteRecords.Enabled() {
+		remoteURL, err := url.Parse(c.RemoteRecords.URL)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid remote_records.url: %s", err.Error())
+		}
+
+		if remoteURL.Scheme != "https" {
+			return Config{}, errors.New("remote_records.url must be an https URL")
+		}
+
+		if c.RemoteRecords.CAFile == "" || c.RemoteRecords.CertificateFile == "" || c.RemoteRecords.PrivateKeyFile == "" {
+			return Config{}, errors.New("remote_records requires a ca_file, certificate_file and private_key_file")
+		}
+
+		if c.RecordsVerification.Enabled() {
+			return Config{}, errors.New("records_verification cannot be combined with remote_records")
+		}
+	}
+
 	switch c.RecursorSelection {
 	case "smart":
 	case "serial":
@@ -96,8 +96,11 @@ var _ = Describe("Config", func() {
 				"private_key_file":          healthPrivateKeyFile,
 				"ca_file":                   healthCAFile,
 				"check_interval":            upcheckInterval,
+				"max_backoff_factor":        4,
 				"max_tracked_queries":       healthMaxTrackedQueries,
 				"synchronous_check_timeout": synchronousCheckTimeout,
+				"rise_threshold":            2,
+				"fall_threshold":            3,
 			},
 			"metrics": map[string]interface{}{
 				"enabled": true,
@@ -176,8 +179,16 @@ var _ = Describe("Config", func() {
 				PrivateKeyFile:          healthPrivateKeyFile,
 				CAFile:                  healthCAFile,
 				CheckInterval:           config.DurationJSON(upcheckIntervalDuration),
+				MaxBackoffFactor:        4,
 				MaxTrackedQueries:       healthMaxTrackedQueries,
 				SynchronousCheckTimeout: config.DurationJSON(synchronousCheckTimeoutDuration),
+				RiseThreshold:           2,
+				FallThreshold:           3,
+				Gossip: config.GossipConfig{
+					Port:     8854,
+					Interval: config.DurationJSON(time.Second),
+					MaxAge:   config.DurationJSON(10 * time.Second),
+				},
 			},
 			Metrics: config.MetricsConfig{
 				Enabled: true,
@@ -187,6 +198,9 @@ var _ = Describe("Config", func() {
 			Cache: config.Cache{
 				Enabled: true,
 			},
+			RemoteRecords: config.RemoteRecords{
+				PollInterval: config.DurationJSON(10 * time.Second),
+			},
 			InternalUpcheckDomain: config.InternalUpcheckDomain{
 				Enabled:  true,
 				DNSQuery: "internal.test.query.",
@@ -240,6 +254,126 @@ var _ = Describe("Config", func() {
 		})
 	})
 
+	Context("records_delta_file", func() {
+		It("allows configuring the path", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53, "records_delta_file": "/some/delta"}`)
+			dnsConfig, err := config.LoadFromFile(configFilePath)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dnsConfig.RecordsDeltaFile).To(Equal("/some/delta"))
+		})
+	})
+
+	Context("records_verification", func() {
+		It("is disabled by default", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53}`)
+			dnsConfig, err := config.LoadFromFile(configFilePath)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dnsConfig.RecordsVerification.Enabled()).To(BeFalse())
+		})
+
+		It("allows configuring a signature", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53, "records_verification": {"signature_file": "/records.sig", "public_key_file": "/key.pem"}}`)
+			dnsConfig, err := config.LoadFromFile(configFilePath)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dnsConfig.RecordsVerification).To(Equal(config.RecordsVerification{
+				SignatureFile: "/records.sig",
+				PublicKeyFile: "/key.pem",
+			}))
+			Expect(dnsConfig.RecordsVerification.Enabled()).To(BeTrue())
+		})
+
+		It("requires a public key to verify a signature", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53, "records_verification": {"signature_file": "/records.sig"}}`)
+
+			_, err := config.LoadFromFile(configFilePath)
+			Expect(err).To(MatchError("records_verification.public_key_file is required to verify a signature_file"))
+		})
+
+		It("does not allow both a signature and a checksum", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53, "records_verification": {"signature_file": "/records.sig", "checksum_file": "/records.sha256", "public_key_file": "/key.pem"}}`)
+
+			_, err := config.LoadFromFile(configFilePath)
+			Expect(err).To(MatchError(ContainSubstring("not both")))
+		})
+	})
+
+	Context("records_sources", func() {
+		It("allows configuring additional records files", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53, "records_sources": [{"path": "/other/*.json", "domains": ["other"], "precedence": 10}]}`)
+			dnsConfig, err := config.LoadFromFile(configFilePath)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dnsConfig.RecordsSources).To(Equal([]config.RecordsSource{
+				{Path: "/other/*.json", Domains: []string{"other"}, Precedence: 10},
+			}))
+		})
+
+		It("requires a path for every source", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53, "records_sources": [{"path": "/other.json"}, {"domains": ["other"]}]}`)
+
+			_, err := config.LoadFromFile(configFilePath)
+			Expect(err).To(MatchError("records_sources[1].path is required"))
+		})
+	})
+
+	Context("remote_records", func() {
+		It("is disabled by default", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53}`)
+			dnsConfig, err := config.LoadFromFile(configFilePath)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dnsConfig.RemoteRecords.Enabled()).To(BeFalse())
+			Expect(dnsConfig.RemoteRecords.PollInterval).To(Equal(config.DurationJSON(10 * time.Second)))
+		})
+
+		It("allows fetching records from a URL", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53, "remote_records": {
+				"url": "https://records.example.com/records.json",
+				"ca_file": "/ca.crt",
+				"certificate_file": "/client.crt",
+				"private_key_file": "/client.key",
+				"poll_interval": "1m",
+				"fallback_file": "/records.json"
+			}}`)
+			dnsConfig, err := config.LoadFromFile(configFilePath)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dnsConfig.RemoteRecords).To(Equal(config.RemoteRecords{
+				URL:             "https://records.example.com/records.json",
+				CAFile:          "/ca.crt",
+				CertificateFile: "/client.crt",
+				PrivateKeyFile:  "/client.key",
+				PollInterval:    config.DurationJSON(time.Minute),
+				FallbackFile:    "/records.json",
+			}))
+			Expect(dnsConfig.RemoteRecords.Enabled()).To(BeTrue())
+		})
+
+		It("requires an https URL", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53, "remote_records": {"url": "http://records.example.com", "ca_file": "/ca.crt", "certificate_file": "/client.crt", "private_key_file": "/client.key"}}`)
+
+			_, err := config.LoadFromFile(configFilePath)
+			Expect(err).To(MatchError("remote_records.url must be an https URL"))
+		})
+
+		It("requires mutual TLS", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53, "remote_records": {"url": "https://records.example.com", "ca_file": "/ca.crt"}}`)
+
+			_, err := config.LoadFromFile(configFilePath)
+			Expect(err).To(MatchError("remote_records requires a ca_file, certificate_file and private_key_file"))
+		})
+
+		It("cannot be combined with records verification", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53, "records_verification": {"checksum_file": "/records.json.sha256"}, "remote_records": {"url": "https://records.example.com", "ca_file": "/ca.crt", "certificate_file": "/client.crt", "private_key_file": "/client.key"}}`)
+
+			_, err := config.LoadFromFile(configFilePath)
+			Expect(err).To(MatchError("records_verification cannot be combined with remote_records"))
+		})
+	})
+
 	Context("recursor_selection", func() {
 		It("allows configuring recursor selection to be serial", func() {
 			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53, "recursor_selection": "serial"}`)
@@ -303,6 +437,58 @@ var _ = Describe("Config", func() {
 		})
 	})
 
+	Context("health thresholds", func() {
+		It("default to changing the state with every health check result", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53}`)
+
+			dnsConfig, err := config.LoadFromFile(configFilePath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(dnsConfig.Health.RiseThreshold).To(Equal(1))
+			Expect(dnsConfig.Health.FallThreshold).To(Equal(1))
+		})
+	})
+
+	Context("gossip", func() {
+		It("is disabled by default", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53}`)
+
+			dnsConfig, err := config.LoadFromFile(configFilePath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(dnsConfig.Health.Gossip).To(Equal(config.GossipConfig{
+				Port:     8854,
+				Interval: config.DurationJSON(time.Second),
+				MaxAge:   config.DurationJSON(10 * time.Second),
+			}))
+		})
+
+		It("parses the gossip configuration", func() {
+			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53, "health": {"gossip": {
+				"enabled": true,
+				"port": 9854,
+				"interval": "2s",
+				"max_age": "30s",
+				"certificate_file": "/server.crt",
+				"private_key_file": "/server.key",
+				"ca_file": "/server_ca.crt"
+			}}}`)
+
+			dnsConfig, err := config.LoadFromFile(configFilePath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(dnsConfig.Health.Gossip).To(Equal(config.GossipConfig{
+				Enabled:         true,
+				Port:            9854,
+				Interval:        config.DurationJSON(2 * time.Second),
+				MaxAge:          config.DurationJSON(30 * time.Second),
+				CertificateFile: "/server.crt",
+				PrivateKeyFile:  "/server.key",
+				CAFile:          "/server_ca.crt",
+			}))
+		})
+	})
+
 	Context("metrics", func() {
 		It("is disabled by default", func() {
 			configFilePath := writeConfigFile(`{"address": "127.0.0.1", "port": 53}`)
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -18,13 +19,17 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"bosh-dns/dns/api"
 	dnsconfig "bosh-dns/dns/config"
 	addressesconfig "bosh-dns/dns/config/addresses"
 	handlersconfig "bosh-dns/dns/config/handlers"
+	"bosh-dns/dns/reload"
 	"bosh-dns/dns/server"
 	"bosh-dns/dns/server/aliases"
+	"bosh-dns/dns/server/criteria"
+	"bosh-dns/dns/server/gossip"
 	"bosh-dns/dns/server/handlers"
 	"bosh-dns/dns/server/healthiness"
 	"bosh-dns/dns/server/monitoring"
@@ -120,7 +125,11 @@ func mainExitCode() int {
 		listenIPs = append(listenIPs, addr.Address)
 	}
 
-	mux := dns.NewServeMux()
+	// Reloads compare the main config to it as it was read, before the
+	// recursors are taken from the OS.
+	loadedConfig := config
+
+	mux := handlers.NewSwappingServeMux()
 	newClock := clock.NewClock()
 	repoUpdate := make(chan struct{})
 
@@ -137,23 +146,77 @@ func mainExitCode() int {
 
 	var healthWatcher healthiness.HealthWatcher = healthiness.NewNopHealthWatcher()
 	var healthChecker healthiness.HealthChecker = healthiness.NewDisabledHealthChecker()
+	var gossipNode *gossip.Node
 	if config.Health.Enabled {
 		httpClient, err := tlsclient.NewFromFiles("health.bosh-dns", config.Health.CAFile, config.Health.CertificateFile, config.Health.PrivateKeyFile, time.Duration(config.RequestTimeout), logger)
 		if err != nil {
 			logger.Error(logTag, fmt.Sprintf("Unable to configure health checker %s", err.Error()))
 			return 1
 		}
-		healthChecker = healthiness.NewHealthChecker(httpClient, config.Health.Port, logger)
+		// health streams stay open, broken ones are detected by the health
+		// checker; only connecting to them times out
+		streamClient, err := tlsclient.NewStreamingFromFiles("health.bosh-dns", config.Health.CAFile, config.Health.CertificateFile, config.Health.PrivateKeyFile, time.Duration(config.RequestTimeout), logger)
+		if err != nil {
+			logger.Error(logTag, fmt.Sprintf("Unable to configure health checker %s", err.Error()))
+			return 1
+		}
+		healthChecker = healthiness.NewStreamingHealthChecker(httpClient, streamClient, newClock, config.Health.Port, logger)
 		checkInterval := time.Duration(config.Health.CheckInterval)
-		healthWatcher = healthiness.NewHealthWatcher(1000, healthChecker, newClock, checkInterval, logger)
+		thresholds := healthiness.Thresholds{Rise: config.Health.RiseThreshold, Fall: config.Health.FallThreshold}
+
+		var peerHealth healthiness.PeerHealth
+		if config.Health.Gossip.Enabled {
+			gossipNode, err = newGossipNode(config.Health.Gossip, httpClient, newClock, logger)
+			if err != nil {
+				logger.Error(logTag, fmt.Sprintf("Unable to configure health gossip %s", err.Error()))
+				return 1
+			}
+			peerHealth = gossipNode
+		}
+
+		healthWatcher = healthiness.NewHealthWatcher(1000, healthChecker, newClock, checkInterval, config.Health.MaxBackoffFactor, thresholds, peerHealth, logger)
 	}
 
 	shutdown := make(chan struct{})
 
-	fileReader := records.NewFileReader(config.RecordsFile, boshsys.NewOsFileSystem(logger), newClock, logger, repoUpdate)
+	recordsVerifier, err := records.NewVerifier(config.RecordsVerification, fs)
+	if err != nil {
+		logger.Error(logTag, fmt.Sprintf("Unable to configure records verification: %s", err.Error()))
+		return 1
+	}
+
+	recordsSourceName := config.RecordsFile
+	var fileReader records.FileReader
+	if config.RemoteRecords.Enabled() {
+		recordsSourceName = config.RemoteRecords.URL
+		fileReader, err = records.NewRemoteFileReader(config.RemoteRecords, time.Duration(config.RequestTimeout), fs, newClock, logger, repoUpdate)
+		if err != nil {
+			logger.Error(logTag, fmt.Sprintf("Unable to configure remote records: %s", err.Error()))
+			return 1
+		}
+	} else if recordsVerifier != nil {
+		fileReader = records.NewVerifyingFileReader(config.RecordsFile, recordsVerifier, monitoring.NewRecordsRejectionCounter(), boshsys.NewOsFileSystem(logger), newClock, logger, repoUpdate)
+	} else {
+		fileReader = records.NewFileReader(config.RecordsFile, boshsys.NewOsFileSystem(logger), newClock, logger, repoUpdate)
+	}
+	additionalSources, err := records.NewSources(config.RecordsSources, fs, newClock, logger, repoUpdate)
+	if err != nil {
+		logger.Error(logTag, fmt.Sprintf("Unable to configure records sources: %s", err.Error()))
+		return 1
+	}
+
+	mainSource := records.Source{Name: recordsSourceName, Reader: fileReader}
+	if config.RecordsDeltaFile != "" {
+		mainSource.DeltaReader = records.NewFileReader(config.RecordsDeltaFile, boshsys.NewOsFileSystem(logger), newClock, logger, repoUpdate)
+	}
+
+	recordsSources := append([]records.Source{mainSource}, additionalSources...)
+
 	filtererFactory := records.NewHealthFiltererFactory(healthWatcher, time.Duration(config.Health.SynchronousCheckTimeout))
 	recordSet, err := //nolint:staticcheck
-		records.NewRecordSet(fileReader, aliasConfiguration, healthWatcher, uint(config.Health.MaxTrackedQueries), shutdown, logger, filtererFactory, records.NewAliasEncoder())
+		records.NewMultiSourceRecordSet(recordsSources, aliasConfiguration, healthWatcher, uint(config.Health.MaxTrackedQueries), shutdown, logger, newClock, filtererFactory, records.NewAliasEncoder())
+
+	prometheus.MustRegister(monitoring.NewRecordsValidationCollector(recordSet))
 
 	truncater := dnsresolver.NewResponseTruncater()
 	localDomain := dnsresolver.NewLocalDomain(logger, recordSet, truncater)
@@ -161,19 +224,25 @@ func mainExitCode() int {
 	var (
 		nextInternalHandler  dns.Handler = handlers.NewDiscoveryHandler(logger, localDomain)
 		metricsServerWrapper *monitoring.MetricsServerWrapper
+		cachingHandler       *handlers.CachingDNSHandler
 	)
 
 	exchangerFactory := handlers.NewExchangerFactory(time.Duration(config.RecursorTimeout))
 	handlerFactory := handlers.NewFactory(exchangerFactory, newClock, config.RecursorMaxRetries, logger, truncater)
-	delegatingHandlers, err := handlersConfiguration.GenerateHandlers(handlerFactory)
+
+	builtInDomains := append([]string{criteria.BoshAgentTLD}, config.UpcheckDomains...)
+	if !config.DisableRecursors {
+		builtInDomains = append(builtInDomains, ".", "arpa.")
+	}
+
+	reloader := reload.NewReloader(configPath, loadedConfig, fs, mux, builtInDomains, recordSet, handlerFactory, recordSet, newClock, logger)
+	err = reloader.Apply(addressConfiguration, aliasConfiguration, handlersConfiguration)
 	if err != nil {
 		logger.Error(logTag, err.Error())
 		return 1
 	}
 
-	for domain, handler := range delegatingHandlers {
-		mux.Handle(domain, handlers.NewRequestLoggerHandler(handler, newClock, logger))
-	}
+	caches := []api.Cache{reloader}
 
 	if !config.DisableRecursors {
 		// Upstream recursors
@@ -185,7 +254,10 @@ func mainExitCode() int {
 		var nextExternalHandler dns.Handler = forwardHandler
 
 		if config.Cache.Enabled {
-			nextExternalHandler = handlers.NewCachingDNSHandler(nextExternalHandler, truncater, newClock, logger)
+			caching := handlers.NewCachingDNSHandler(nextExternalHandler, truncater, newClock, logger)
+			cachingHandler = &caching
+			caches = append(caches, caching)
+			nextExternalHandler = caching
 		}
 		if config.Metrics.Enabled {
 			metricsAddr := fmt.Sprintf("%s:%d", config.Metrics.Address, config.Metrics.Port)
@@ -259,6 +331,17 @@ func mainExitCode() int {
 
 	go healthWatcher.Run(shutdown)
 
+	if gossipNode != nil {
+		gossipServer, err := newGossipServer(config.Health.Gossip, gossipNode, logger)
+		if err != nil {
+			logger.Error(logTag, fmt.Sprintf("Unable to configure gossip server: %s", err.Error()))
+			return 1
+		}
+
+		go gossipNode.Run(shutdown)
+		go serveGossip(gossipServer, shutdown, logger)
+	}
+
 	sigterm := make(chan os.Signal, 1)
 	signal.Notify(sigterm, syscall.SIGTERM)
 
@@ -268,6 +351,11 @@ func mainExitCode() int {
 		close(shutdown)
 	}()
 
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	go reloader.Run(sighup, shutdown)
+
 	jobs, err := healthconfig.ParseJobs(config.JobsDir, "")
 	if err != nil {
 		logger.Error(logTag, fmt.Sprintf("failed to parse jobs directory: %s", err.Error()))
@@ -275,7 +363,15 @@ func mainExitCode() int {
 	}
 
 	http.Handle("/instances", api.NewInstancesHandler(recordSet, healthWatcher))
+	http.Handle("/instances/health-history", api.NewHealthHistoryHandler(recordSet, healthWatcher))
+	http.Handle("/instances/health-checks", api.NewHealthChecksHandler(recordSet, healthWatcher))
 	http.Handle("/local-groups", api.NewLocalGroupsHandler(jobs, healthChecker))
+	http.Handle("/cache", api.NewCacheHandler(caches))
+	http.Handle("/cache/flush", api.NewCacheFlushHandler(caches))
+	http.Handle("/records/history", api.NewRecordsHistoryHandler(recordSet))
+	http.Handle("/records/accept", api.NewRecordsAcceptHandler(recordSet))
+	http.Handle("/records/conflicts", api.NewRecordsConflictsHandler(recordSet))
+	http.Handle("/records/validation", api.NewRecordsValidationHandler(recordSet))
 
 	go func(config dnsconfig.APIConfig) {
 		tlsConfig, err := tlsconfig.Build(
@@ -299,7 +395,24 @@ func mainExitCode() int {
 		httpServer.ListenAndServeTLS("", "") //nolint:errcheck
 	}(config.API)
 
-	if err := dnsServer.Run(); err != nil {
+	var cacheSnapshot *handlers.CacheSnapshot
+	if cachingHandler != nil && config.Cache.SnapshotFile != "" {
+		snapshot := handlers.NewCacheSnapshot(config.Cache.SnapshotFile, fs, newClock, logger)
+		cacheSnapshot = &snapshot
+		if _, err := cacheSnapshot.Restore(*cachingHandler); err != nil {
+			logger.Error(logTag, fmt.Sprintf("discarding cache snapshot: %s", err.Error()))
+		}
+	}
+
+	err = dnsServer.Run()
+
+	if cacheSnapshot != nil {
+		if err := cacheSnapshot.Save(*cachingHandler); err != nil {
+			logger.Error(logTag, fmt.Sprintf("saving cache snapshot: %s", err.Error()))
+		}
+	}
+
+	if err != nil {
 		logger.Error(logTag, "bosh-dns failed: %s", err.Error())
 		return 1
 	}
@@ -307,3 +420,59 @@ func mainExitCode() int {
 	logger.Info(logTag, "bosh-dns stopped")
 	return 0
 }
+
+func newGossipNode(config dnsconfig.GossipConfig, client gossip.HTTPClientPoster, clock clock.Clock, logger boshlog.Logger) (*gossip.Node, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing local addresses")
+	}
+
+	localIPs := []string{}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			localIPs = append(localIPs, ipNet.IP.String())
+		}
+	}
+
+	nodeConfig := gossip.Config{
+		Interval: time.Duration(config.Interval),
+		MaxAge:   time.Duration(config.MaxAge),
+		LocalIPs: localIPs,
+	}
+
+	return gossip.NewNode(nodeConfig, gossip.NewHTTPTransport(client, config.Port), clock, logger), nil
+}
+
+func newGossipServer(config dnsconfig.GossipConfig, node *gossip.Node, logger boshlog.Logger) (*http.Server, error) {
+	tlsConfig, err := tlsconfig.Build(
+		tlsconfig.WithIdentityFromFile(config.CertificateFile, config.PrivateKeyFile),
+		tlsconfig.WithInternalServiceDefaults(),
+	).Server(
+		tlsconfig.WithClientAuthenticationFromFile(config.CAFile),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/gossip", gossip.NewHandler(node, logger))
+
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", config.Port),
+		Handler:           mux,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 5 * time.Second,
+	}, nil
+}
+
+func serveGossip(httpServer *http.Server, shutdown chan struct{}, logger boshlog.Logger) {
+	go func() {
+		<-shutdown
+		httpServer.Close() //nolint:errcheck
+	}()
+
+	err := httpServer.ListenAndServeTLS("", "")
+	if err != nil && err != http.ErrServerClosed {
+		logger.Error("gossip", "Gossip server ended: %s", err.Error())
+	}
+}
@@ -116,6 +116,8 @@ var _ = Describe("main", func() {
 			metricsEnabled      bool
 			httpJSONServer      *ghttp.Server
 			recordsFilePath     string
+			recordsSources      []config.RecordsSource
+			remoteRecords       config.RemoteRecords
 			session             *gexec.Session
 			recordsJSONContent  string
 			aliases1JSONContent string
@@ -175,6 +177,8 @@ var _ = Describe("main", func() {
 			healthEnabled = true
 			metricsEnabled = false
 			recursorList = []string{}
+			recordsSources = nil
+			remoteRecords = config.RemoteRecords{}
 		})
 
 		JustBeforeEach(func() {
@@ -239,6 +243,8 @@ var _ = Describe("main", func() {
 			cfg.Port = listenPort
 			cfg.Recursors = recursorList
 			cfg.RecordsFile = recordsFilePath
+			cfg.RecordsSources = recordsSources
+			cfg.RemoteRecords = remoteRecords
 			cfg.AddressesFilesGlob = path.Join(addressesDir, "*")
 			cfg.AliasFilesGlob = path.Join(aliasesDir, "*")
 			cfg.JobsDir = jobsDir
@@ -510,6 +516,95 @@ var _ = Describe("main", func() {
 				})
 			})
 
+			Describe("/cache", func() {
+				It("returns no entries when nothing is cached", func() {
+					resp, err := secureGet(apiClient, listenAPIPort, "cache")
+					Expect(err).NotTo(HaveOccurred())
+					defer resp.Body.Close() //nolint:errcheck
+
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(json.NewDecoder(resp.Body).More()).To(BeFalse())
+				})
+			})
+
+			Describe("/cache/flush", func() {
+				It("reports how many entries were flushed", func() {
+					resp, err := apiClient.Post(fmt.Sprintf("https://127.0.0.1:%d/cache/flush?suffix=bosh", listenAPIPort), nil)
+					Expect(err).NotTo(HaveOccurred())
+					defer resp.Body.Close() //nolint:errcheck
+
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+					var result api.CacheFlushResult
+					Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+					Expect(result).To(Equal(api.CacheFlushResult{Flushed: 0}))
+				})
+			})
+
+			Describe("/records/history", func() {
+				It("lists the applied records version", func() {
+					resp, err := secureGet(apiClient, listenAPIPort, "records/history")
+					Expect(err).NotTo(HaveOccurred())
+					defer resp.Body.Close() //nolint:errcheck
+
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+					var version api.RecordsVersion
+					Expect(json.NewDecoder(resp.Body).Decode(&version)).To(Succeed())
+					Expect(version.Version).To(Equal(uint64(3)))
+					Expect(version.Forced).To(BeFalse())
+				})
+			})
+
+			Describe("/records/accept", func() {
+				It("applies the records file again", func() {
+					resp, err := apiClient.Post(fmt.Sprintf("https://127.0.0.1:%d/records/accept", listenAPIPort), nil)
+					Expect(err).NotTo(HaveOccurred())
+					defer resp.Body.Close() //nolint:errcheck
+
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+					var version api.RecordsVersion
+					Expect(json.NewDecoder(resp.Body).Decode(&version)).To(Succeed())
+					Expect(version.Version).To(Equal(uint64(3)))
+					Expect(version.Forced).To(BeTrue())
+				})
+			})
+
+			Describe("/records/conflicts", func() {
+				It("returns no conflicts for a single records file", func() {
+					resp, err := secureGet(apiClient, listenAPIPort, "records/conflicts")
+					Expect(err).NotTo(HaveOccurred())
+					defer resp.Body.Close() //nolint:errcheck
+
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(json.NewDecoder(resp.Body).More()).To(BeFalse())
+				})
+			})
+
+			Describe("/records/validation", func() {
+				It("returns the IPs that are used by several instances", func() {
+					resp, err := secureGet(apiClient, listenAPIPort, "records/validation")
+					Expect(err).NotTo(HaveOccurred())
+					defer resp.Body.Close() //nolint:errcheck
+
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+					problems := []api.RecordsProblem{}
+					decoder := json.NewDecoder(resp.Body)
+					for decoder.More() {
+						var problem api.RecordsProblem
+						Expect(decoder.Decode(&problem)).To(Succeed())
+						problems = append(problems, problem)
+					}
+
+					Expect(problems).To(Equal([]api.RecordsProblem{
+						{Kind: "duplicate_ip", Source: recordsFilePath, Field: "ip", Message: "IP 127.0.0.2 is used by instances my-instance-1, my-instance-3"},
+						{Kind: "duplicate_ip", Source: recordsFilePath, Field: "ip", Message: "IP 127.0.0.3 is used by instances my-instance-2, my-instance-4"},
+					}))
+				})
+			})
+
 			Describe("/local-groups", func() {
 				BeforeEach(func() {
 					job1Dir := path.Join(jobsDir, "job1", ".bosh")
@@ -687,6 +782,7 @@ var _ = Describe("main", func() {
 
 						Expect(resp.StatusCode).To(Equal(http.StatusOK))
 						Expect(string(metrics)).To(MatchRegexp("coredns_dns_requests_total{family=\"1\",proto=\"tcp\",server=\"\",type=\"ANY\",view=\"\",zone=\".\"} [1-9][0-9]*"))
+						Expect(string(metrics)).To(ContainSubstring(`boshdns_records_problems{kind="duplicate_ip"} 2`))
 					})
 				})
 
@@ -1104,7 +1200,8 @@ var _ = Describe("main", func() {
 								"group_id": "19",
 								"root_domain": "bosh"
 							}]
-						}
+						},
+						"Version": 4
 					}`), 0644)
 					Expect(err).NotTo(HaveOccurred())
 				})
@@ -1152,6 +1249,201 @@ var _ = Describe("main", func() {
 				})
 			})
 
+			Context("with an additional records source", func() {
+				var otherRecordsDir string
+
+				BeforeEach(func() {
+					var err error
+					otherRecordsDir, err = os.MkdirTemp("", "other-records")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.WriteFile(path.Join(otherRecordsDir, "records.json"), []byte(`{
+						"record_keys": ["id", "instance_group", "az", "network", "deployment", "ip", "domain"],
+						"record_infos": [
+							["other-instance", "other-group", "az1", "other-network", "other-deployment", "127.0.0.4", "other-director"],
+							["other-instance", "my-group", "az1", "my-network", "my-deployment", "127.0.0.5", "bosh"]
+						],
+						"Version": 1
+					}`), 0644)
+					Expect(err).NotTo(HaveOccurred())
+
+					recordsSources = []config.RecordsSource{
+						{Path: path.Join(otherRecordsDir, "*.json"), Domains: []string{"other-director"}},
+					}
+				})
+
+				AfterEach(func() {
+					Expect(os.RemoveAll(otherRecordsDir)).To(Succeed())
+				})
+
+				It("resolves records of both sources", func() {
+					c := &dns.Client{}
+
+					m := &dns.Msg{}
+					SetQuestion(m, nil, "other-instance.other-group.other-network.other-deployment.other-director.", dns.TypeA)
+					r, _, err := c.Exchange(m, fmt.Sprintf("%s:%d", listenAddress, listenPort))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(r.Answer).To(HaveLen(1))
+					Expect(r.Answer[0].(*dns.A).A.String()).To(Equal("127.0.0.4"))
+
+					m = &dns.Msg{}
+					SetQuestion(m, nil, "other-instance.my-group.my-network.my-deployment.bosh.", dns.TypeA)
+					r, _, err = c.Exchange(m, fmt.Sprintf("%s:%d", listenAddress, listenPort))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(r.Answer).To(BeEmpty())
+				})
+			})
+
+			Context("with remote records", func() {
+				var (
+					recordsServer *ghttp.Server
+					fallbackDir   string
+				)
+
+				BeforeEach(func() {
+					tlsConfig, err := tlsconfig.Build(
+						tlsconfig.WithIdentityFromFile("../healthcheck/assets/test_certs/test_server.pem", "../healthcheck/assets/test_certs/test_server.key"),
+						tlsconfig.WithInternalServiceDefaults(),
+					).Server(
+						tlsconfig.WithClientAuthenticationFromFile("../healthcheck/assets/test_certs/test_ca.pem"),
+					)
+					Expect(err).ToNot(HaveOccurred())
+
+					recordsServer = ghttp.NewUnstartedServer()
+					recordsServer.HTTPTestServer.TLS = tlsConfig
+					recordsServer.RouteToHandler("GET", "/records.json", func(w http.ResponseWriter, r *http.Request) {
+						if r.Header.Get("If-None-Match") == `"remote-v1"` {
+							w.WriteHeader(http.StatusNotModified)
+							return
+						}
+
+						w.Header().Set("ETag", `"remote-v1"`)
+						w.Write([]byte(`{
+							"record_keys": ["id", "instance_group", "az", "network", "deployment", "ip", "domain"],
+							"record_infos": [
+								["remote-instance", "remote-group", "az1", "remote-network", "remote-deployment", "127.0.0.7", "bosh"],
+								["primer-instance", "primer-group", "az1", "primer-network", "primer-deployment", "127.0.0.254", "primer"]
+							],
+							"Version": 1
+						}`)) //nolint:errcheck
+					})
+					recordsServer.HTTPTestServer.StartTLS()
+
+					fallbackDir, err = os.MkdirTemp("", "remote-records")
+					Expect(err).NotTo(HaveOccurred())
+
+					remoteRecords = config.RemoteRecords{
+						URL:             recordsServer.URL() + "/records.json",
+						ServerName:      "health.bosh-dns",
+						CAFile:          "../healthcheck/assets/test_certs/test_ca.pem",
+						CertificateFile: "../healthcheck/assets/test_certs/test_client.pem",
+						PrivateKeyFile:  "../healthcheck/assets/test_certs/test_client.key",
+						PollInterval:    config.DurationJSON(100 * time.Millisecond),
+						FallbackFile:    path.Join(fallbackDir, "records.json"),
+					}
+				})
+
+				AfterEach(func() {
+					recordsServer.Close()
+					Expect(os.RemoveAll(fallbackDir)).To(Succeed())
+				})
+
+				It("resolves the records fetched from the URL instead of the records file", func() {
+					c := &dns.Client{}
+
+					m := &dns.Msg{}
+					SetQuestion(m, nil, "remote-instance.remote-group.remote-network.remote-deployment.bosh.", dns.TypeA)
+					r, _, err := c.Exchange(m, fmt.Sprintf("%s:%d", listenAddress, listenPort))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(r.Answer).To(HaveLen(1))
+					Expect(r.Answer[0].(*dns.A).A.String()).To(Equal("127.0.0.7"))
+
+					m = &dns.Msg{}
+					SetQuestion(m, nil, "my-instance.my-group.my-network.my-deployment.bosh.", dns.TypeA)
+					r, _, err = c.Exchange(m, fmt.Sprintf("%s:%d", listenAddress, listenPort))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(r.Answer).To(BeEmpty())
+				})
+
+				It("keeps a fallback copy of the fetched records", func() {
+					Eventually(func() (string, error) {
+						contents, err := os.ReadFile(remoteRecords.FallbackFile)
+						return string(contents), err
+					}).Should(ContainSubstring("remote-instance"))
+				})
+			})
+
+			Context("writing an older records.json version", func() {
+				resolveMyInstance := func() string {
+					c := &dns.Client{}
+					m := &dns.Msg{}
+					SetQuestion(m, nil, "my-instance.my-group.my-network.my-deployment.bosh.", dns.TypeA)
+					r, _, err := c.Exchange(m, fmt.Sprintf("%s:%d", listenAddress, listenPort))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(r.Answer).To(HaveLen(1))
+
+					return r.Answer[0].(*dns.A).A.String()
+				}
+
+				JustBeforeEach(func() {
+					err := os.WriteFile(recordsFilePath, []byte(`{
+						"record_keys": ["id", "instance_group", "az", "network", "deployment", "ip", "domain"],
+						"record_infos": [
+							["my-instance", "my-group", "az1", "my-network", "my-deployment", "127.0.0.3", "bosh"]
+						],
+						"Version": 2
+					}`), 0644)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("keeps the applied records until an operator accepts it", func() {
+					Eventually(session.Out).Should(gbytes.Say(`Refusing DNS blob version 2 from .*, version 3 is already applied`))
+					Expect(resolveMyInstance()).To(Equal("127.0.0.1"))
+
+					resp, err := apiClient.Post(fmt.Sprintf("https://127.0.0.1:%d/records/accept", listenAPIPort), nil)
+					Expect(err).NotTo(HaveOccurred())
+					defer resp.Body.Close() //nolint:errcheck
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+					Expect(resolveMyInstance()).To(Equal("127.0.0.3"))
+				})
+			})
+
+			Context("reloading configuration", func() {
+				resolveReloadedAlias := func() string {
+					c := &dns.Client{}
+					m := &dns.Msg{}
+					SetQuestion(m, nil, "reloaded.alias.", dns.TypeA)
+					response, _, err := c.Exchange(m, fmt.Sprintf("%s:%d", listenAddress, listenPort))
+					if err != nil || len(response.Answer) == 0 {
+						return ""
+					}
+
+					return response.Answer[0].(*dns.A).A.String()
+				}
+
+				It("picks up new aliases on SIGHUP", func() {
+					err := os.WriteFile(path.Join(aliasesDir, "reloaded"), []byte(`{"reloaded.alias.": ["10.11.12.14"]}`), 0644)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(resolveReloadedAlias()).To(Equal(""))
+
+					session.Signal(syscall.SIGHUP)
+
+					Eventually(resolveReloadedAlias, 5*time.Second).Should(Equal("10.11.12.14"))
+					Eventually(session.Out).Should(gbytes.Say(`\[Reloader\].*INFO \- Reloaded configuration`))
+				})
+
+				It("keeps the previous configuration when the new one is invalid", func() {
+					err := os.WriteFile(path.Join(aliasesDir, "reloaded"), []byte(`{"reloaded.alias.": `), 0644)
+					Expect(err).NotTo(HaveOccurred())
+
+					session.Signal(syscall.SIGHUP)
+
+					Eventually(session.Out).Should(gbytes.Say(`\[Reloader\].*ERROR \- Keeping previous configuration`))
+					Expect(session).NotTo(gexec.Exit())
+				})
+			})
+
 			Context("http json domains", func() {
 				BeforeEach(func() {
 					recursorList = []string{"1.1.1.1:1111"}
@@ -1553,7 +1845,8 @@ var _ = Describe("main", func() {
 						"record_keys": ["id", "instance_group", "az", "network", "deployment", "ip", "domain"],
 						"record_infos": [
 							["my-instance", "my-group", "az1", "my-network", "my-deployment", "127.0.0.1", "bosh"]
-						]
+						],
+						"Version": 4
 					}`), 0644)
 					Expect(err).NotTo(HaveOccurred())
 
@@ -1747,6 +2040,38 @@ var _ = Describe("main", func() {
 			Eventually(session.Out).Should(gbytes.Say("[main].*ERROR - bosh-dns failed: timed out waiting for server to bind"))
 		})
 
+		It("exits 1 and logs a helpful error message when the gossip server cannot be configured", func() {
+			cfg := config.NewDefaultConfig()
+			cfg.Address = listenAddress
+			cfg.Port = listenPort
+			cfg.Recursors = []string{"169.254.169.254"}
+			cfg.UpcheckDomains = []string{"upcheck.bosh-dns."}
+			cfg.JobsDir = jobsDir
+			cfg.Health = config.HealthConfig{
+				Enabled:         true,
+				Port:            2345 + GinkgoParallelProcess(),
+				CAFile:          "../healthcheck/assets/test_certs/test_ca.pem",
+				CertificateFile: "../healthcheck/assets/test_certs/test_client.pem",
+				PrivateKeyFile:  "../healthcheck/assets/test_certs/test_client.key",
+				Gossip: config.GossipConfig{
+					Enabled:         true,
+					Port:            8854 + GinkgoParallelProcess(),
+					Interval:        config.DurationJSON(time.Second),
+					MaxAge:          config.DurationJSON(10 * time.Second),
+					CAFile:          "../healthcheck/assets/test_certs/test_ca.pem",
+					CertificateFile: "/does/not/exist.pem",
+					PrivateKeyFile:  "/does/not/exist.key",
+				},
+			}
+			cmd = newCommandWithConfig(cfg)
+
+			session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session, "5s").Should(gexec.Exit(1))
+			Eventually(session.Out).Should(gbytes.Say("[main].*ERROR - Unable to configure gossip server"))
+		})
+
 		It("exits 1 and logs a helpful error message when failing to parse jobs", func() {
 			cfg := config.NewDefaultConfig()
 			cfg.Address = listenAddress
@@ -2009,6 +2334,7 @@ func newFakeHealthServer(ip, state string, groups map[string]string) *ghttp.Serv
 		"state":       state,
 		"group_state": groups,
 	}))
+	server.RouteToHandler("GET", "/health/stream", ghttp.RespondWith(http.StatusNotFound, nil))
 	server.HTTPTestServer.StartTLS()
 
 	return server
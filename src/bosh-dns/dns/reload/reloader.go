@@ -0,0 +1,299 @@
+package reload
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/miekg/dns"
+
+	dnsconfig "bosh-dns/dns/config"
+	addressesconfig "bosh-dns/dns/config/addresses"
+	handlersconfig "bosh-dns/dns/config/handlers"
+	"bosh-dns/dns/server/aliases"
+	"bosh-dns/dns/server/handlers"
+)
+
+//counterfeiter:generate . AliasUpdater
+
+type AliasUpdater interface {
+	UpdateAliases(aliases.Config)
+}
+
+//counterfeiter:generate . ServerMux
+
+type ServerMux interface {
+	Update(add map[string]dns.Handler, remove []string)
+}
+
+// reloadableConfigFields are the fields of the main config that are applied by
+// a reload. Changes to any other field only take effect after a restart.
+var reloadableConfigFields = map[string]struct{}{
+	"AliasFilesGlob":     {},
+	"HandlersFilesGlob":  {},
+	"AddressesFilesGlob": {},
+}
+
+// Reloader owns the parts of the configuration that can be changed without
+// restarting bosh-dns: the aliases and the delegating handlers. Handlers whose
+// configuration did not change are kept so that their caches survive a reload.
+// Handlers for the built-in and internal domains are ignored, bosh-dns keeps
+// answering those itself.
+type Reloader struct {
+	configPath      string
+	config          dnsconfig.Config
+	fs              boshsys.FileSystem
+	mux             ServerMux
+	builtInDomains  []string
+	internalDomains handlers.DomainProvider
+	handlerFactory  handlersconfig.HandlerFactory
+	aliasUpdater    AliasUpdater
+	clock           clock.Clock
+	logger          boshlog.Logger
+	logTag          string
+
+	mutex          sync.Mutex
+	addresses      addressesconfig.AddressConfigs
+	handlerConfigs map[string]handlersconfig.HandlerConfig
+	handlers       map[string]dns.Handler
+}
+
+func NewReloader(
+	configPath string,
+	config dnsconfig.Config,
+	fs boshsys.FileSystem,
+	mux ServerMux,
+	builtInDomains []string,
+	internalDomains handlers.DomainProvider,
+	handlerFactory handlersconfig.HandlerFactory,
+	aliasUpdater AliasUpdater,
+	clock clock.Clock,
+	logger boshlog.Logger,
+) *Reloader {
+	return &Reloader{
+		configPath:      configPath,
+		config:          config,
+		fs:              fs,
+		mux:             mux,
+		builtInDomains:  builtInDomains,
+		internalDomains: internalDomains,
+		handlerFactory:  handlerFactory,
+		aliasUpdater:    aliasUpdater,
+		clock:           clock,
+		logger:          logger,
+		logTag:          "Reloader",
+		handlerConfigs:  map[string]handlersconfig.HandlerConfig{},
+		handlers:        map[string]dns.Handler{},
+	}
+}
+
+// Run reloads the configuration every time a signal is received. A
+// configuration that fails to load leaves the previous one in place.
+func (r *Reloader) Run(signals <-chan os.Signal, shutdown chan struct{}) {
+	for {
+		select {
+		case <-shutdown:
+			return
+		case <-signals:
+			if err := r.Reload(); err != nil {
+				r.logger.Error(r.logTag, "Keeping previous configuration: %s", err.Error())
+				continue
+			}
+
+			r.logger.Info(r.logTag, "Reloaded configuration from %s", r.configPath)
+		}
+	}
+}
+
+// Reload reads the main config and the alias, handler and address files it
+// points to. Nothing is changed unless all of them are valid.
+func (r *Reloader) Reload() error {
+	config, err := dnsconfig.LoadFromFile(r.configPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Loading config '%s'", r.configPath)
+	}
+
+	if _, err := config.GetLogLevel(); err != nil {
+		return bosherr.WrapErrorf(err, "Loading config '%s'", r.configPath)
+	}
+
+	addressConfiguration, err := addressesconfig.ConfigFromGlob(r.fs, addressesconfig.NewFSLoader(r.fs), config.AddressesFilesGlob)
+	if err != nil {
+		return bosherr.WrapError(err, "Loading addresses configuration")
+	}
+
+	aliasConfiguration, err := aliases.ConfigFromGlob(r.fs, aliases.NewFSLoader(r.fs), config.AliasFilesGlob)
+	if err != nil {
+		return bosherr.WrapError(err, "Loading alias configuration")
+	}
+
+	handlersConfiguration, err := handlersconfig.ConfigFromGlob(r.fs, handlersconfig.NewFSLoader(r.fs), config.HandlersFilesGlob)
+	if err != nil {
+		return bosherr.WrapError(err, "Loading handlers configuration")
+	}
+
+	if err := r.Apply(addressConfiguration, aliasConfiguration, handlersConfiguration); err != nil {
+		return err
+	}
+
+	r.warnAboutRestartOnlyChanges(config)
+
+	return nil
+}
+
+func (r *Reloader) warnAboutRestartOnlyChanges(config dnsconfig.Config) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := reflect.ValueOf(r.config)
+	current := reflect.ValueOf(config)
+
+	for i := 0; i < previous.NumField(); i++ {
+		field := previous.Type().Field(i)
+		if _, found := reloadableConfigFields[field.Name]; found {
+			continue
+		}
+
+		if !reflect.DeepEqual(previous.Field(i).Interface(), current.Field(i).Interface()) {
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			r.logger.Warn(r.logTag, "Config field '%s' changed; restart bosh-dns to apply it", name)
+		}
+	}
+
+	r.config = config
+}
+
+// Apply swaps in the given configuration. It is also used for the initial
+// configuration so that startup and reloads register handlers the same way.
+func (r *Reloader) Apply(
+	addressConfiguration addressesconfig.AddressConfigs,
+	aliasConfiguration aliases.Config,
+	handlersConfiguration handlersconfig.HandlerConfigs,
+) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	reserved := r.reservedDomains()
+	configs := map[string]handlersconfig.HandlerConfig{}
+	for _, handlerConfig := range handlersConfiguration {
+		if _, found := reserved[dns.CanonicalName(handlerConfig.Domain)]; found {
+			r.logger.Warn(r.logTag, "Ignoring the handler for '%s'; bosh-dns answers the domain itself", handlerConfig.Domain)
+			continue
+		}
+
+		configs[handlerConfig.Domain] = handlerConfig
+	}
+
+	changed := handlersconfig.HandlerConfigs{}
+	for domain, handlerConfig := range configs {
+		if current, found := r.handlerConfigs[domain]; found && reflect.DeepEqual(current, handlerConfig) {
+			continue
+		}
+
+		// Handler factories shuffle the recursors they are given; hand them a
+		// copy so the kept config can be compared on the next reload.
+		handlerConfig.Source.Recursors = append([]string(nil), handlerConfig.Source.Recursors...)
+		changed = append(changed, handlerConfig)
+	}
+
+	created, err := changed.GenerateHandlers(r.handlerFactory)
+	if err != nil {
+		return err
+	}
+
+	r.aliasUpdater.UpdateAliases(aliasConfiguration)
+
+	removed := []string{}
+	for domain := range r.handlers {
+		if _, found := configs[domain]; !found {
+			// A domain that became internal is now registered by bosh-dns.
+			if _, found := reserved[dns.CanonicalName(domain)]; !found {
+				removed = append(removed, domain)
+			}
+			delete(r.handlers, domain)
+		}
+	}
+
+	added := map[string]dns.Handler{}
+	for domain, handler := range created {
+		added[domain] = handlers.NewRequestLoggerHandler(handler, r.clock, r.logger)
+		r.handlers[domain] = handler
+	}
+
+	// Queries for domains that are in both the old and the new configuration
+	// must never miss a handler, so all changes are swapped in at once.
+	r.mux.Update(added, removed)
+
+	if r.addresses != nil && !reflect.DeepEqual(r.addresses, addressConfiguration) {
+		r.logger.Warn(r.logTag, "Listen addresses changed; restart bosh-dns to apply them")
+	}
+
+	r.addresses = addressConfiguration
+	r.handlerConfigs = configs
+
+	return nil
+}
+
+func (r *Reloader) reservedDomains() map[string]struct{} {
+	reserved := map[string]struct{}{}
+	for _, domain := range r.builtInDomains {
+		reserved[dns.CanonicalName(domain)] = struct{}{}
+	}
+
+	if r.internalDomains != nil {
+		for _, domain := range r.internalDomains.Domains() {
+			reserved[dns.CanonicalName(domain)] = struct{}{}
+		}
+	}
+
+	return reserved
+}
+
+// Entries returns the cached responses of all delegating handlers.
+func (r *Reloader) Entries() []*dns.Msg {
+	entries := []*dns.Msg{}
+	for _, cache := range r.caches() {
+		entries = append(entries, cache.Entries()...)
+	}
+
+	return entries
+}
+
+// Flush drops the matching responses from the caches of all delegating
+// handlers.
+func (r *Reloader) Flush(match func(name string) bool) int {
+	flushed := 0
+	for _, cache := range r.caches() {
+		flushed += cache.Flush(match)
+	}
+
+	return flushed
+}
+
+func (r *Reloader) caches() []handlers.CachingDNSHandler {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	domains := make([]string, 0, len(r.handlers))
+	for domain := range r.handlers {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	caches := []handlers.CachingDNSHandler{}
+	for _, domain := range domains {
+		if cache, ok := r.handlers[domain].(handlers.CachingDNSHandler); ok {
+			caches = append(caches, cache)
+		}
+	}
+
+	return caches
+}
@@ -0,0 +1,276 @@
+package reload_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	dnsconfig "bosh-dns/dns/config"
+	addressesconfig "bosh-dns/dns/config/addresses"
+	handlersconfig "bosh-dns/dns/config/handlers"
+	confighandlersfakes "bosh-dns/dns/config/handlers/handlersfakes"
+	"bosh-dns/dns/reload"
+	"bosh-dns/dns/reload/reloadfakes"
+	"bosh-dns/dns/server/aliases"
+	"bosh-dns/dns/server/handlers/handlersfakes"
+)
+
+var _ = Describe("Reloader", func() {
+	var (
+		dir              string
+		configPath       string
+		fakeMux          *reloadfakes.FakeServerMux
+		fakeDomains      *handlersfakes.FakeDomainProvider
+		fakeFactory      *confighandlersfakes.FakeHandlerFactory
+		fakeAliasUpdater *reloadfakes.FakeAliasUpdater
+		fakeLogger       *loggerfakes.FakeLogger
+		reloader         *reload.Reloader
+	)
+
+	writeFile := func(name, contents string) {
+		Expect(os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644)).To(Succeed())
+	}
+
+	writeConfig := func(contents string) {
+		writeFile("config.json", contents)
+	}
+
+	handledDomains := func() map[string]dns.Handler {
+		domains := map[string]dns.Handler{}
+		for i := 0; i < fakeMux.UpdateCallCount(); i++ {
+			added, removed := fakeMux.UpdateArgsForCall(i)
+			for _, domain := range removed {
+				delete(domains, domain)
+			}
+			for domain, handler := range added {
+				domains[domain] = handler
+			}
+		}
+		return domains
+	}
+
+	lastUpdate := func() (map[string]dns.Handler, []string) {
+		return fakeMux.UpdateArgsForCall(fakeMux.UpdateCallCount() - 1)
+	}
+
+	warnings := func() []string {
+		messages := []string{}
+		for i := 0; i < fakeLogger.WarnCallCount(); i++ {
+			_, message, args := fakeLogger.WarnArgsForCall(i)
+			messages = append(messages, fmt.Sprintf(message, args...))
+		}
+		return messages
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		configPath = filepath.Join(dir, "config.json")
+
+		fakeMux = &reloadfakes.FakeServerMux{}
+		fakeDomains = &handlersfakes.FakeDomainProvider{}
+		fakeDomains.DomainsReturns([]string{"bosh."})
+		fakeFactory = &confighandlersfakes.FakeHandlerFactory{}
+		fakeFactory.CreateForwardHandlerStub = func([]string, bool) dns.Handler {
+			return &confighandlersfakes.FakeDnsHandler{}
+		}
+		fakeFactory.CreateDenyHandlerStub = func(string) dns.Handler {
+			return &confighandlersfakes.FakeDnsHandler{}
+		}
+		fakeAliasUpdater = &reloadfakes.FakeAliasUpdater{}
+		fakeLogger = &loggerfakes.FakeLogger{}
+
+		writeConfig(fmt.Sprintf(`{
+			"port": 53,
+			"recursor_selection": "smart",
+			"alias_files_glob": "%[1]s/*.aliases",
+			"handlers_files_glob": "%[1]s/*.handlers",
+			"addresses_files_glob": "%[1]s/*.addresses"
+		}`, dir))
+		writeFile("a.aliases", `{"alias.": ["1.2.3.4"]}`)
+		writeFile("a.handlers", `[{"domain": "forward.", "source": {"type": "dns", "recursors": ["10.0.0.1"]}}]`)
+
+		config, err := dnsconfig.LoadFromFile(configPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		reloader = reload.NewReloader(configPath, config, boshsys.NewOsFileSystem(fakeLogger), fakeMux, []string{".", "upcheck.bosh-dns."}, fakeDomains, fakeFactory, fakeAliasUpdater, fakeclock.NewFakeClock(time.Now()), fakeLogger)
+		Expect(reloader.Apply(
+			addressesconfig.AddressConfigs{},
+			aliases.NewConfig(),
+			handlersconfig.HandlerConfigs{
+				{Domain: "forward.", Source: handlersconfig.Source{Type: "dns", Recursors: []string{"10.0.0.1:53"}}},
+				{Domain: "removed.", Source: handlersconfig.Source{Type: "deny"}},
+			},
+		)).To(Succeed())
+		Expect(handledDomains()).To(HaveLen(2))
+	})
+
+	Describe("Reload", func() {
+		It("swaps the aliases", func() {
+			Expect(reloader.Reload()).To(Succeed())
+
+			Expect(fakeAliasUpdater.UpdateAliasesCallCount()).To(Equal(2))
+			Expect(fakeAliasUpdater.UpdateAliasesArgsForCall(1).Resolutions("alias.")).To(Equal([]string{"1.2.3.4"}))
+		})
+
+		It("keeps unchanged handlers and removes the ones that are no longer configured", func() {
+			Expect(reloader.Reload()).To(Succeed())
+
+			Expect(fakeFactory.CreateForwardHandlerCallCount()).To(Equal(1))
+			added, removed := lastUpdate()
+			Expect(added).To(BeEmpty())
+			Expect(removed).To(Equal([]string{"removed."}))
+			Expect(handledDomains()).To(HaveKey("forward."))
+			Expect(handledDomains()).To(HaveLen(1))
+		})
+
+		It("ignores handlers for domains that bosh-dns answers itself", func() {
+			writeFile("a.handlers", `[
+				{"domain": "forward.", "source": {"type": "dns", "recursors": ["10.0.0.1"]}},
+				{"domain": ".", "source": {"type": "deny"}},
+				{"domain": "Upcheck.Bosh-DNS.", "source": {"type": "deny"}},
+				{"domain": "bosh.", "source": {"type": "deny"}}
+			]`)
+
+			Expect(reloader.Reload()).To(Succeed())
+
+			added, removed := lastUpdate()
+			Expect(added).To(BeEmpty())
+			Expect(removed).To(Equal([]string{"removed."}))
+			Expect(handledDomains()).To(HaveLen(1))
+			Expect(warnings()).To(ContainElements(
+				"Ignoring the handler for '.'; bosh-dns answers the domain itself",
+				"Ignoring the handler for 'Upcheck.Bosh-DNS.'; bosh-dns answers the domain itself",
+				"Ignoring the handler for 'bosh.'; bosh-dns answers the domain itself",
+			))
+		})
+
+		It("leaves domains that became internal to bosh-dns", func() {
+			fakeDomains.DomainsReturns([]string{"bosh.", "removed."})
+
+			Expect(reloader.Reload()).To(Succeed())
+
+			_, removed := lastUpdate()
+			Expect(removed).To(BeEmpty())
+		})
+
+		It("replaces handlers whose configuration changed", func() {
+			writeFile("a.handlers", `[{"domain": "forward.", "source": {"type": "dns", "recursors": ["10.0.0.2"]}}]`)
+
+			Expect(reloader.Reload()).To(Succeed())
+
+			Expect(fakeFactory.CreateForwardHandlerCallCount()).To(Equal(2))
+			recursors, _ := fakeFactory.CreateForwardHandlerArgsForCall(1)
+			Expect(recursors).To(Equal([]string{"10.0.0.2:53"}))
+			added, removed := lastUpdate()
+			Expect(added).To(HaveKey("forward."))
+			Expect(removed).To(Equal([]string{"removed."}))
+		})
+
+		It("swaps all handlers in at once", func() {
+			writeFile("b.handlers", `[{"domain": "added.", "source": {"type": "deny"}}]`)
+
+			Expect(reloader.Reload()).To(Succeed())
+
+			Expect(fakeMux.UpdateCallCount()).To(Equal(2))
+			added, removed := lastUpdate()
+			Expect(added).To(HaveLen(1))
+			Expect(added).To(HaveKey("added."))
+			Expect(removed).To(Equal([]string{"removed."}))
+		})
+
+		It("warns when the listen addresses changed", func() {
+			writeFile("a.addresses", `[{"address": "127.0.0.2", "port": 53}]`)
+
+			Expect(reloader.Reload()).To(Succeed())
+
+			Expect(warnings()).To(Equal([]string{"Listen addresses changed; restart bosh-dns to apply them"}))
+		})
+
+		It("warns about each changed main config field that needs a restart", func() {
+			writeConfig(fmt.Sprintf(`{
+				"port": 5353,
+				"recursors": ["10.0.0.9"],
+				"recursor_selection": "smart",
+				"alias_files_glob": "%[1]s/*.other-aliases",
+				"handlers_files_glob": "%[1]s/*.handlers",
+				"addresses_files_glob": "%[1]s/*.addresses"
+			}`, dir))
+
+			Expect(reloader.Reload()).To(Succeed())
+
+			Expect(warnings()).To(ConsistOf(
+				"Config field 'port' changed; restart bosh-dns to apply it",
+				"Config field 'recursors' changed; restart bosh-dns to apply it",
+			))
+		})
+
+		It("does not warn when only reloadable configuration changed", func() {
+			writeFile("a.aliases", `{"alias.": ["1.2.3.5"]}`)
+
+			Expect(reloader.Reload()).To(Succeed())
+			Expect(reloader.Reload()).To(Succeed())
+
+			Expect(warnings()).To(BeEmpty())
+		})
+
+		Context("when the main config is invalid", func() {
+			BeforeEach(func() {
+				writeConfig(`{"port": 0}`)
+			})
+
+			It("keeps the previous configuration", func() {
+				Expect(reloader.Reload()).To(MatchError(ContainSubstring("port is required")))
+
+				Expect(fakeAliasUpdater.UpdateAliasesCallCount()).To(Equal(1))
+				Expect(handledDomains()).To(HaveLen(2))
+			})
+		})
+
+		Context("when a handlers file is invalid", func() {
+			BeforeEach(func() {
+				writeFile("a.handlers", `[{"domain": "forward.", "source": {"type": "dns"}}]`)
+			})
+
+			It("keeps the previous configuration", func() {
+				Expect(reloader.Reload()).To(MatchError(ContainSubstring("No recursors present")))
+
+				Expect(fakeAliasUpdater.UpdateAliasesCallCount()).To(Equal(1))
+				Expect(handledDomains()).To(HaveLen(2))
+			})
+		})
+
+		Context("when an aliases file is invalid", func() {
+			BeforeEach(func() {
+				writeFile("a.aliases", `{"alias.": `)
+			})
+
+			It("keeps the previous configuration", func() {
+				Expect(reloader.Reload()).To(MatchError(ContainSubstring("Loading alias configuration")))
+
+				Expect(fakeAliasUpdater.UpdateAliasesCallCount()).To(Equal(1))
+				Expect(fakeMux.UpdateCallCount()).To(Equal(1))
+			})
+		})
+	})
+
+	Describe("Run", func() {
+		It("reloads whenever a signal is received", func() {
+			signals := make(chan os.Signal)
+			shutdown := make(chan struct{})
+			defer close(shutdown)
+
+			go reloader.Run(signals, shutdown)
+			signals <- os.Interrupt
+
+			Eventually(fakeAliasUpdater.UpdateAliasesCallCount).Should(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,74 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package reloadfakes
+
+import (
+	"bosh-dns/dns/reload"
+	"bosh-dns/dns/server/aliases"
+	"sync"
+)
+
+type FakeAliasUpdater struct {
+	UpdateAliasesStub        func(aliases.Config)
+	updateAliasesMutex       sync.RWMutex
+	updateAliasesArgsForCall []struct {
+		arg1 aliases.Config
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeAliasUpdater) UpdateAliases(arg1 aliases.Config) {
+	fake.updateAliasesMutex.Lock()
+	fake.updateAliasesArgsForCall = append(fake.updateAliasesArgsForCall, struct {
+		arg1 aliases.Config
+	}{arg1})
+	stub := fake.UpdateAliasesStub
+	fake.recordInvocation("UpdateAliases", []interface{}{arg1})
+	fake.updateAliasesMutex.Unlock()
+	if stub != nil {
+		fake.UpdateAliasesStub(arg1)
+	}
+}
+
+func (fake *FakeAliasUpdater) UpdateAliasesCallCount() int {
+	fake.updateAliasesMutex.RLock()
+	defer fake.updateAliasesMutex.RUnlock()
+	return len(fake.updateAliasesArgsForCall)
+}
+
+func (fake *FakeAliasUpdater) UpdateAliasesCalls(stub func(aliases.Config)) {
+	fake.updateAliasesMutex.Lock()
+	defer fake.updateAliasesMutex.Unlock()
+	fake.UpdateAliasesStub = stub
+}
+
+func (fake *FakeAliasUpdater) UpdateAliasesArgsForCall(i int) aliases.Config {
+	fake.updateAliasesMutex.RLock()
+	defer fake.updateAliasesMutex.RUnlock()
+	argsForCall := fake.updateAliasesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAliasUpdater) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeAliasUpdater) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ reload.AliasUpdater = new(FakeAliasUpdater)
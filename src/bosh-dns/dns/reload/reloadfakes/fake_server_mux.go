@@ -0,0 +1,82 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package reloadfakes
+
+import (
+	"bosh-dns/dns/reload"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+type FakeServerMux struct {
+	UpdateStub        func(map[string]dns.Handler, []string)
+	updateMutex       sync.RWMutex
+	updateArgsForCall []struct {
+		arg1 map[string]dns.Handler
+		arg2 []string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeServerMux) Update(arg1 map[string]dns.Handler, arg2 []string) {
+	var arg2Copy []string
+	if arg2 != nil {
+		arg2Copy = make([]string, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.updateMutex.Lock()
+	fake.updateArgsForCall = append(fake.updateArgsForCall, struct {
+		arg1 map[string]dns.Handler
+		arg2 []string
+	}{arg1, arg2Copy})
+	stub := fake.UpdateStub
+	fake.recordInvocation("Update", []interface{}{arg1, arg2Copy})
+	fake.updateMutex.Unlock()
+	if stub != nil {
+		fake.UpdateStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeServerMux) UpdateCallCount() int {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return len(fake.updateArgsForCall)
+}
+
+func (fake *FakeServerMux) UpdateCalls(stub func(map[string]dns.Handler, []string)) {
+	fake.updateMutex.Lock()
+	defer fake.updateMutex.Unlock()
+	fake.UpdateStub = stub
+}
+
+func (fake *FakeServerMux) UpdateArgsForCall(i int) (map[string]dns.Handler, []string) {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	argsForCall := fake.updateArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeServerMux) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeServerMux) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ reload.ServerMux = new(FakeServerMux)
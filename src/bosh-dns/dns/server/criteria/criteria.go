@@ -10,8 +10,24 @@ import (
 	"bosh-dns/dns/server/record"
 )
 
-var keyValueRegex = regexp.MustCompile("(a|i|s|m|n|y)([0-9]+)")
+var keyValueRegex = regexp.MustCompile("(x?)(a|b|e|i|l|s|m|n|y)([0-9]+)")
 var groupRegex = regexp.MustCompile("^q-g([0-9]+)$")
+var readableFilterRegex = regexp.MustCompile("^(?:az-(.+)|index-([0-9]+)|health-(smart|unhealthy|ordered|healthy|all))$")
+
+// HealthFilterCodes are the values of the s query key for the health filters
+// that can be named in readable queries as health-<name>. The prefix keeps
+// them apart from instances and groups named e.g. all.
+var HealthFilterCodes = map[string]string{
+	"smart":     "0",
+	"unhealthy": "1",
+	"ordered":   "2",
+	"healthy":   "3",
+	"all":       "4",
+}
+
+// NegatedPrefix marks criteria keys that exclude the records matching them,
+// e.g. xa2 selects the records that are not in the AZ with ID 2.
+const NegatedPrefix = "x"
 
 type Criteria map[string][]string
 
@@ -35,6 +51,12 @@ func (c Criteria) Matcher() Matcher {
 		if field == "y" || field == "s" || field == "fqdn" {
 			continue
 		}
+
+		if negated := strings.TrimPrefix(field, NegatedPrefix); negated != field {
+			matcher.Append(Not(Field(negated, values)))
+			continue
+		}
+
 		matcher.Append(Field(field, values))
 	}
 
@@ -93,6 +115,19 @@ func (m *OrMatcher) Append(matcher Matcher) {
 	m.criteria = append(m.criteria, matcher)
 }
 
+// NotMatcher matches the records that the wrapped matcher does not match.
+type NotMatcher struct {
+	matcher Matcher
+}
+
+func Not(matcher Matcher) *NotMatcher {
+	return &NotMatcher{matcher: matcher}
+}
+
+func (m *NotMatcher) Match(r *record.Record) bool {
+	return !m.matcher.Match(r)
+}
+
 func Field(field string, values []string) Matcher {
 	l := len(values)
 	if l > 1 {
@@ -110,43 +145,105 @@ func Field(field string, values []string) Matcher {
 	return FieldMatcher("", "")
 }
 
-func globMatches(field, value string) bool {
-	if value == "*" {
-		return true
-	} else if strings.HasPrefix(value, "*") {
-		return strings.HasSuffix(field, value[1:])
-	} else if strings.HasSuffix(value, "*") {
-		return strings.HasPrefix(field, value[0:len(value)-1])
+// IsGlob reports whether value is a glob pattern, using * for any sequence
+// of characters and ? for a single character.
+func IsGlob(value string) bool {
+	return strings.ContainsAny(value, "*?")
+}
+
+// compileGlob returns a function that reports whether a string matches the
+// glob pattern. Patterns without wildcards are matched exactly and patterns
+// with a single leading or trailing * are matched without a regexp.
+func compileGlob(pattern string) func(string) bool {
+	if !IsGlob(pattern) {
+		return func(s string) bool { return s == pattern }
 	}
 
-	return false
+	if pattern == "*" {
+		return func(string) bool { return true }
+	}
+
+	if !strings.Contains(pattern, "?") && strings.Count(pattern, "*") == 1 {
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			return func(s string) bool { return strings.HasSuffix(s, suffix) }
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			return func(s string) bool { return strings.HasPrefix(s, prefix) }
+		}
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+
+	return regexp.MustCompile(expr.String()).MatchString
+}
+
+// LifecycleCodes are the values of the l query key.
+var LifecycleCodes = map[string]string{
+	"0": record.LifecycleService,
+	"1": record.LifecycleErrand,
+}
+
+// InstanceStateCodes are the values of the e query key.
+var InstanceStateCodes = map[string]string{
+	"0": record.InstanceStateStarted,
+	"1": record.InstanceStateStopped,
+	"2": record.InstanceStateDetached,
+}
+
+func recordLifecycle(r *record.Record) string {
+	if r.Lifecycle == "" {
+		return record.LifecycleService
+	}
+
+	return r.Lifecycle
+}
+
+func recordInstanceState(r *record.Record) string {
+	if r.InstanceState == "" {
+		return record.InstanceStateStarted
+	}
+
+	return r.InstanceState
 }
 
 func FieldMatcher(field, value string) MatcherFunc {
 	switch field {
 
 	case "instanceName":
-		return func(r *record.Record) bool { return r.ID == value }
+		matches := compileGlob(value)
+		return func(r *record.Record) bool { return matches(r.ID) }
 	case "instanceGroupName":
-		if strings.Contains(value, "*") {
-			return func(r *record.Record) bool { return globMatches(r.Group, value) }
-		}
-		return func(r *record.Record) bool { return r.Group == value }
+		matches := compileGlob(value)
+		return func(r *record.Record) bool { return matches(r.Group) }
 	case "network":
-		if strings.Contains(value, "*") {
-			return func(r *record.Record) bool { return globMatches(r.Network, value) }
-		}
-		return func(r *record.Record) bool { return r.Network == value }
+		matches := compileGlob(value)
+		return func(r *record.Record) bool { return matches(r.Network) }
 	case "deployment":
-		if strings.Contains(value, "*") {
-			return func(r *record.Record) bool { return globMatches(r.Deployment, value) }
-		}
-		return func(r *record.Record) bool { return r.Deployment == value }
+		matches := compileGlob(value)
+		return func(r *record.Record) bool { return matches(r.Deployment) }
 	case "domain":
-		return func(r *record.Record) bool { return r.Domain == value }
+		matches := compileGlob(value)
+		return func(r *record.Record) bool { return matches(r.Domain) }
 
 	case "agentID":
-		return func(r *record.Record) bool { return r.AgentID == value }
+		matches := compileGlob(value)
+		return func(r *record.Record) bool { return matches(r.AgentID) }
+
+	case "az":
+		matches := compileGlob(value)
+		return func(r *record.Record) bool { return matches(r.AZ) }
 
 	case "m":
 		return func(r *record.Record) bool { return r.NumID == value }
@@ -156,6 +253,14 @@ func FieldMatcher(field, value string) MatcherFunc {
 		return func(r *record.Record) bool { return r.AZID == value }
 	case "i":
 		return func(r *record.Record) bool { return r.InstanceIndex == value }
+	case "b":
+		return func(r *record.Record) bool { return (value == "1" && r.Bootstrap) || (value == "0" && !r.Bootstrap) }
+	case "l":
+		lifecycle, known := LifecycleCodes[value]
+		return func(r *record.Record) bool { return known && recordLifecycle(r) == lifecycle }
+	case "e":
+		state, known := InstanceStateCodes[value]
+		return func(r *record.Record) bool { return known && recordInstanceState(r) == state }
 	case "g": // array
 		return func(r *record.Record) bool {
 			for _, groupID := range r.GroupIDs {
@@ -186,6 +291,7 @@ func parseCriteria(qt QueryFormType) (Criteria, error) {
 
 		criteriaMap.appendCriteria("instanceName", qt.(ShortForm).Instance())
 		criteriaMap.appendCriteria("domain", qt.(ShortForm).Domain())
+		criteriaMap.parseReadableFilters(qt.(ShortForm).Filters())
 	case LONG:
 
 		if err := criteriaMap.parseShortQueries(qt.Query()); err != nil {
@@ -203,6 +309,7 @@ func parseCriteria(qt QueryFormType) (Criteria, error) {
 		}
 
 		criteriaMap.appendCriteria("domain", qt.(LongForm).Domain())
+		criteriaMap.parseReadableFilters(qt.(LongForm).Filters())
 	case AGENTID:
 		criteriaMap.appendCriteria("agentID", qt.Query())
 	case NONBOSH:
@@ -227,11 +334,39 @@ func (c Criteria) parseShortQueries(query string) error {
 		return errors.New("illegal dns query")
 	}
 	for _, q := range querySections {
-		c.appendCriteria(q[1], q[2])
+		if q[1] == NegatedPrefix && (q[2] == "s" || q[2] == "y") {
+			return errors.New("illegal dns query")
+		}
+
+		c.appendCriteria(q[1]+q[2], q[3])
 	}
 	return nil
 }
 
+// IsReadableFilter reports whether label is a filter of the readable query
+// form: az-<az name>, index-<instance index> or health-<health filter name>.
+func IsReadableFilter(label string) bool {
+	return readableFilterRegex.MatchString(label)
+}
+
+func (c Criteria) parseReadableFilters(filters []string) {
+	for _, filter := range filters {
+		matches := readableFilterRegex.FindStringSubmatch(filter)
+		if matches == nil {
+			continue
+		}
+
+		switch {
+		case matches[1] != "":
+			c.appendCriteria("az", matches[1])
+		case matches[2] != "":
+			c.appendCriteria("i", matches[2])
+		case matches[3] != "":
+			c.appendCriteria("s", HealthFilterCodes[matches[3]])
+		}
+	}
+}
+
 func (c Criteria) appendCriteria(key, value string) {
 	values, ok := c[key]
 	if !ok {
@@ -18,6 +18,21 @@ var _ = Describe("Criteria", func() {
 			Expect(c).To(BeAssignableToTypeOf(criteria.Criteria{}))
 		})
 
+		It("maps readable filters onto the criteria keys", func() {
+			c, err := criteria.NewCriteria("_filter.az-z1.index-0.health-healthy.my-group.my-network.my-deployment.bosh.", []string{"bosh."})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c).To(Equal(criteria.Criteria{
+				"az":                {"z1"},
+				"i":                 {"0"},
+				"s":                 {"3"},
+				"instanceGroupName": {"my-group"},
+				"network":           {"my-network"},
+				"deployment":        {"my-deployment"},
+				"domain":            {"bosh."},
+				"fqdn":              {"_filter.az-z1.index-0.health-healthy.my-group.my-network.my-deployment.bosh."},
+			}))
+		})
+
 		It("returns an error when failing to parse segments", func() {
 			_, err := criteria.NewCriteria("garbage", []string{})
 			Expect(err).To(MatchError("domain is malformed"))
@@ -87,6 +102,72 @@ var _ = Describe("Criteria", func() {
 		}),
 	)
 
+	DescribeTable("NotMatcher", func(result bool) {
+		matcher := criteria.Not(criteria.MatcherFunc(func(_ *record.Record) bool {
+			return result
+		}))
+		Expect(matcher.Match(new(record.Record))).To(Equal(!result))
+	},
+		Entry("true", true),
+		Entry("false", false),
+	)
+
+	Describe("negated criteria", func() {
+		var recs []record.Record
+
+		BeforeEach(func() {
+			recs = []record.Record{
+				{ID: "instance0", AZID: "1", InstanceIndex: "0", Bootstrap: true, Group: "my-group", Network: "my-network", Deployment: "my-deployment", Domain: "bosh."},
+				{ID: "instance1", AZID: "2", InstanceIndex: "1", Group: "my-group", Network: "my-network", Deployment: "my-deployment", Domain: "bosh."},
+				{ID: "instance2", AZID: "3", InstanceIndex: "2", Group: "my-group", Network: "my-network", Deployment: "my-deployment", Domain: "bosh."},
+			}
+		})
+
+		matchingIDs := func(fqdn string) []string {
+			c, err := criteria.NewCriteria(fqdn, []string{"bosh."})
+			Expect(err).NotTo(HaveOccurred())
+
+			matcher := c.Matcher()
+			ids := []string{}
+			for i := range recs {
+				if matcher.Match(&recs[i]) {
+					ids = append(ids, recs[i].ID)
+				}
+			}
+
+			return ids
+		}
+
+		It("parses negated keys of the encoded query", func() {
+			c, err := criteria.NewCriteria("q-s0xa2xi0.my-group.my-network.my-deployment.bosh.", []string{"bosh."})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c["xa"]).To(Equal([]string{"2"}))
+			Expect(c["xi"]).To(Equal([]string{"0"}))
+		})
+
+		It("excludes the records matching a negated key", func() {
+			Expect(matchingIDs("q-s0xa2.my-group.my-network.my-deployment.bosh.")).To(Equal([]string{"instance0", "instance2"}))
+			Expect(matchingIDs("q-s0xb1.my-group.my-network.my-deployment.bosh.")).To(Equal([]string{"instance1", "instance2"}))
+		})
+
+		It("excludes the records matching any of the values of a negated key", func() {
+			Expect(matchingIDs("q-s0xa1xa3.my-group.my-network.my-deployment.bosh.")).To(Equal([]string{"instance1"}))
+		})
+
+		It("combines negated and plain keys", func() {
+			Expect(matchingIDs("q-a3i2s0xb1.my-group.my-network.my-deployment.bosh.")).To(Equal([]string{"instance2"}))
+			Expect(matchingIDs("q-a1s0xb1.my-group.my-network.my-deployment.bosh.")).To(BeEmpty())
+		})
+
+		It("returns an error when negating the health keys", func() {
+			_, err := criteria.NewCriteria("q-xs0.my-group.my-network.my-deployment.bosh.", []string{"bosh."})
+			Expect(err).To(MatchError("illegal dns query"))
+
+			_, err = criteria.NewCriteria("q-s0xy1.my-group.my-network.my-deployment.bosh.", []string{"bosh."})
+			Expect(err).To(MatchError("illegal dns query"))
+		})
+	})
+
 	Describe("FieldMatcher", func() {
 		var rec *record.Record
 
@@ -105,6 +186,9 @@ var _ = Describe("Criteria", func() {
 				AZID:          "azid",
 				InstanceIndex: "0",
 				GroupIDs:      []string{"gid"},
+				Bootstrap:     true,
+				Lifecycle:     "errand",
+				InstanceState: "stopped",
 			}
 		})
 
@@ -133,8 +217,22 @@ var _ = Describe("Criteria", func() {
 			Entry("Short-form network", "n", "netid"),
 			Entry("Short-form AZ", "a", "azid"),
 			Entry("Short-form index", "i", "0"),
+			Entry("AZ name", "az", "z1"),
+			Entry("Instance group", "instanceGroupName", "a-*roup"),
+			Entry("Instance group", "instanceGroupName", "a-gr?up"),
+			Entry("Network", "network", "n*t"),
+			Entry("Deployment name", "deployment", "d*p"),
+			Entry("Deployment name", "deployment", "d?p*"),
+			Entry("Instance name", "instanceName", "i*"),
+			Entry("Instance name", "instanceName", "?d"),
+			Entry("TLD", "domain", "bo*h"),
+			Entry("AgentID ", "agentID", "a*c"),
+			Entry("AZ name", "az", "z?"),
 			Entry("Group ", "g", "gid"),
 			Entry("AgentID ", "agentID", "abc"),
+			Entry("Bootstrap", "b", "1"),
+			Entry("Lifecycle", "l", "1"),
+			Entry("Instance state", "e", "1"),
 		)
 
 		DescribeTable("Matching with known fields but non-matching values", func(field, value string) {
@@ -142,21 +240,49 @@ var _ = Describe("Criteria", func() {
 			Expect(mFunc(rec)).To(BeFalse())
 		},
 			Entry("Instance name", "instanceName", "id2"),
-			Entry("Instance group", "instanceGroupName", "a-*roup"),
 			Entry("Instance group", "instanceGroupName", "b-group"),
 			Entry("Network", "network", "net2"),
-			Entry("Network", "network", "n*t"),
 			Entry("Deployment name", "deployment", "dep2"),
-			Entry("Deployment name", "deployment", "d*p"),
 			Entry("TLD", "domain", "notbosh"),
 			Entry("Short-form instance", "m", "345"),
 			Entry("Short-form network", "n", "netid2"),
 			Entry("Short-form AZ", "a", "azid2"),
 			Entry("Short-form index", "i", "1"),
+			Entry("AZ name", "az", "z2"),
+			Entry("Instance group", "instanceGroupName", "a-*roupx"),
+			Entry("Instance group", "instanceGroupName", "a-gro?p?"),
+			Entry("Network", "network", "n?"),
+			Entry("Deployment name", "deployment", "d*x*p"),
+			Entry("Instance name", "instanceName", "x*"),
+			Entry("Instance name", "instanceName", "i.*"),
+			Entry("TLD", "domain", "b*x"),
+			Entry("AgentID ", "agentID", "ab?c"),
+			Entry("AZ name", "az", "z?2"),
 			Entry("Group ", "g", "gid2"),
 			Entry("AgentID ", "agentID", "abcd"),
+			Entry("Bootstrap", "b", "0"),
+			Entry("Lifecycle", "l", "0"),
+			Entry("Instance state", "e", "0"),
+			Entry("Instance state", "e", "2"),
 		)
 
+		Context("when the record does not set lifecycle or instance state", func() {
+			BeforeEach(func() {
+				rec.Bootstrap = false
+				rec.Lifecycle = ""
+				rec.InstanceState = ""
+			})
+
+			It("matches as a started service instance", func() {
+				Expect(criteria.FieldMatcher("b", "0")(rec)).To(BeTrue())
+				Expect(criteria.FieldMatcher("l", "0")(rec)).To(BeTrue())
+				Expect(criteria.FieldMatcher("e", "0")(rec)).To(BeTrue())
+				Expect(criteria.FieldMatcher("b", "1")(rec)).To(BeFalse())
+				Expect(criteria.FieldMatcher("l", "1")(rec)).To(BeFalse())
+				Expect(criteria.FieldMatcher("e", "1")(rec)).To(BeFalse())
+			})
+		})
+
 		It("returns false when matching on an unknown field", func() {
 			mFunc := criteria.FieldMatcher("bad", "no")
 			Expect(mFunc(rec)).To(BeFalse())
@@ -25,6 +25,7 @@ type ShortForm struct {
 	group    string
 	domain   string
 	instance string
+	filters  []string
 }
 
 type LongForm struct {
@@ -59,6 +60,10 @@ func ParseQuery(fqdn string, domains []string) (QueryFormType, error) {
 		return NonBoshDNSForm{query: segments[0]}, nil
 	}
 
+	if readable, ok := parseReadableQuery(fqdn, tld); ok {
+		return readable, nil
+	}
+
 	groupQuery := strings.TrimSuffix(segments[1], "."+tld)
 	groupSegments := strings.Split(groupQuery, ".")
 	instanceName := ""
@@ -98,6 +103,58 @@ func ParseQuery(fqdn string, domains []string) (QueryFormType, error) {
 	return ShortForm{query: segments[0], domain: tld}, nil
 }
 
+// ReadableQueryMarker is the first label of readable queries, e.g.
+// _filter.az-z1.index-0.health-healthy.my-group.my-network.my-deployment.bosh.
+// BOSH replaces underscores in instance and group names with hyphens, so the
+// marker keeps names such as index-0 or az-z1 from being read as filters.
+const ReadableQueryMarker = "_filter"
+
+// parseReadableQuery parses queries that start with ReadableQueryMarker and
+// readable filters. The filters may be followed by an instance name or an
+// encoded q- query and then the group segments of the short or long form.
+func parseReadableQuery(fqdn, tld string) (QueryFormType, bool) {
+	labels := strings.Split(strings.TrimSuffix(fqdn, "."+tld), ".")
+	if labels[0] != ReadableQueryMarker {
+		return nil, false
+	}
+	labels = labels[1:]
+	if len(labels) == 0 {
+		return nil, false
+	}
+
+	groupLabels := 3
+	if groupRegex.MatchString(labels[len(labels)-1]) {
+		groupLabels = 1
+	}
+
+	filters := 0
+	for filters < len(labels)-groupLabels && IsReadableFilter(labels[filters]) {
+		filters++
+	}
+
+	rest := labels[filters:]
+	if filters == 0 || len(rest) > groupLabels+1 {
+		return nil, false
+	}
+
+	form := ShortForm{domain: tld, filters: labels[:filters]}
+	if len(rest) > groupLabels {
+		if isQuery(rest[0]) {
+			form.query = rest[0]
+		} else {
+			form.instance = rest[0]
+		}
+		rest = rest[1:]
+	}
+
+	form.group = rest[0]
+	if groupLabels == 1 {
+		return form, true
+	}
+
+	return LongForm{ShortForm: form, network: rest[1], deployment: rest[2]}, true
+}
+
 func findTLD(fqdn string, domains []string) string {
 	for _, possible := range domains {
 		if strings.HasSuffix(fqdn, possible) {
@@ -132,6 +189,10 @@ func (s ShortForm) Instance() string {
 	return s.instance
 }
 
+func (s ShortForm) Filters() []string {
+	return s.filters
+}
+
 func (s LongForm) Type() int {
 	return LONG
 }
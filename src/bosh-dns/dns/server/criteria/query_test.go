@@ -47,6 +47,92 @@ var _ = Describe("ParseQuery", func() {
 		Expect(err).To(MatchError(`bad group segment query had 4 values []string{"one", "two", "three", "four"}`))
 	})
 
+	Context("readable queries", func() {
+		It("parses long-form with readable filters", func() {
+			s, err := criteria.ParseQuery("_filter.az-z1.index-0.health-healthy.my-group.my-network.my-deployment.bosh", []string{"bosh"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Type()).To(Equal(criteria.LONG))
+
+			long := s.(criteria.LongForm)
+			Expect(long.Filters()).To(Equal([]string{"az-z1", "index-0", "health-healthy"}))
+			Expect(long.Query()).To(BeEmpty())
+			Expect(long.Instance()).To(BeEmpty())
+			Expect(long.Group()).To(Equal("my-group"))
+			Expect(long.Network()).To(Equal("my-network"))
+			Expect(long.Deployment()).To(Equal("my-deployment"))
+			Expect(long.Domain()).To(Equal("bosh"))
+		})
+
+		It("parses short-form with readable filters", func() {
+			s, err := criteria.ParseQuery("_filter.az-z1.health-all.q-g7.bosh", []string{"bosh"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Type()).To(Equal(criteria.SHORT))
+
+			short := s.(criteria.ShortForm)
+			Expect(short.Filters()).To(Equal([]string{"az-z1", "health-all"}))
+			Expect(short.Group()).To(Equal("q-g7"))
+			Expect(short.Domain()).To(Equal("bosh"))
+		})
+
+		It("parses an instance name or query after the readable filters", func() {
+			s, err := criteria.ParseQuery("_filter.az-z1.q-i2.my-group.my-network.my-deployment.bosh", []string{"bosh"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.(criteria.LongForm).Filters()).To(Equal([]string{"az-z1"}))
+			Expect(s.(criteria.LongForm).Query()).To(Equal("q-i2"))
+
+			s, err = criteria.ParseQuery("_filter.health-healthy.instance-id.my-group.my-network.my-deployment.bosh", []string{"bosh"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.(criteria.LongForm).Filters()).To(Equal([]string{"health-healthy"}))
+			Expect(s.(criteria.LongForm).Instance()).To(Equal("instance-id"))
+		})
+
+		It("treats readable labels in the group segments as names", func() {
+			s, err := criteria.ParseQuery("_filter.health-healthy.all.my-network.my-deployment.bosh", []string{"bosh"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.(criteria.LongForm).Filters()).To(Equal([]string{"health-healthy"}))
+			Expect(s.(criteria.LongForm).Group()).To(Equal("all"))
+		})
+
+		It("treats filter labels without the marker as names", func() {
+			s, err := criteria.ParseQuery("index-0.az-z1.my-network.my-deployment.bosh", []string{"bosh"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Type()).To(Equal(criteria.LONG))
+
+			long := s.(criteria.LongForm)
+			Expect(long.Filters()).To(BeEmpty())
+			Expect(long.Instance()).To(Equal("index-0"))
+			Expect(long.Group()).To(Equal("az-z1"))
+
+			s, err = criteria.ParseQuery("health-all.q-g7.bosh", []string{"bosh"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.(criteria.ShortForm).Filters()).To(BeEmpty())
+			Expect(s.(criteria.ShortForm).Instance()).To(Equal("health-all"))
+		})
+
+		It("treats bare health filter names as instance names", func() {
+			s, err := criteria.ParseQuery("all.my-group.my-network.my-deployment.bosh", []string{"bosh"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Type()).To(Equal(criteria.LONG))
+
+			long := s.(criteria.LongForm)
+			Expect(long.Filters()).To(BeEmpty())
+			Expect(long.Instance()).To(Equal("all"))
+			Expect(long.Group()).To(Equal("my-group"))
+			Expect(long.Network()).To(Equal("my-network"))
+			Expect(long.Deployment()).To(Equal("my-deployment"))
+
+			s, err = criteria.ParseQuery("_filter.health-all.healthy.my-group.my-network.my-deployment.bosh", []string{"bosh"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.(criteria.LongForm).Filters()).To(Equal([]string{"health-all"}))
+			Expect(s.(criteria.LongForm).Instance()).To(Equal("healthy"))
+		})
+
+		It("does not treat the labels as filters when there are too many segments", func() {
+			_, err := criteria.ParseQuery("_filter.health-healthy.query.extra.one.two.three.bosh", []string{"bosh"})
+			Expect(err).To(MatchError(`bad group segment query had 6 values []string{"health-healthy", "query", "extra", "one", "two", "three"}`))
+		})
+	})
+
 	It("garbage", func() {
 		s, err := criteria.ParseQuery("q-s0m1.my-group.my-network.my-deployment.bosh", []string{"bosh"})
 		Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,121 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package gossipfakes
+
+import (
+	"bosh-dns/dns/server/gossip"
+	"net/http"
+	"sync"
+)
+
+type FakeHTTPClientPoster struct {
+	PostStub        func(string, []byte) (*http.Response, error)
+	postMutex       sync.RWMutex
+	postArgsForCall []struct {
+		arg1 string
+		arg2 []byte
+	}
+	postReturns struct {
+		result1 *http.Response
+		result2 error
+	}
+	postReturnsOnCall map[int]struct {
+		result1 *http.Response
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeHTTPClientPoster) Post(arg1 string, arg2 []byte) (*http.Response, error) {
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.postMutex.Lock()
+	ret, specificReturn := fake.postReturnsOnCall[len(fake.postArgsForCall)]
+	fake.postArgsForCall = append(fake.postArgsForCall, struct {
+		arg1 string
+		arg2 []byte
+	}{arg1, arg2Copy})
+	stub := fake.PostStub
+	fakeReturns := fake.postReturns
+	fake.recordInvocation("Post", []interface{}{arg1, arg2Copy})
+	fake.postMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHTTPClientPoster) PostCallCount() int {
+	fake.postMutex.RLock()
+	defer fake.postMutex.RUnlock()
+	return len(fake.postArgsForCall)
+}
+
+func (fake *FakeHTTPClientPoster) PostCalls(stub func(string, []byte) (*http.Response, error)) {
+	fake.postMutex.Lock()
+	defer fake.postMutex.Unlock()
+	fake.PostStub = stub
+}
+
+func (fake *FakeHTTPClientPoster) PostArgsForCall(i int) (string, []byte) {
+	fake.postMutex.RLock()
+	defer fake.postMutex.RUnlock()
+	argsForCall := fake.postArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeHTTPClientPoster) PostReturns(result1 *http.Response, result2 error) {
+	fake.postMutex.Lock()
+	defer fake.postMutex.Unlock()
+	fake.PostStub = nil
+	fake.postReturns = struct {
+		result1 *http.Response
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHTTPClientPoster) PostReturnsOnCall(i int, result1 *http.Response, result2 error) {
+	fake.postMutex.Lock()
+	defer fake.postMutex.Unlock()
+	fake.PostStub = nil
+	if fake.postReturnsOnCall == nil {
+		fake.postReturnsOnCall = make(map[int]struct {
+			result1 *http.Response
+			result2 error
+		})
+	}
+	fake.postReturnsOnCall[i] = struct {
+		result1 *http.Response
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHTTPClientPoster) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeHTTPClientPoster) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ gossip.HTTPClientPoster = new(FakeHTTPClientPoster)
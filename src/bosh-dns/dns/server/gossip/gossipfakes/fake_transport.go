@@ -0,0 +1,115 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package gossipfakes
+
+import (
+	"bosh-dns/dns/server/gossip"
+	"sync"
+)
+
+type FakeTransport struct {
+	ExchangeStub        func(string, gossip.Message) (gossip.Message, error)
+	exchangeMutex       sync.RWMutex
+	exchangeArgsForCall []struct {
+		arg1 string
+		arg2 gossip.Message
+	}
+	exchangeReturns struct {
+		result1 gossip.Message
+		result2 error
+	}
+	exchangeReturnsOnCall map[int]struct {
+		result1 gossip.Message
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeTransport) Exchange(arg1 string, arg2 gossip.Message) (gossip.Message, error) {
+	fake.exchangeMutex.Lock()
+	ret, specificReturn := fake.exchangeReturnsOnCall[len(fake.exchangeArgsForCall)]
+	fake.exchangeArgsForCall = append(fake.exchangeArgsForCall, struct {
+		arg1 string
+		arg2 gossip.Message
+	}{arg1, arg2})
+	stub := fake.ExchangeStub
+	fakeReturns := fake.exchangeReturns
+	fake.recordInvocation("Exchange", []interface{}{arg1, arg2})
+	fake.exchangeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransport) ExchangeCallCount() int {
+	fake.exchangeMutex.RLock()
+	defer fake.exchangeMutex.RUnlock()
+	return len(fake.exchangeArgsForCall)
+}
+
+func (fake *FakeTransport) ExchangeCalls(stub func(string, gossip.Message) (gossip.Message, error)) {
+	fake.exchangeMutex.Lock()
+	defer fake.exchangeMutex.Unlock()
+	fake.ExchangeStub = stub
+}
+
+func (fake *FakeTransport) ExchangeArgsForCall(i int) (string, gossip.Message) {
+	fake.exchangeMutex.RLock()
+	defer fake.exchangeMutex.RUnlock()
+	argsForCall := fake.exchangeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeTransport) ExchangeReturns(result1 gossip.Message, result2 error) {
+	fake.exchangeMutex.Lock()
+	defer fake.exchangeMutex.Unlock()
+	fake.ExchangeStub = nil
+	fake.exchangeReturns = struct {
+		result1 gossip.Message
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransport) ExchangeReturnsOnCall(i int, result1 gossip.Message, result2 error) {
+	fake.exchangeMutex.Lock()
+	defer fake.exchangeMutex.Unlock()
+	fake.ExchangeStub = nil
+	if fake.exchangeReturnsOnCall == nil {
+		fake.exchangeReturnsOnCall = make(map[int]struct {
+			result1 gossip.Message
+			result2 error
+		})
+	}
+	fake.exchangeReturnsOnCall[i] = struct {
+		result1 gossip.Message
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransport) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeTransport) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ gossip.Transport = new(FakeTransport)
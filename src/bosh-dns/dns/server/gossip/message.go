@@ -0,0 +1,54 @@
+package gossip
+
+import (
+	"time"
+
+	"bosh-dns/healthcheck/api"
+)
+
+type MemberState string
+
+const (
+	StateAlive   MemberState = "alive"
+	StateSuspect MemberState = "suspect"
+	StateDead    MemberState = "dead"
+)
+
+// precedence orders the states of a member with the same incarnation.
+func (s MemberState) precedence() int {
+	switch s {
+	case StateSuspect:
+		return 1
+	case StateDead:
+		return 2
+	}
+
+	return 0
+}
+
+// Member is a bosh-dns instance that takes part in gossip. Incarnation is
+// only increased by the member itself to refute that it is suspect or dead.
+type Member struct {
+	IP          string      `json:"ip"`
+	State       MemberState `json:"state"`
+	Incarnation uint64      `json:"incarnation"`
+}
+
+// Message is exchanged with a member in both directions. Incarnation is the
+// one of the sender, which is identified by the address it connects from.
+type Message struct {
+	Incarnation  uint64               `json:"incarnation"`
+	Members      []Member             `json:"members,omitempty"`
+	Observations []ObservationMessage `json:"observations,omitempty"`
+}
+
+// ObservationMessage carries the age of an observation rather than the time
+// it was made, so that the clocks of members do not need to agree. Observer
+// is empty when the sender made the observation itself; observations made by
+// others are not accepted.
+type ObservationMessage struct {
+	IP       string           `json:"ip"`
+	Observer string           `json:"observer,omitempty"`
+	Result   api.HealthResult `json:"result"`
+	Age      time.Duration    `json:"age"`
+}
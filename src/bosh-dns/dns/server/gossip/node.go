@@ -0,0 +1,377 @@
+package gossip
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"bosh-dns/healthcheck/api"
+)
+
+const (
+	// Fanout is the number of members exchanged with every interval.
+	Fanout = 3
+
+	// SuspectIntervals is the number of intervals after which a suspect
+	// member that did not refute the suspicion is declared dead.
+	SuspectIntervals = 5
+
+	// DeadRetention is how long a dead member is remembered. Until it is
+	// forgotten the member is not added again by Join or Observe.
+	DeadRetention = 10 * time.Minute
+
+	// MaxObservations is the largest number of observations in a message,
+	// the most recent ones are sent.
+	MaxObservations = 1000
+)
+
+type Config struct {
+	// Interval is how often members are exchanged with.
+	Interval time.Duration
+	// MaxAge is the age after which observations are neither used nor shared.
+	MaxAge time.Duration
+	// LocalIPs are the addresses of this instance, which is never a member of
+	// its own list.
+	LocalIPs []string
+}
+
+// Observation is the health result of an instance as seen by this node or
+// by Observer, the member that checked the instance and sent the result.
+type Observation struct {
+	IP         string
+	Observer   string
+	Result     api.HealthResult
+	ObservedAt time.Time
+}
+
+type member struct {
+	Member
+	changedAt time.Time
+}
+
+// Node shares health observations with other bosh-dns instances. Every
+// interval it exchanges its members and observations with a few random
+// members, members that cannot be reached become suspect and eventually dead
+// unless they refute it.
+type Node struct {
+	config    Config
+	transport Transport
+	clock     clock.Clock
+	logger    boshlog.Logger
+	logTag    string
+
+	mutex        *sync.Mutex
+	incarnation  uint64
+	local        map[string]bool
+	members      map[string]*member
+	observations map[string]Observation
+}
+
+func NewNode(config Config, transport Transport, clock clock.Clock, logger boshlog.Logger) *Node {
+	local := map[string]bool{}
+	for _, ip := range config.LocalIPs {
+		local[ip] = true
+	}
+
+	return &Node{
+		config:    config,
+		transport: transport,
+		clock:     clock,
+		logger:    logger,
+		logTag:    "gossip",
+
+		mutex:        &sync.Mutex{},
+		local:        local,
+		members:      map[string]*member{},
+		observations: map[string]Observation{},
+	}
+}
+
+// Join adds members that are not known yet.
+func (n *Node) Join(ips ...string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	for _, ip := range ips {
+		n.join(ip)
+	}
+}
+
+func (n *Node) join(ip string) {
+	if n.local[ip] || n.members[ip] != nil {
+		return
+	}
+
+	n.logger.Debug(n.logTag, "member %s joined", ip)
+	n.members[ip] = &member{Member: Member{IP: ip, State: StateAlive}, changedAt: n.clock.Now()}
+}
+
+// Observe records a health result this node got from the instance itself.
+// Instances answering health checks run bosh-dns and are added as members.
+// Results that do not tell whether the instance is running are not shared,
+// they may only reflect the network between this node and the instance.
+func (n *Node) Observe(ip string, result api.HealthResult) {
+	if !shareable(result) {
+		return
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.observations[ip] = Observation{IP: ip, Result: result, ObservedAt: n.clock.Now()}
+	n.join(ip)
+}
+
+// Observation returns the most recent observation of the instance unless it
+// is older than the configured maximum age.
+func (n *Node) Observation(ip string) (Observation, bool) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	observation, found := n.observations[ip]
+	if !found || n.clock.Since(observation.ObservedAt) > n.config.MaxAge {
+		return Observation{}, false
+	}
+
+	return observation, true
+}
+
+// Members returns the known members ordered by IP.
+func (n *Node) Members() []Member {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	members := make([]Member, 0, len(n.members))
+	for _, m := range n.members {
+		members = append(members, m.Member)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].IP < members[j].IP })
+
+	return members
+}
+
+func (n *Node) Run(signal <-chan struct{}) {
+	timer := n.clock.NewTimer(n.config.Interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C():
+			n.Round()
+			timer.Reset(n.config.Interval)
+		case <-signal:
+			return
+		}
+	}
+}
+
+// Round exchanges with up to Fanout random members that are not dead and
+// with one random dead member, then expires suspect members, dead members
+// and old observations.
+func (n *Node) Round() {
+	n.mutex.Lock()
+	candidates := []string{}
+	dead := []string{}
+	for ip, m := range n.members {
+		if m.State == StateDead {
+			dead = append(dead, ip)
+		} else {
+			candidates = append(candidates, ip)
+		}
+	}
+	n.mutex.Unlock()
+
+	rand.Shuffle(len(candidates), func(i, j int) { //nolint:gosec
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if len(candidates) > Fanout {
+		candidates = candidates[:Fanout]
+	}
+
+	// probing a dead member as well lets partitions heal
+	if len(dead) > 0 {
+		candidates = append(candidates, dead[rand.Intn(len(dead))]) //nolint:gosec
+	}
+
+	wg := sync.WaitGroup{}
+	for _, ip := range candidates {
+		wg.Add(1)
+		go func(ip string) {
+			defer wg.Done()
+
+			response, err := n.transport.Exchange(ip, n.message())
+			if err != nil {
+				n.logger.Debug(n.logTag, "exchange with %s failed: %s", ip, err)
+				n.suspect(ip)
+				return
+			}
+
+			n.receive(ip, response)
+		}(ip)
+	}
+	wg.Wait()
+
+	n.expire()
+}
+
+// HandleExchange merges the message of a member that connected from the
+// given address and returns the message to answer with.
+func (n *Node) HandleExchange(from string, msg Message) Message {
+	n.receive(from, msg)
+	return n.message()
+}
+
+func (n *Node) message() Message {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	msg := Message{Incarnation: n.incarnation}
+	for _, m := range n.members {
+		msg.Members = append(msg.Members, m.Member)
+	}
+
+	// only observations of this node are shared, members do not pass on
+	// what they learned from others
+	now := n.clock.Now()
+	for _, observation := range n.observations {
+		age := now.Sub(observation.ObservedAt)
+		if observation.Observer != "" || age > n.config.MaxAge {
+			continue
+		}
+
+		msg.Observations = append(msg.Observations, ObservationMessage{
+			IP:       observation.IP,
+			Observer: observation.Observer,
+			Result:   observation.Result,
+			Age:      age,
+		})
+	}
+
+	sort.Slice(msg.Observations, func(i, j int) bool { return msg.Observations[i].Age < msg.Observations[j].Age })
+	if len(msg.Observations) > MaxObservations {
+		msg.Observations = msg.Observations[:MaxObservations]
+	}
+
+	return msg
+}
+
+func (n *Node) receive(from string, msg Message) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	now := n.clock.Now()
+
+	// hearing from a member directly shows that it is alive
+	if !n.local[from] {
+		n.join(from)
+		sender := n.members[from]
+		if msg.Incarnation > sender.Incarnation {
+			sender.Incarnation = msg.Incarnation
+		}
+		n.setState(sender, StateAlive, now)
+	}
+
+	for _, m := range msg.Members {
+		if n.local[m.IP] {
+			if m.State != StateAlive && m.Incarnation >= n.incarnation {
+				n.incarnation = m.Incarnation + 1
+				n.logger.Info(n.logTag, "refuting that this instance is %s with incarnation %d", m.State, n.incarnation)
+			}
+			continue
+		}
+
+		n.merge(m, now)
+	}
+
+	// Only observations the sender made itself are accepted, so a member can
+	// only speak for its own checks. Ages are claimed by the sender, ages
+	// below one interval are rounded up so that a sender cannot make its
+	// result win over a check this node made at about the same time.
+	for _, o := range msg.Observations {
+		if n.local[from] || o.Observer != "" || !shareable(o.Result) || o.Age < 0 || o.Age > n.config.MaxAge {
+			continue
+		}
+
+		age := o.Age
+		if age < n.config.Interval {
+			age = n.config.Interval
+		}
+
+		observedAt := now.Add(-age)
+		if existing, found := n.observations[o.IP]; found && !existing.ObservedAt.Before(observedAt) {
+			continue
+		}
+
+		n.observations[o.IP] = Observation{IP: o.IP, Observer: from, Result: o.Result, ObservedAt: observedAt}
+	}
+}
+
+// merge applies what another member knows about a member. A higher
+// incarnation always wins, with the same incarnation dead overrides suspect
+// and suspect overrides alive. Dead members are not learned from others.
+func (n *Node) merge(m Member, now time.Time) {
+	existing := n.members[m.IP]
+	if existing == nil {
+		if m.State != StateDead {
+			n.members[m.IP] = &member{Member: m, changedAt: now}
+		}
+		return
+	}
+
+	if m.Incarnation > existing.Incarnation {
+		existing.Incarnation = m.Incarnation
+		n.setState(existing, m.State, now)
+	} else if m.Incarnation == existing.Incarnation && m.State.precedence() > existing.State.precedence() {
+		n.setState(existing, m.State, now)
+	}
+}
+
+func (n *Node) suspect(ip string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if m := n.members[ip]; m != nil && m.State == StateAlive {
+		n.setState(m, StateSuspect, n.clock.Now())
+	}
+}
+
+func (n *Node) setState(m *member, state MemberState, now time.Time) {
+	if m.State == state {
+		return
+	}
+
+	n.logger.Debug(n.logTag, "member %s changed from %s to %s", m.IP, m.State, state)
+	m.State = state
+	m.changedAt = now
+}
+
+func (n *Node) expire() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	now := n.clock.Now()
+	for ip, m := range n.members {
+		switch {
+		case m.State == StateSuspect && now.Sub(m.changedAt) >= SuspectIntervals*n.config.Interval:
+			n.setState(m, StateDead, now)
+		case m.State == StateDead && now.Sub(m.changedAt) >= DeadRetention:
+			delete(n.members, ip)
+		}
+	}
+
+	for ip, observation := range n.observations {
+		if now.Sub(observation.ObservedAt) > n.config.MaxAge {
+			delete(n.observations, ip)
+		}
+	}
+}
+
+func shareable(result api.HealthResult) bool {
+	return result.State == api.StatusRunning || result.State == api.StatusFailing
+}
@@ -0,0 +1,302 @@
+package gossip_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bosh-dns/dns/server/gossip"
+	"bosh-dns/healthcheck/api"
+)
+
+// network connects in-process nodes, exchanges with unknown or unreachable
+// nodes fail.
+type network struct {
+	mutex       sync.Mutex
+	nodes       map[string]*gossip.Node
+	unreachable map[string]bool
+}
+
+func (n *network) setReachable(ip string, reachable bool) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.unreachable[ip] = !reachable
+}
+
+type networkTransport struct {
+	network *network
+	from    string
+}
+
+func (t networkTransport) Exchange(ip string, msg gossip.Message) (gossip.Message, error) {
+	t.network.mutex.Lock()
+	node, found := t.network.nodes[ip]
+	unreachable := t.network.unreachable[ip] || t.network.unreachable[t.from]
+	t.network.mutex.Unlock()
+
+	if !found || unreachable {
+		return gossip.Message{}, errors.New("connection refused")
+	}
+
+	return node.HandleExchange(t.from, msg), nil
+}
+
+var _ = Describe("Node", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		net       *network
+		config    gossip.Config
+		nodes     []*gossip.Node
+		ips       []string
+	)
+
+	newNode := func(ip string) *gossip.Node {
+		config.LocalIPs = []string{ip}
+		node := gossip.NewNode(config, networkTransport{network: net, from: ip}, fakeClock, &loggerfakes.FakeLogger{})
+
+		net.mutex.Lock()
+		net.nodes[ip] = node
+		net.mutex.Unlock()
+
+		return node
+	}
+
+	rounds := func(count int) {
+		for i := 0; i < count; i++ {
+			fakeClock.Increment(config.Interval)
+			for _, node := range nodes {
+				node.Round()
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		net = &network{nodes: map[string]*gossip.Node{}, unreachable: map[string]bool{}}
+		config = gossip.Config{Interval: time.Second, MaxAge: 30 * time.Second}
+
+		ips = []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}
+		nodes = nil
+		for _, ip := range ips {
+			nodes = append(nodes, newNode(ip))
+		}
+
+		// every node only knows the next one to begin with
+		for i, node := range nodes {
+			node.Join(ips[(i+1)%len(ips)])
+		}
+	})
+
+	It("learns every member", func() {
+		rounds(5)
+
+		for i, node := range nodes {
+			members := node.Members()
+			Expect(members).To(HaveLen(len(ips) - 1))
+			for _, member := range members {
+				Expect(member.IP).NotTo(Equal(ips[i]))
+				Expect(member.State).To(Equal(gossip.StateAlive))
+			}
+		}
+	})
+
+	It("shares observations with every member it exchanges with", func() {
+		observedAt := fakeClock.Now()
+		nodes[0].Observe("10.0.1.1", api.HealthResult{State: api.StatusFailing, GroupState: map[string]api.HealthStatus{"1": api.StatusFailing}})
+		rounds(15)
+
+		for _, node := range nodes[1:] {
+			observation, found := node.Observation("10.0.1.1")
+			Expect(found).To(BeTrue())
+			Expect(observation.Observer).To(Equal("10.0.0.1"))
+			Expect(observation.Result).To(Equal(api.HealthResult{State: api.StatusFailing, GroupState: map[string]api.HealthStatus{"1": api.StatusFailing}}))
+			Expect(observation.ObservedAt).To(Equal(observedAt))
+		}
+	})
+
+	It("does not pass on observations of other members", func() {
+		rounds(5)
+		net.setReachable("10.0.0.1", false)
+		nodes[1].HandleExchange("10.0.0.1", gossip.Message{Observations: []gossip.ObservationMessage{
+			{IP: "10.0.1.1", Result: api.HealthResult{State: api.StatusRunning}},
+		}})
+		rounds(15)
+
+		_, found := nodes[1].Observation("10.0.1.1")
+		Expect(found).To(BeTrue())
+		for _, node := range nodes[2:] {
+			_, found := node.Observation("10.0.1.1")
+			Expect(found).To(BeFalse())
+		}
+	})
+
+	It("keeps the most recent observation", func() {
+		nodes[0].Observe("10.0.1.1", api.HealthResult{State: api.StatusRunning})
+		fakeClock.Increment(2 * config.Interval)
+		nodes[3].Observe("10.0.1.1", api.HealthResult{State: api.StatusFailing})
+		rounds(15)
+
+		for _, node := range nodes {
+			observation, found := node.Observation("10.0.1.1")
+			Expect(found).To(BeTrue())
+			Expect(observation.Result.State).To(Equal(api.StatusFailing))
+		}
+	})
+
+	It("adds observed instances as members", func() {
+		nodes[0].Observe("10.0.1.1", api.HealthResult{State: api.StatusRunning})
+		Expect(nodes[0].Members()).To(ContainElement(gossip.Member{IP: "10.0.1.1", State: gossip.StateAlive}))
+	})
+
+	It("forgets observations older than the maximum age", func() {
+		nodes[0].Observe("10.0.1.1", api.HealthResult{State: api.StatusRunning})
+		rounds(3)
+
+		fakeClock.Increment(config.MaxAge)
+		for _, node := range nodes {
+			_, found := node.Observation("10.0.1.1")
+			Expect(found).To(BeFalse())
+		}
+
+		rounds(1)
+		for _, node := range nodes {
+			_, found := node.Observation("10.0.1.1")
+			Expect(found).To(BeFalse())
+		}
+	})
+
+	Describe("trust", func() {
+		var node *gossip.Node
+
+		BeforeEach(func() {
+			node = nodes[0]
+		})
+
+		receive := func(observations ...gossip.ObservationMessage) {
+			node.HandleExchange("10.0.0.9", gossip.Message{Observations: observations})
+		}
+
+		It("attributes observations to the sender", func() {
+			receive(gossip.ObservationMessage{IP: "10.0.1.1", Result: api.HealthResult{State: api.StatusRunning}, Age: 2 * time.Second})
+
+			observation, _ := node.Observation("10.0.1.1")
+			Expect(observation).To(Equal(gossip.Observation{IP: "10.0.1.1", Observer: "10.0.0.9", Result: api.HealthResult{State: api.StatusRunning}, ObservedAt: fakeClock.Now().Add(-2 * time.Second)}))
+		})
+
+		It("ignores observations the sender did not make itself", func() {
+			receive(gossip.ObservationMessage{IP: "10.0.1.1", Observer: "10.0.0.8", Result: api.HealthResult{State: api.StatusRunning}, Age: 2 * time.Second})
+
+			_, found := node.Observation("10.0.1.1")
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not trust ages below one interval", func() {
+			receive(gossip.ObservationMessage{IP: "10.0.1.1", Result: api.HealthResult{State: api.StatusRunning}})
+
+			observation, _ := node.Observation("10.0.1.1")
+			Expect(observation.ObservedAt).To(Equal(fakeClock.Now().Add(-config.Interval)))
+		})
+
+		It("ignores results that do not tell whether the instance is running", func() {
+			receive(gossip.ObservationMessage{IP: "10.0.1.1", Result: api.HealthResult{State: "unknown"}})
+			node.Observe("10.0.1.2", api.HealthResult{State: "unknown"})
+
+			_, found := node.Observation("10.0.1.1")
+			Expect(found).To(BeFalse())
+			_, found = node.Observation("10.0.1.2")
+			Expect(found).To(BeFalse())
+		})
+
+		It("ignores observations that are too old or from the future", func() {
+			receive(
+				gossip.ObservationMessage{IP: "10.0.1.1", Result: api.HealthResult{State: api.StatusRunning}, Age: config.MaxAge + time.Second},
+				gossip.ObservationMessage{IP: "10.0.1.2", Result: api.HealthResult{State: api.StatusRunning}, Age: -time.Second},
+			)
+
+			_, found := node.Observation("10.0.1.1")
+			Expect(found).To(BeFalse())
+			_, found = node.Observation("10.0.1.2")
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not replace more recent observations", func() {
+			node.Observe("10.0.1.1", api.HealthResult{State: api.StatusRunning})
+			receive(gossip.ObservationMessage{IP: "10.0.1.1", Result: api.HealthResult{State: api.StatusFailing}, Age: time.Second})
+
+			observation, _ := node.Observation("10.0.1.1")
+			Expect(observation.Result.State).To(Equal(api.StatusRunning))
+		})
+
+		It("never adds itself as a member", func() {
+			node.Join("10.0.0.1")
+			node.HandleExchange("10.0.0.9", gossip.Message{Members: []gossip.Member{{IP: "10.0.0.1", State: gossip.StateAlive}}})
+			Expect(node.Members()).NotTo(ContainElement(HaveField("IP", "10.0.0.1")))
+		})
+	})
+
+	Describe("failure detection", func() {
+		BeforeEach(func() {
+			rounds(5)
+		})
+
+		memberState := func(node *gossip.Node, ip string) gossip.MemberState {
+			for _, member := range node.Members() {
+				if member.IP == ip {
+					return member.State
+				}
+			}
+			return ""
+		}
+
+		It("declares unreachable members dead", func() {
+			net.setReachable("10.0.0.5", false)
+			rounds(3)
+
+			Expect(memberState(nodes[0], "10.0.0.5")).To(Or(Equal(gossip.StateSuspect), Equal(gossip.StateDead)))
+
+			rounds(gossip.SuspectIntervals + 5)
+			for _, node := range nodes[:4] {
+				Expect(memberState(node, "10.0.0.5")).To(Equal(gossip.StateDead))
+			}
+		})
+
+		It("takes members back once they are reachable again", func() {
+			net.setReachable("10.0.0.5", false)
+			rounds(gossip.SuspectIntervals + 5)
+
+			net.setReachable("10.0.0.5", true)
+			rounds(5)
+
+			for _, node := range nodes[:4] {
+				Expect(memberState(node, "10.0.0.5")).To(Equal(gossip.StateAlive))
+			}
+		})
+
+		It("refutes suspicion with a higher incarnation", func() {
+			nodes[0].HandleExchange("10.0.0.9", gossip.Message{Members: []gossip.Member{{IP: "10.0.0.5", State: gossip.StateSuspect}}})
+			Expect(memberState(nodes[0], "10.0.0.5")).To(Equal(gossip.StateSuspect))
+
+			rounds(gossip.SuspectIntervals - 1)
+
+			for _, node := range nodes[:4] {
+				Expect(node.Members()).To(ContainElement(gossip.Member{IP: "10.0.0.5", State: gossip.StateAlive, Incarnation: 1}))
+			}
+		})
+
+		It("forgets dead members after a while", func() {
+			net.setReachable("10.0.0.5", false)
+			rounds(gossip.SuspectIntervals + 5)
+
+			fakeClock.Increment(gossip.DeadRetention)
+			rounds(1)
+			for _, node := range nodes[:4] {
+				Expect(memberState(node, "10.0.0.5")).To(BeEmpty())
+			}
+		})
+	})
+})
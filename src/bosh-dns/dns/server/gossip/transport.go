@@ -0,0 +1,116 @@
+package gossip
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// CN is the common name of the certificates members authenticate with, the
+// same as for health checks.
+const CN = "health.bosh-dns"
+
+// maxMessageSize bounds the messages accepted from members.
+const maxMessageSize = 16 << 20
+
+//counterfeiter:generate . Transport
+
+type Transport interface {
+	Exchange(ip string, msg Message) (Message, error)
+}
+
+//counterfeiter:generate . HTTPClientPoster
+
+type HTTPClientPoster interface {
+	Post(endpoint string, payload []byte) (*http.Response, error)
+}
+
+type httpTransport struct {
+	client HTTPClientPoster
+	port   int
+}
+
+// NewHTTPTransport exchanges messages with the gossip server of members on
+// the given port. The client is expected to use mutual TLS.
+func NewHTTPTransport(client HTTPClientPoster, port int) Transport {
+	return &httpTransport{
+		client: client,
+		port:   port,
+	}
+}
+
+func (t *httpTransport) Exchange(ip string, msg Message) (Message, error) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return Message{}, err
+	}
+
+	response, err := t.client.Post(fmt.Sprintf("https://%s/gossip", net.JoinHostPort(ip, fmt.Sprintf("%d", t.port))), payload)
+	if err != nil {
+		return Message{}, err
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode != http.StatusOK {
+		return Message{}, fmt.Errorf("gossip with %s failed: %s", ip, response.Status)
+	}
+
+	var answer Message
+	err = json.NewDecoder(response.Body).Decode(&answer)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return answer, nil
+}
+
+// Handler serves the gossip exchange of a node. Members are identified by
+// the address they connect from and must present a client certificate for
+// CN.
+type Handler struct {
+	node   *Node
+	logger boshlog.Logger
+}
+
+func NewHandler(node *Node, logger boshlog.Logger) *Handler {
+	return &Handler{
+		node:   node,
+		logger: logger,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close() //nolint:errcheck
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != CN {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("TLS certificate common name does not match")) //nolint:errcheck
+		return
+	}
+
+	from, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var msg Message
+	err = json.NewDecoder(io.LimitReader(r.Body, maxMessageSize)).Decode(&msg)
+	if err != nil {
+		h.logger.Debug("gossip", "invalid message from %s: %s", from, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.node.HandleExchange(from, msg)) //nolint:errcheck
+}
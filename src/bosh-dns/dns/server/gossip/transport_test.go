@@ -0,0 +1,124 @@
+package gossip_test
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bosh-dns/dns/server/gossip"
+	"bosh-dns/dns/server/gossip/gossipfakes"
+	"bosh-dns/healthcheck/api"
+)
+
+var _ = Describe("HTTPTransport", func() {
+	var (
+		fakeClient *gossipfakes.FakeHTTPClientPoster
+		transport  gossip.Transport
+	)
+
+	BeforeEach(func() {
+		fakeClient = &gossipfakes.FakeHTTPClientPoster{}
+		transport = gossip.NewHTTPTransport(fakeClient, 8854)
+	})
+
+	It("posts the message to the gossip server of the member", func() {
+		fakeClient.PostReturns(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"incarnation":2,"members":[{"ip":"10.0.0.3","state":"suspect","incarnation":1}]}`)),
+		}, nil)
+
+		answer, err := transport.Exchange("10.0.0.2", gossip.Message{Incarnation: 1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(answer).To(Equal(gossip.Message{Incarnation: 2, Members: []gossip.Member{{IP: "10.0.0.3", State: gossip.StateSuspect, Incarnation: 1}}}))
+
+		endpoint, payload := fakeClient.PostArgsForCall(0)
+		Expect(endpoint).To(Equal("https://10.0.0.2:8854/gossip"))
+		Expect(payload).To(MatchJSON(`{"incarnation":1}`))
+	})
+
+	It("brackets IPv6 addresses", func() {
+		fakeClient.PostReturns(nil, errors.New("fake connect err"))
+
+		_, err := transport.Exchange("2601:646:102:95::24", gossip.Message{})
+		Expect(err).To(MatchError("fake connect err"))
+
+		endpoint, _ := fakeClient.PostArgsForCall(0)
+		Expect(endpoint).To(Equal("https://[2601:646:102:95::24]:8854/gossip"))
+	})
+
+	It("fails when the member does not answer with 200 OK", func() {
+		fakeClient.PostReturns(&http.Response{StatusCode: http.StatusForbidden, Status: "403 Forbidden", Body: io.NopCloser(bytes.NewBufferString(""))}, nil)
+
+		_, err := transport.Exchange("10.0.0.2", gossip.Message{})
+		Expect(err).To(MatchError("gossip with 10.0.0.2 failed: 403 Forbidden"))
+	})
+})
+
+var _ = Describe("Handler", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		node      *gossip.Node
+		handler   *gossip.Handler
+		w         *httptest.ResponseRecorder
+		r         *http.Request
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		node = gossip.NewNode(gossip.Config{Interval: time.Second, MaxAge: time.Minute, LocalIPs: []string{"10.0.0.1"}}, &gossipfakes.FakeTransport{}, fakeClock, &loggerfakes.FakeLogger{})
+		node.Observe("10.0.1.1", api.HealthResult{State: api.StatusRunning})
+		handler = gossip.NewHandler(node, &loggerfakes.FakeLogger{})
+
+		r = httptest.NewRequest("POST", "/gossip", bytes.NewBufferString(`{"incarnation":3,"observations":[{"ip":"10.0.1.2","result":{"state":"failing"},"age":1000000000}]}`))
+		r.RemoteAddr = "10.0.0.2:43210"
+		r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "health.bosh-dns"}}}}
+		w = httptest.NewRecorder()
+	})
+
+	It("merges the message of the member and answers with its own", func() {
+		handler.ServeHTTP(w, r)
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var answer gossip.Message
+		Expect(json.NewDecoder(w.Body).Decode(&answer)).To(Succeed())
+		Expect(answer.Members).To(ConsistOf(
+			gossip.Member{IP: "10.0.1.1", State: gossip.StateAlive},
+			gossip.Member{IP: "10.0.0.2", State: gossip.StateAlive, Incarnation: 3},
+		))
+		Expect(answer.Observations).To(ConsistOf(
+			gossip.ObservationMessage{IP: "10.0.1.1", Result: api.HealthResult{State: api.StatusRunning}},
+		))
+
+		observation, found := node.Observation("10.0.1.2")
+		Expect(found).To(BeTrue())
+		Expect(observation.Observer).To(Equal("10.0.0.2"))
+	})
+
+	It("rejects members without a certificate for the common name", func() {
+		r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "other"}}}}
+		handler.ServeHTTP(w, r)
+
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+		Expect(node.Members()).To(HaveLen(1))
+	})
+
+	It("rejects invalid messages", func() {
+		r = httptest.NewRequest("POST", "/gossip", bytes.NewBufferString(`duck?`))
+		r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "health.bosh-dns"}}}}
+		handler.ServeHTTP(w, r)
+
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+	})
+})
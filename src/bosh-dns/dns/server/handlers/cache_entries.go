@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	"github.com/coredns/coredns/plugin/pkg/response"
+	"github.com/miekg/dns"
+)
+
+// maxCacheEntries matches the combined capacity of the positive and negative
+// caches of the coredns cache plugin.
+const maxCacheEntries = 20000
+
+// The TTL limits the coredns cache plugin applies by default, so that entries
+// expire here when they expire in the coredns cache.
+const (
+	maxCacheTTL         = dnsutil.MaximumDefaultTTL
+	maxNegativeCacheTTL = dnsutil.MaximumDefaultTTL / 2
+	minCacheTTL         = dnsutil.MinimalDefaultTTL
+)
+
+// cacheEntry keeps a response in its packed wire format, which takes a
+// fraction of the memory of the unpacked message.
+type cacheEntry struct {
+	packed           []byte
+	name             string
+	dnssecOK         bool
+	checkingDisabled bool
+	storedAt         time.Time
+	expiresAt        time.Time
+}
+
+func (e cacheEntry) remainingTTL(now time.Time) time.Duration {
+	return e.expiresAt.Sub(now)
+}
+
+func (e cacheEntry) message() (*dns.Msg, error) {
+	msg := &dns.Msg{}
+	if err := msg.Unpack(e.packed); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// cacheEntries mirrors the responses held by the coredns cache plugin, whose
+// own storage is not accessible from outside of the plugin.
+type cacheEntries struct {
+	clock   clock.Clock
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+
+	// tracked holds the keys of the entries added since track was called, it
+	// is nil while no entries are tracked.
+	tracked map[string]struct{}
+}
+
+func newCacheEntries(clock clock.Clock) *cacheEntries {
+	return &cacheEntries{
+		clock:   clock,
+		entries: map[string]cacheEntry{},
+	}
+}
+
+func cacheEntryKey(qname string, qtype uint16, do, cd bool) string {
+	return fmt.Sprintf("%s/%d/%t/%t", strings.ToLower(qname), qtype, do, cd)
+}
+
+func (c *cacheEntries) add(req, resp *dns.Msg) {
+	if resp == nil || resp.Truncated || len(resp.Question) == 0 {
+		return
+	}
+
+	now := c.clock.Now()
+	mt, _ := response.Typify(resp, now.UTC())
+	switch mt {
+	case response.NoError, response.NoData, response.Delegation:
+	case response.NameError:
+		if !hasSOA(resp) {
+			return
+		}
+	default:
+		return
+	}
+
+	ttl := cacheTTL(resp, mt)
+	if ttl <= 0 {
+		return
+	}
+
+	msg := resp.Copy()
+	capTTLs(msg, uint32(ttl.Seconds()))
+	packed, err := msg.Pack()
+	if err != nil {
+		return
+	}
+
+	do := false
+	if opt := req.IsEdns0(); opt != nil {
+		do = opt.Do()
+	}
+
+	key := cacheEntryKey(resp.Question[0].Name, resp.Question[0].Qtype, do, req.CheckingDisabled)
+	entry := cacheEntry{
+		packed:           packed,
+		name:             strings.ToLower(resp.Question[0].Name),
+		dnssecOK:         do,
+		checkingDisabled: req.CheckingDisabled,
+		storedAt:         now,
+		expiresAt:        now.Add(ttl),
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, found := c.entries[key]; !found && len(c.entries) >= maxCacheEntries {
+		c.unsafeEvict(now)
+	}
+	c.entries[key] = entry
+
+	if c.tracked != nil {
+		c.tracked[key] = struct{}{}
+	}
+}
+
+// track starts recording which entries are added.
+func (c *cacheEntries) track() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.tracked = map[string]struct{}{}
+}
+
+// untrack stops recording and returns the entries added since track was
+// called that are still cached.
+func (c *cacheEntries) untrack() []cacheEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entries := make([]cacheEntry, 0, len(c.tracked))
+	for key := range c.tracked {
+		if entry, found := c.entries[key]; found {
+			entries = append(entries, entry)
+		}
+	}
+	c.tracked = nil
+
+	return entries
+}
+
+// unsafeEvict drops expired entries, or an arbitrary one if none have expired,
+// the same way the coredns cache evicts when it is full.
+func (c *cacheEntries) unsafeEvict(now time.Time) {
+	for key, entry := range c.entries {
+		if entry.remainingTTL(now) <= 0 {
+			delete(c.entries, key)
+		}
+	}
+
+	if len(c.entries) < maxCacheEntries {
+		return
+	}
+
+	for key := range c.entries {
+		delete(c.entries, key)
+		return
+	}
+}
+
+// live returns all entries that have not expired yet.
+func (c *cacheEntries) live() []cacheEntry {
+	now := c.clock.Now()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entries := make([]cacheEntry, 0, len(c.entries))
+	for key, entry := range c.entries {
+		if entry.remainingTTL(now) <= 0 {
+			delete(c.entries, key)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// remove drops all entries whose question name is accepted by match. It
+// returns the number of dropped entries and the unexpired entries that are
+// left.
+func (c *cacheEntries) remove(match func(name string) bool) (int, []cacheEntry) {
+	now := c.clock.Now()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	removed := 0
+	remaining := make([]cacheEntry, 0, len(c.entries))
+	for key, entry := range c.entries {
+		if match(entry.name) {
+			delete(c.entries, key)
+			removed++
+			continue
+		}
+
+		if entry.remainingTTL(now) <= 0 {
+			delete(c.entries, key)
+			continue
+		}
+		remaining = append(remaining, entry)
+	}
+
+	return removed, remaining
+}
+
+// cacheTTL returns how long the coredns cache keeps a response, which caps
+// the TTL of positive and negative responses and raises very short ones.
+func cacheTTL(resp *dns.Msg, mt response.Type) time.Duration {
+	maxTTL := maxCacheTTL
+	if mt == response.NameError || mt == response.NoData {
+		maxTTL = maxNegativeCacheTTL
+	}
+
+	ttl := dnsutil.MinimalTTLWithMaximum(resp, mt, maxTTL)
+	return min(max(ttl, minCacheTTL), maxTTL)
+}
+
+func capTTLs(msg *dns.Msg, ttl uint32) {
+	for _, section := range [][]dns.RR{msg.Answer, msg.Ns, msg.Extra} {
+		for _, rr := range section {
+			header := rr.Header()
+			if header.Rrtype != dns.TypeOPT && header.Ttl > ttl {
+				header.Ttl = ttl
+			}
+		}
+	}
+}
+
+func hasSOA(m *dns.Msg) bool {
+	for _, rr := range m.Ns {
+		if rr.Header().Rrtype == dns.TypeSOA {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,10 @@ package handlers
 
 import (
 	"context"
+	"math"
+	"net"
+	"sync"
+	"sync/atomic"
 
 	"code.cloudfoundry.org/clock"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -14,7 +18,10 @@ import (
 
 type CachingDNSHandler struct {
 	next      dns.Handler
-	ca        *cache.Cache
+	ca        *atomic.Pointer[cache.Cache]
+	entries   *cacheEntries
+	cacheLock *sync.RWMutex
+	flushLock *sync.Mutex
 	logger    boshlog.Logger
 	logTag    string
 	truncater dnsresolver.ResponseTruncater
@@ -23,6 +30,7 @@ type CachingDNSHandler struct {
 
 type requestContext struct {
 	fromCache bool
+	replay    *dns.Msg
 }
 
 type ctxKey int
@@ -32,11 +40,13 @@ const (
 )
 
 func NewCachingDNSHandler(next dns.Handler, truncater dnsresolver.ResponseTruncater, clock clock.Clock, logger boshlog.Logger) CachingDNSHandler {
-	ca := cache.New()
-	ca.Next = corednsHandlerWrapper{Next: next}
-	ca.Zones = []string{"."}
+	ca := &atomic.Pointer[cache.Cache]{}
+	ca.Store(newCorednsCache(next))
 	return CachingDNSHandler{
 		ca:        ca,
+		entries:   newCacheEntries(clock),
+		cacheLock: &sync.RWMutex{},
+		flushLock: &sync.Mutex{},
 		logTag:    "CachingDNSHandler",
 		next:      next,
 		truncater: truncater,
@@ -47,9 +57,16 @@ func NewCachingDNSHandler(next dns.Handler, truncater dnsresolver.ResponseTrunca
 
 func (c CachingDNSHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	internal.LogReceivedRequest(c.logger, c, c.logTag, r)
+	indicator := &requestContext{
+		fromCache: true,
+	}
+
 	var dnsMsg *dns.Msg
 	truncatingWriter := internal.WrapWriterWithIntercept(w, func(resp *dns.Msg) {
 		dnsMsg = resp
+		if r.RecursionDesired && !indicator.fromCache {
+			c.entries.add(r, resp)
+		}
 		c.truncater.TruncateIfNeeded(w, r, resp)
 	})
 
@@ -58,14 +75,15 @@ func (c CachingDNSHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 		return
 	}
 
-	indicator := &requestContext{
-		fromCache: true,
-	}
 	requestContext := context.WithValue(context.Background(), indicatorKey, indicator)
 
+	// the cache must not be replaced by Flush while the response is written
+	// into it, or the response ends up in the discarded cache
+	c.cacheLock.RLock()
 	before := c.clock.Now()
-	_, err := c.ca.ServeDNS(requestContext, truncatingWriter, r)
+	_, err := c.ca.Load().ServeDNS(requestContext, truncatingWriter, r)
 	duration := c.clock.Now().Sub(before).Nanoseconds()
+	c.cacheLock.RUnlock()
 
 	if err != nil {
 		c.logger.Error(c.logTag, "Error getting dns cache:", err.Error())
@@ -75,6 +93,116 @@ func (c CachingDNSHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	}
 }
 
+// Entries returns the cached responses with the TTLs of their records reduced
+// to the time they have left in the cache.
+func (c CachingDNSHandler) Entries() []*dns.Msg {
+	now := c.clock.Now()
+
+	entries := c.entries.live()
+	msgs := make([]*dns.Msg, 0, len(entries))
+	for _, entry := range entries {
+		msg, err := entry.message()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Skipping cache entry for %s: %s", entry.name, err.Error())
+			continue
+		}
+
+		reduceTTLs(msg, uint32(now.Sub(entry.storedAt).Seconds()))
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+// Flush removes the cached responses for every question name accepted by
+// match and returns how many responses were removed.
+func (c CachingDNSHandler) Flush(match func(name string) bool) int {
+	c.flushLock.Lock()
+	defer c.flushLock.Unlock()
+
+	c.entries.track()
+	flushed, remaining := c.entries.remove(match)
+	if flushed == 0 {
+		c.entries.untrack()
+		return 0
+	}
+
+	// the coredns cache cannot remove single entries, so the remaining ones
+	// are moved to a new cache which then replaces the current one. Queries
+	// keep being answered from the current cache while the new one is built.
+	ca := newCorednsCache(c.next)
+	for _, entry := range remaining {
+		c.warmNew(ca, entry)
+	}
+
+	// the cache must not be replaced while a response is written into it, and
+	// the responses cached since the flush started are moved over as well
+	c.cacheLock.Lock()
+	for _, entry := range c.entries.untrack() {
+		c.warmNew(ca, entry)
+	}
+	c.ca.Store(ca)
+	c.cacheLock.Unlock()
+
+	c.logger.Info(c.logTag, "Flushed %d cache entries", flushed)
+	return flushed
+}
+
+// warmNew populates a cache that replaces the current one with an entry that
+// is already recorded.
+func (c CachingDNSHandler) warmNew(ca *cache.Cache, e cacheEntry) {
+	if err := c.warmInto(ca, e, false); err != nil {
+		c.logger.Warn(c.logTag, "Dropping cache entry for %s: %s", e.name, err.Error())
+	}
+}
+
+// warm populates the cache with a previously cached response without
+// contacting the next handler.
+func (c CachingDNSHandler) warm(e cacheEntry) error {
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+
+	return c.warmInto(c.ca.Load(), e, true)
+}
+
+func (c CachingDNSHandler) warmInto(ca *cache.Cache, e cacheEntry, record bool) error {
+	msg, err := e.message()
+	if err != nil {
+		return err
+	}
+
+	if elapsed := c.clock.Now().Sub(e.storedAt); elapsed > 0 {
+		reduceTTLs(msg, uint32(math.Ceil(elapsed.Seconds())))
+	}
+
+	req := &dns.Msg{}
+	req.SetQuestion(msg.Question[0].Name, msg.Question[0].Qtype)
+	req.Question[0].Qclass = msg.Question[0].Qclass
+	req.RecursionDesired = true
+	req.CheckingDisabled = e.checkingDisabled
+	if e.dnssecOK {
+		req.SetEdns0(dns.DefaultMsgSize, true)
+	}
+
+	indicator := &requestContext{replay: msg}
+	requestContext := context.WithValue(context.Background(), indicatorKey, indicator)
+
+	writer := internal.WrapWriterWithIntercept(discardResponseWriter{}, func(resp *dns.Msg) {
+		if record {
+			c.entries.add(req, resp)
+		}
+	})
+	_, err = ca.ServeDNS(requestContext, writer, req)
+	return err
+}
+
+func newCorednsCache(next dns.Handler) *cache.Cache {
+	ca := cache.New()
+	ca.Next = corednsHandlerWrapper{Next: next}
+	ca.Zones = []string{"."}
+	return ca
+}
+
 type corednsHandlerWrapper struct {
 	Next dns.Handler
 }
@@ -83,6 +211,10 @@ func (w corednsHandlerWrapper) ServeDNS(ctx context.Context, writer dns.Response
 	requestContext := ctx.Value(indicatorKey).(*requestContext)
 	requestContext.fromCache = false
 
+	if requestContext.replay != nil {
+		return 0, writer.WriteMsg(requestContext.replay)
+	}
+
 	w.Next.ServeDNS(writer, m)
 	return 0, nil
 }
@@ -90,3 +222,16 @@ func (w corednsHandlerWrapper) ServeDNS(ctx context.Context, writer dns.Response
 func (w corednsHandlerWrapper) Name() string {
 	return "CorednsHandlerWrapper"
 }
+
+// discardResponseWriter accepts responses for cache warming, which has no
+// client to reply to.
+type discardResponseWriter struct{}
+
+func (discardResponseWriter) WriteMsg(*dns.Msg) error     { return nil }
+func (discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (discardResponseWriter) LocalAddr() net.Addr         { return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)} }
+func (discardResponseWriter) RemoteAddr() net.Addr        { return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)} }
+func (discardResponseWriter) Close() error                { return nil }
+func (discardResponseWriter) TsigStatus() error           { return nil }
+func (discardResponseWriter) TsigTimersOnly(bool)         {}
+func (discardResponseWriter) Hijack()                     {}
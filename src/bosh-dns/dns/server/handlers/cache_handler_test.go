@@ -2,6 +2,7 @@ package handlers_test
 
 import (
 	"net"
+	"strings"
 	"time"
 
 	"code.cloudfoundry.org/clock/fakeclock"
@@ -40,7 +41,7 @@ var _ bool = Describe("CacheHandler", func() {
 			// coredns now deep copies our object, which results in [] instead of nil for Ns and Extra,
 			// and a minimum TTL value being set. Mock those values in the test object so that we can
 			// use simple gomega Equals matchers in the tests.
-			Answer: []dns.RR{&dns.A{A: net.ParseIP("99.99.99.99"), Hdr: dns.RR_Header{Ttl: 5}}},
+			Answer: []dns.RR{&dns.A{A: net.ParseIP("99.99.99.99"), Hdr: dns.RR_Header{Name: "my-instance.my-group.my-network.my-deployment.bosh.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 5}}},
 			Ns:     []dns.RR{},
 			Extra:  []dns.RR{},
 		}
@@ -122,4 +123,165 @@ var _ bool = Describe("CacheHandler", func() {
 			})
 		})
 	})
+
+	Describe("Entries", func() {
+		BeforeEach(func() {
+			m := &dns.Msg{}
+			SetQuestion(m, nil, "my-instance.my-group.my-network.my-deployment.bosh.", dns.TypeANY)
+			cacheHandler.ServeDNS(fakeWriter, m)
+		})
+
+		It("returns the cached responses", func() {
+			entries := cacheHandler.Entries()
+			Expect(entries).To(HaveLen(1))
+			Expect(strings.ToLower(entries[0].Question[0].Name)).To(Equal("my-instance.my-group.my-network.my-deployment.bosh."))
+			Expect(entries[0].Answer[0].(*dns.A).A.String()).To(Equal("99.99.99.99"))
+		})
+
+		It("reduces the TTLs by the time spent in the cache", func() {
+			fakeClock.Increment(2 * time.Second)
+			Expect(cacheHandler.Entries()[0].Answer[0].Header().Ttl).To(Equal(uint32(3)))
+		})
+
+		It("omits expired responses", func() {
+			fakeClock.Increment(6 * time.Second)
+			Expect(cacheHandler.Entries()).To(BeEmpty())
+		})
+	})
+
+	Describe("Entries with TTLs above the limits of the cache", func() {
+		BeforeEach(func() {
+			fakeDnsHandler.ServeDNSStub = func(w dns.ResponseWriter, r *dns.Msg) {
+				m := &dns.Msg{}
+				m.SetReply(r)
+				if strings.EqualFold(r.Question[0].Name, "missing.bosh.") {
+					m.Rcode = dns.RcodeNameError
+					m.Ns = []dns.RR{&dns.SOA{
+						Hdr:    dns.RR_Header{Name: "bosh.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 86400},
+						Ns:     "ns.bosh.",
+						Mbox:   "hostmaster.bosh.",
+						Minttl: 86400,
+					}}
+				} else {
+					m.Answer = []dns.RR{&dns.A{
+						Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 86400},
+						A:   net.ParseIP("99.99.99.99"),
+					}}
+				}
+				Expect(w.WriteMsg(m)).To(Succeed())
+			}
+		})
+
+		It("caps the TTL of positive responses the way the cache does", func() {
+			m := &dns.Msg{}
+			SetQuestion(m, nil, "present.bosh.", dns.TypeA)
+			cacheHandler.ServeDNS(fakeWriter, m)
+
+			Expect(cacheHandler.Entries()[0].Answer[0].Header().Ttl).To(Equal(uint32(3600)))
+
+			fakeClock.Increment(3600 * time.Second)
+			Expect(cacheHandler.Entries()).To(BeEmpty())
+		})
+
+		It("caps the TTL of negative responses the way the cache does", func() {
+			m := &dns.Msg{}
+			SetQuestion(m, nil, "missing.bosh.", dns.TypeA)
+			cacheHandler.ServeDNS(fakeWriter, m)
+
+			Expect(cacheHandler.Entries()[0].Ns[0].Header().Ttl).To(Equal(uint32(1800)))
+
+			fakeClock.Increment(1800 * time.Second)
+			Expect(cacheHandler.Entries()).To(BeEmpty())
+		})
+	})
+
+	Describe("Flush", func() {
+		BeforeEach(func() {
+			for _, name := range []string{"one.bosh.", "two.bosh."} {
+				m := &dns.Msg{}
+				SetQuestion(m, nil, name, dns.TypeANY)
+				cacheHandler.ServeDNS(fakeWriter, m)
+			}
+			Expect(fakeDnsHandler.ServeDNSCallCount()).To(Equal(2))
+		})
+
+		It("removes matching responses from the cache", func() {
+			Expect(cacheHandler.Flush(func(name string) bool { return name == "one.bosh." })).To(Equal(1))
+			Expect(cacheHandler.Entries()).To(HaveLen(1))
+
+			m := &dns.Msg{}
+			SetQuestion(m, nil, "one.bosh.", dns.TypeANY)
+			cacheHandler.ServeDNS(fakeWriter, m)
+			Expect(fakeDnsHandler.ServeDNSCallCount()).To(Equal(3))
+		})
+
+		It("keeps responses of queries that are answered while flushing", func() {
+			release := make(chan struct{})
+			serveDNS := fakeDnsHandler.ServeDNSStub
+			fakeDnsHandler.ServeDNSStub = func(w dns.ResponseWriter, r *dns.Msg) {
+				if strings.EqualFold(r.Question[0].Name, "three.bosh.") {
+					<-release
+				}
+				serveDNS(w, r)
+			}
+
+			answered := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(answered)
+
+				m := &dns.Msg{}
+				SetQuestion(m, nil, "three.bosh.", dns.TypeANY)
+				cacheHandler.ServeDNS(&internalfakes.FakeResponseWriter{}, m)
+			}()
+			Eventually(fakeDnsHandler.ServeDNSCallCount).Should(Equal(3))
+
+			flushed := make(chan int)
+			go func() {
+				flushed <- cacheHandler.Flush(func(name string) bool { return name == "two.bosh." })
+			}()
+			Consistently(flushed).ShouldNot(Receive())
+
+			close(release)
+			Eventually(answered).Should(BeClosed())
+			Eventually(flushed).Should(Receive(Equal(1)))
+
+			Expect(cacheHandler.Entries()).To(HaveLen(2))
+
+			m := &dns.Msg{}
+			SetQuestion(m, nil, "three.bosh.", dns.TypeANY)
+			cacheHandler.ServeDNS(fakeWriter, m)
+			Expect(fakeDnsHandler.ServeDNSCallCount()).To(Equal(3))
+		})
+
+		It("keeps serving the remaining responses from the cache", func() {
+			cacheHandler.Flush(func(name string) bool { return name == "one.bosh." })
+
+			m := &dns.Msg{}
+			SetQuestion(m, nil, "two.bosh.", dns.TypeANY)
+			cacheHandler.ServeDNS(fakeWriter, m)
+			Expect(fakeDnsHandler.ServeDNSCallCount()).To(Equal(2))
+		})
+
+		It("keeps answering the remaining responses from the cache while flushing", func() {
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+
+				for i := 0; i < 50; i++ {
+					cacheHandler.Flush(func(name string) bool { return name == "one.bosh." })
+				}
+			}()
+
+			for i := 0; i < 50; i++ {
+				m := &dns.Msg{}
+				SetQuestion(m, nil, "two.bosh.", dns.TypeANY)
+				cacheHandler.ServeDNS(&internalfakes.FakeResponseWriter{}, m)
+			}
+
+			Eventually(done).Should(BeClosed())
+			Expect(fakeDnsHandler.ServeDNSCallCount()).To(Equal(2))
+		})
+	})
 })
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/miekg/dns"
+)
+
+const cacheSnapshotVersion = 1
+
+type cacheSnapshotFile struct {
+	Version int                  `json:"version"`
+	Entries []cacheSnapshotEntry `json:"entries"`
+}
+
+type cacheSnapshotEntry struct {
+	Message          []byte    `json:"message"`
+	DNSSECOK         bool      `json:"dnssec_ok"`
+	CheckingDisabled bool      `json:"checking_disabled"`
+	StoredAt         time.Time `json:"stored_at"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// CacheSnapshot persists the responses of a CachingDNSHandler to disk so that
+// they survive a restart of bosh-dns.
+type CacheSnapshot struct {
+	path   string
+	fs     boshsys.FileSystem
+	clock  clock.Clock
+	logger boshlog.Logger
+	logTag string
+}
+
+func NewCacheSnapshot(path string, fs boshsys.FileSystem, clock clock.Clock, logger boshlog.Logger) CacheSnapshot {
+	return CacheSnapshot{
+		path:   path,
+		fs:     fs,
+		clock:  clock,
+		logger: logger,
+		logTag: "CacheSnapshot",
+	}
+}
+
+// Save writes all unexpired entries of the cache to the snapshot file. The file
+// is replaced atomically so that a partially written snapshot is never read.
+func (s CacheSnapshot) Save(c CachingDNSHandler) error {
+	snapshot := cacheSnapshotFile{Version: cacheSnapshotVersion, Entries: []cacheSnapshotEntry{}}
+
+	for _, entry := range c.entries.live() {
+		snapshot.Entries = append(snapshot.Entries, cacheSnapshotEntry{
+			Message:          entry.packed,
+			DNSSECOK:         entry.dnssecOK,
+			CheckingDisabled: entry.checkingDisabled,
+			StoredAt:         entry.storedAt,
+			ExpiresAt:        entry.expiresAt,
+		})
+	}
+
+	contents, err := json.Marshal(snapshot)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling cache snapshot")
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := s.fs.WriteFile(tmpPath, contents); err != nil {
+		return bosherr.WrapErrorf(err, "Writing cache snapshot to '%s'", tmpPath)
+	}
+
+	if err := s.fs.Rename(tmpPath, s.path); err != nil {
+		return bosherr.WrapErrorf(err, "Moving cache snapshot to '%s'", s.path)
+	}
+
+	s.logger.Info(s.logTag, "Saved %d cache entries to %s", len(snapshot.Entries), s.path)
+	return nil
+}
+
+// Restore loads the snapshot file into the cache. Entries that expired while
+// bosh-dns was not running are dropped and the TTLs of the remaining ones are
+// reduced by the time that has passed. A snapshot that cannot be parsed is
+// removed so that it is not retried on the next start.
+func (s CacheSnapshot) Restore(c CachingDNSHandler) (int, error) {
+	if !s.fs.FileExists(s.path) {
+		return 0, nil
+	}
+
+	contents, err := s.fs.ReadFile(s.path)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Reading cache snapshot '%s'", s.path)
+	}
+
+	var snapshot cacheSnapshotFile
+	err = json.Unmarshal(contents, &snapshot)
+	if err == nil && snapshot.Version != cacheSnapshotVersion {
+		err = bosherr.Errorf("Unsupported version %d", snapshot.Version)
+	}
+	if err != nil {
+		s.discard()
+		return 0, bosherr.WrapErrorf(err, "Parsing cache snapshot '%s'", s.path)
+	}
+
+	now := s.clock.Now()
+	restored := 0
+
+	for i, snapshotEntry := range snapshot.Entries {
+		if !snapshotEntry.ExpiresAt.After(now) {
+			continue
+		}
+
+		msg := &dns.Msg{}
+		if err := msg.Unpack(snapshotEntry.Message); err != nil || len(msg.Question) != 1 {
+			s.logger.Warn(s.logTag, "Skipping corrupt cache entry #%d in %s", i, s.path)
+			continue
+		}
+
+		err := c.warm(cacheEntry{
+			packed:           snapshotEntry.Message,
+			name:             msg.Question[0].Name,
+			dnssecOK:         snapshotEntry.DNSSECOK,
+			checkingDisabled: snapshotEntry.CheckingDisabled,
+			storedAt:         snapshotEntry.StoredAt,
+		})
+		if err != nil {
+			s.logger.Warn(s.logTag, "Skipping cache entry for %s: %s", msg.Question[0].Name, err.Error())
+			continue
+		}
+
+		restored++
+	}
+
+	s.logger.Info(s.logTag, "Restored %d of %d cache entries from %s", restored, len(snapshot.Entries), s.path)
+	return restored, nil
+}
+
+func (s CacheSnapshot) discard() {
+	if err := s.fs.RemoveAll(s.path); err != nil {
+		s.logger.Warn(s.logTag, "Unable to remove cache snapshot '%s': %s", s.path, err.Error())
+	}
+}
+
+func reduceTTLs(msg *dns.Msg, seconds uint32) {
+	for _, section := range [][]dns.RR{msg.Answer, msg.Ns, msg.Extra} {
+		for _, rr := range section {
+			header := rr.Header()
+			if header.Rrtype == dns.TypeOPT {
+				continue
+			}
+
+			if header.Ttl > seconds {
+				header.Ttl -= seconds
+			} else {
+				header.Ttl = 0
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package handlers_test
+
+import (
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bosh-dns/dns/server/handlers"
+	"bosh-dns/dns/server/handlers/handlersfakes"
+	"bosh-dns/dns/server/internal/internalfakes"
+	"bosh-dns/dns/server/records/dnsresolver/dnsresolverfakes"
+)
+
+var _ = Describe("CacheSnapshot", func() {
+	var (
+		fakeDnsHandler *handlersfakes.FakeDNSHandler
+		fakeWriter     *internalfakes.FakeResponseWriter
+		fakeTruncater  *dnsresolverfakes.FakeResponseTruncater
+		fakeClock      *fakeclock.FakeClock
+		fakeLogger     *loggerfakes.FakeLogger
+		fakeFS         *fakesys.FakeFileSystem
+		cacheHandler   handlers.CachingDNSHandler
+		snapshot       handlers.CacheSnapshot
+	)
+
+	newRequest := func() *dns.Msg {
+		m := &dns.Msg{}
+		m.SetQuestion("example.com.", dns.TypeA)
+		return m
+	}
+
+	newCacheHandler := func() handlers.CachingDNSHandler {
+		return handlers.NewCachingDNSHandler(fakeDnsHandler, fakeTruncater, fakeClock, fakeLogger)
+	}
+
+	BeforeEach(func() {
+		fakeDnsHandler = &handlersfakes.FakeDNSHandler{}
+		fakeWriter = &internalfakes.FakeResponseWriter{}
+		fakeTruncater = &dnsresolverfakes.FakeResponseTruncater{}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		fakeLogger = &loggerfakes.FakeLogger{}
+		fakeFS = fakesys.NewFakeFileSystem()
+
+		fakeDnsHandler.ServeDNSStub = func(w dns.ResponseWriter, r *dns.Msg) {
+			m := &dns.Msg{}
+			m.SetReply(r)
+			m.Answer = []dns.RR{&dns.A{
+				Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+				A:   net.ParseIP("99.99.99.99"),
+			}}
+			Expect(w.WriteMsg(m)).To(Succeed())
+		}
+
+		cacheHandler = newCacheHandler()
+		snapshot = handlers.NewCacheSnapshot("/snapshot.json", fakeFS, fakeClock, fakeLogger)
+	})
+
+	Context("when a snapshot was saved", func() {
+		BeforeEach(func() {
+			cacheHandler.ServeDNS(fakeWriter, newRequest())
+			Expect(fakeDnsHandler.ServeDNSCallCount()).To(Equal(1))
+
+			Expect(snapshot.Save(cacheHandler)).To(Succeed())
+			Expect(fakeFS.FileExists("/snapshot.json")).To(BeTrue())
+			Expect(fakeFS.FileExists("/snapshot.json.tmp")).To(BeFalse())
+		})
+
+		It("serves restored entries without asking the next handler", func() {
+			restoredHandler := newCacheHandler()
+			restored, err := snapshot.Restore(restoredHandler)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored).To(Equal(1))
+
+			restoredHandler.ServeDNS(fakeWriter, newRequest())
+			Expect(fakeDnsHandler.ServeDNSCallCount()).To(Equal(1))
+
+			response := fakeWriter.WriteMsgArgsForCall(1)
+			Expect(response.Answer).To(HaveLen(1))
+			Expect(response.Answer[0].(*dns.A).A.String()).To(Equal("99.99.99.99"))
+		})
+
+		It("reduces TTLs by the time that has passed since saving", func() {
+			fakeClock.Increment(100 * time.Second)
+
+			restoredHandler := newCacheHandler()
+			_, err := snapshot.Restore(restoredHandler)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(restoredHandler.Entries()[0].Answer[0].Header().Ttl).To(Equal(uint32(200)))
+
+			restoredHandler.ServeDNS(fakeWriter, newRequest())
+			response := fakeWriter.WriteMsgArgsForCall(1)
+			Expect(response.Answer[0].Header().Ttl).To(Equal(uint32(200)))
+		})
+
+		It("drops entries that have expired", func() {
+			fakeClock.Increment(301 * time.Second)
+
+			restoredHandler := newCacheHandler()
+			restored, err := snapshot.Restore(restoredHandler)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored).To(Equal(0))
+
+			restoredHandler.ServeDNS(fakeWriter, newRequest())
+			Expect(fakeDnsHandler.ServeDNSCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("when there is no snapshot", func() {
+		It("restores nothing", func() {
+			restored, err := snapshot.Restore(cacheHandler)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored).To(Equal(0))
+		})
+	})
+
+	Context("when the snapshot is corrupt", func() {
+		BeforeEach(func() {
+			Expect(fakeFS.WriteFileString("/snapshot.json", `{"version":1,"entries":[`)).To(Succeed())
+		})
+
+		It("returns an error and removes the snapshot", func() {
+			_, err := snapshot.Restore(cacheHandler)
+			Expect(err).To(MatchError(ContainSubstring("Parsing cache snapshot '/snapshot.json'")))
+			Expect(fakeFS.FileExists("/snapshot.json")).To(BeFalse())
+		})
+	})
+
+	Context("when an entry of the snapshot is corrupt", func() {
+		BeforeEach(func() {
+			Expect(fakeFS.WriteFileString("/snapshot.json", `{"version":1,"entries":[{"message":"AAAA","expires_at":"2999-01-01T00:00:00Z"}]}`)).To(Succeed())
+		})
+
+		It("skips the entry", func() {
+			restored, err := snapshot.Restore(cacheHandler)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/miekg/dns"
+)
+
+// SwappingServeMux is a dns.ServeMux whose registrations can be changed
+// together. Every change builds a new dns.ServeMux and swaps it in, so a query
+// is always matched against either all or none of the changes of an Update.
+type SwappingServeMux struct {
+	mutex    sync.Mutex
+	handlers map[string]dns.Handler
+	current  atomic.Pointer[dns.ServeMux]
+}
+
+func NewSwappingServeMux() *SwappingServeMux {
+	mux := &SwappingServeMux{handlers: map[string]dns.Handler{}}
+	mux.current.Store(dns.NewServeMux())
+
+	return mux
+}
+
+func (m *SwappingServeMux) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	m.current.Load().ServeDNS(w, req)
+}
+
+func (m *SwappingServeMux) Handle(pattern string, handler dns.Handler) {
+	m.Update(map[string]dns.Handler{pattern: handler}, nil)
+}
+
+func (m *SwappingServeMux) HandleRemove(pattern string) {
+	m.Update(nil, []string{pattern})
+}
+
+// Update removes the handlers of the given patterns and registers the added
+// ones in a single swap.
+func (m *SwappingServeMux) Update(add map[string]dns.Handler, remove []string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, pattern := range remove {
+		delete(m.handlers, dns.CanonicalName(pattern))
+	}
+
+	for pattern, handler := range add {
+		m.handlers[dns.CanonicalName(pattern)] = handler
+	}
+
+	next := dns.NewServeMux()
+	for pattern, handler := range m.handlers {
+		next.Handle(pattern, handler)
+	}
+
+	m.current.Store(next)
+}
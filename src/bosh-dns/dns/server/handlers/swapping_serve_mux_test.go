@@ -0,0 +1,97 @@
+package handlers_test
+
+import (
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bosh-dns/dns/server/handlers"
+	"bosh-dns/dns/server/internal/internalfakes"
+)
+
+var _ = Describe("SwappingServeMux", func() {
+	var (
+		mux        *handlers.SwappingServeMux
+		fakeWriter *internalfakes.FakeResponseWriter
+	)
+
+	answeringHandler := func(rcode int) dns.Handler {
+		return dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+			m := &dns.Msg{}
+			m.SetRcode(req, rcode)
+			Expect(w.WriteMsg(m)).To(Succeed())
+		})
+	}
+
+	serve := func(name string) int {
+		m := &dns.Msg{}
+		m.SetQuestion(name, dns.TypeA)
+		mux.ServeDNS(fakeWriter, m)
+
+		return fakeWriter.WriteMsgArgsForCall(fakeWriter.WriteMsgCallCount() - 1).Rcode
+	}
+
+	BeforeEach(func() {
+		mux = handlers.NewSwappingServeMux()
+		fakeWriter = &internalfakes.FakeResponseWriter{}
+	})
+
+	It("serves queries with the handler of the closest domain", func() {
+		mux.Handle(".", answeringHandler(dns.RcodeServerFailure))
+		mux.Handle("example.com.", answeringHandler(dns.RcodeNameError))
+
+		Expect(serve("host.Example.com.")).To(Equal(dns.RcodeNameError))
+		Expect(serve("other.org.")).To(Equal(dns.RcodeServerFailure))
+	})
+
+	It("refuses queries that no handler matches", func() {
+		Expect(serve("example.com.")).To(Equal(dns.RcodeRefused))
+	})
+
+	It("removes handlers", func() {
+		mux.Handle("example.com.", answeringHandler(dns.RcodeNameError))
+		mux.HandleRemove("example.com.")
+
+		Expect(serve("example.com.")).To(Equal(dns.RcodeRefused))
+	})
+
+	Describe("Update", func() {
+		It("adds and removes handlers together", func() {
+			mux.Handle("removed.", answeringHandler(dns.RcodeNameError))
+			mux.Handle("kept.", answeringHandler(dns.RcodeNameError))
+
+			mux.Update(map[string]dns.Handler{
+				"kept.":  answeringHandler(dns.RcodeServerFailure),
+				"added.": answeringHandler(dns.RcodeSuccess),
+			}, []string{"removed."})
+
+			Expect(serve("removed.")).To(Equal(dns.RcodeRefused))
+			Expect(serve("kept.")).To(Equal(dns.RcodeServerFailure))
+			Expect(serve("added.")).To(Equal(dns.RcodeSuccess))
+		})
+
+		It("keeps serving domains that are replaced while queries are answered", func() {
+			mux.Handle("kept.", answeringHandler(dns.RcodeSuccess))
+
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+
+				for i := 0; i < 100; i++ {
+					mux.Update(map[string]dns.Handler{"kept.": answeringHandler(dns.RcodeSuccess)}, []string{"kept."})
+				}
+			}()
+
+			for i := 0; i < 100; i++ {
+				m := &dns.Msg{}
+				m.SetQuestion("kept.", dns.TypeA)
+				writer := &internalfakes.FakeResponseWriter{}
+				mux.ServeDNS(writer, m)
+				Expect(writer.WriteMsgArgsForCall(0).Rcode).To(Equal(dns.RcodeSuccess))
+			}
+
+			Eventually(done).Should(BeClosed())
+		})
+	})
+})
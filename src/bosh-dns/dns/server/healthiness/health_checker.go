@@ -8,7 +8,11 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"reflect"
+	"sync"
+	"time"
 
+	"code.cloudfoundry.org/clock"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	"bosh-dns/healthcheck/api"
@@ -20,27 +24,78 @@ type HTTPClientGetter interface {
 	Get(endpoint string) (*http.Response, error)
 }
 
+const (
+	// StreamRetryInterval is how long to wait before connecting to the health
+	// stream of an instance again after it ended.
+	StreamRetryInterval = 5 * time.Second
+
+	// StreamUnsupportedRetryInterval is how long to wait before connecting
+	// again to an instance that did not provide a health stream, so that the
+	// stream is picked up once the instance is upgraded.
+	StreamUnsupportedRetryInterval = 10 * time.Minute
+
+	// StreamTimeout is how long a health stream may stay silent before it is
+	// considered broken.
+	StreamTimeout = 3 * api.HealthStreamHeartbeat
+)
+
 type healthChecker struct {
-	client HTTPClientGetter
-	port   int
-	logger boshlog.Logger
-	logTag string
+	client       HTTPClientGetter
+	streamClient HTTPClientGetter
+	clock        clock.Clock
+	port         int
+	logger       boshlog.Logger
+	logTag       string
+
+	streams     map[string]*healthStream
+	streamMutex *sync.Mutex
+}
+
+type healthStream struct {
+	result    api.HealthResult
+	connected bool
+	stop      chan struct{}
 }
 
 func NewHealthChecker(client HTTPClientGetter, port int, logger boshlog.Logger) HealthChecker {
 	return &healthChecker{
-		client: client,
-		port:   port,
-		logTag: "HealthChecker",
-		logger: logger,
+		client:      client,
+		port:        port,
+		logTag:      "HealthChecker",
+		logger:      logger,
+		streams:     map[string]*healthStream{},
+		streamMutex: &sync.Mutex{},
 	}
 }
 
+// NewStreamingHealthChecker returns a health checker that also subscribes to
+// the health stream of instances. streamClient must not time out reading
+// responses, broken streams are detected with StreamTimeout instead; it
+// should time out connecting, see tlsclient.NewStreamingFromFiles. While the stream of
+// an instance is connected, GetStatus returns its latest result without
+// polling the instance.
+func NewStreamingHealthChecker(client, streamClient HTTPClientGetter, clock clock.Clock, port int, logger boshlog.Logger) HealthChecker {
+	hc := NewHealthChecker(client, port, logger).(*healthChecker)
+	hc.streamClient = streamClient
+	hc.clock = clock
+
+	return hc
+}
+
 type healthStatus struct { //nolint:unused
 	State api.HealthStatus
 }
 
 func (hc *healthChecker) GetStatus(ip string) api.HealthResult {
+	hc.streamMutex.Lock()
+	stream, found := hc.streams[ip]
+	if found && stream.connected {
+		result := stream.result
+		hc.streamMutex.Unlock()
+		return result
+	}
+	hc.streamMutex.Unlock()
+
 	endpoint := fmt.Sprintf("https://%s/health", net.JoinHostPort(ip, fmt.Sprintf("%d", hc.port)))
 
 	response, err := hc.client.Get(endpoint)
@@ -69,3 +124,138 @@ func (hc *healthChecker) GetStatus(ip string) api.HealthResult {
 
 	return parsedResponse
 }
+
+// Subscribe connects to the health stream of the instance and calls changed
+// with every result that differs from the previous one. The stream is
+// reconnected until Unsubscribe is called, an instance that does not provide
+// one is only tried again after StreamUnsupportedRetryInterval.
+func (hc *healthChecker) Subscribe(ip string, changed func(api.HealthResult)) {
+	if hc.streamClient == nil {
+		return
+	}
+
+	hc.streamMutex.Lock()
+	defer hc.streamMutex.Unlock()
+
+	if _, found := hc.streams[ip]; found {
+		return
+	}
+
+	stream := &healthStream{stop: make(chan struct{})}
+	hc.streams[ip] = stream
+
+	go hc.stream(ip, stream, changed)
+}
+
+func (hc *healthChecker) Unsubscribe(ip string) {
+	hc.streamMutex.Lock()
+	defer hc.streamMutex.Unlock()
+
+	if stream, found := hc.streams[ip]; found {
+		close(stream.stop)
+		delete(hc.streams, ip)
+	}
+}
+
+func (hc *healthChecker) stream(ip string, stream *healthStream, changed func(api.HealthResult)) {
+	endpoint := fmt.Sprintf("https://%s/health/stream", net.JoinHostPort(ip, fmt.Sprintf("%d", hc.port)))
+
+	wasSupported := true
+	for {
+		supported := hc.readStream(endpoint, ip, stream, changed)
+
+		hc.streamMutex.Lock()
+		stream.connected = false
+		hc.streamMutex.Unlock()
+
+		retryInterval := StreamRetryInterval
+		if !supported {
+			if wasSupported {
+				hc.logger.Info(hc.logTag, "health stream not provided by %s, polling instead", ip)
+			}
+			retryInterval = StreamUnsupportedRetryInterval
+		}
+		wasSupported = supported
+
+		select {
+		case <-stream.stop:
+			return
+		case <-hc.clock.After(retryInterval):
+		}
+	}
+}
+
+// readStream reads results from the health stream until it ends. It returns
+// false when the instance does not provide a health stream.
+func (hc *healthChecker) readStream(endpoint, ip string, stream *healthStream, changed func(api.HealthResult)) bool {
+	response, err := hc.streamClient.Get(endpoint)
+	if err != nil {
+		hc.logger.Debug(hc.logTag, "network error connecting to health stream of %s: %v", ip, err)
+		return true
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode == http.StatusNotFound {
+		return false
+	} else if response.StatusCode != http.StatusOK {
+		hc.logger.Debug(hc.logTag, "http error connecting to health stream of %s: %v", ip, response.StatusCode)
+		return true
+	}
+
+	received := make(chan struct{}, 1)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		timer := hc.clock.NewTimer(StreamTimeout)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-received:
+				timer.Reset(StreamTimeout)
+			case <-timer.C():
+				hc.logger.Debug(hc.logTag, "health stream of %s timed out", ip)
+				response.Body.Close() //nolint:errcheck
+				return
+			case <-stream.stop:
+				response.Body.Close() //nolint:errcheck
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	decoder := json.NewDecoder(response.Body)
+	for {
+		var result api.HealthResult
+		err := decoder.Decode(&result)
+		if err != nil {
+			hc.logger.Debug(hc.logTag, "health stream of %s ended: %v", ip, err)
+			return true
+		}
+
+		select {
+		case <-stream.stop:
+			return true
+		default:
+		}
+
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+
+		hc.streamMutex.Lock()
+		isChange := !stream.connected || !reflect.DeepEqual(stream.result, result)
+		stream.result = result
+		stream.connected = true
+		hc.streamMutex.Unlock()
+
+		if isChange {
+			hc.logger.Debug(hc.logTag, "health stream of %s reports: %+v", ip, result)
+			changed(result)
+		}
+	}
+}
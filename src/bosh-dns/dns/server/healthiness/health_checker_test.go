@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
+	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -119,4 +122,156 @@ var _ = Describe("HealthChecker", func() {
 			})
 		})
 	})
+
+	Describe("Subscribe", func() {
+		var (
+			fakeStreamClient *healthinessfakes.FakeHTTPClientGetter
+			fakeClock        *fakeclock.FakeClock
+			streamWriter     *io.PipeWriter
+			subscriber       healthiness.HealthSubscriber
+
+			resultsMutex sync.Mutex
+			results      []api.HealthResult
+		)
+
+		changed := func(result api.HealthResult) {
+			resultsMutex.Lock()
+			defer resultsMutex.Unlock()
+			results = append(results, result)
+		}
+
+		changes := func() []api.HealthResult {
+			resultsMutex.Lock()
+			defer resultsMutex.Unlock()
+			return append([]api.HealthResult{}, results...)
+		}
+
+		BeforeEach(func() {
+			ip = "127.0.0.1"
+			results = nil
+			fakeStreamClient = &healthinessfakes.FakeHTTPClientGetter{}
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+
+			var streamReader *io.PipeReader
+			streamReader, streamWriter = io.Pipe()
+			fakeStreamClient.GetReturnsOnCall(0, &http.Response{StatusCode: http.StatusOK, Body: streamReader}, nil)
+			fakeStreamClient.GetReturns(nil, errors.New("fake connect err"))
+
+			healthChecker = healthiness.NewStreamingHealthChecker(fakeClient, fakeStreamClient, fakeClock, 8081, fakeLogger)
+			subscriber = healthChecker.(healthiness.HealthSubscriber)
+		})
+
+		AfterEach(func() {
+			subscriber.Unsubscribe(ip)
+		})
+
+		write := func(line string) {
+			_, err := streamWriter.Write([]byte(line + "\n"))
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		It("reports the changes streamed by the instance", func() {
+			subscriber.Subscribe(ip, changed)
+			Eventually(fakeStreamClient.GetCallCount).Should(Equal(1))
+			Expect(fakeStreamClient.GetArgsForCall(0)).To(Equal(fmt.Sprintf("https://%s:8081/health/stream", ip)))
+
+			write(`{"state":"running"}`)
+			write(`{"state":"running"}`)
+			write(`{"state":"failing","group_state":{"1":"failing"}}`)
+
+			Eventually(changes).Should(Equal([]api.HealthResult{
+				{State: api.StatusRunning},
+				{State: api.StatusFailing, GroupState: map[string]api.HealthStatus{"1": api.StatusFailing}},
+			}))
+		})
+
+		It("returns the streamed status without polling while the stream is connected", func() {
+			subscriber.Subscribe(ip, changed)
+			write(`{"state":"failing"}`)
+			Eventually(changes).Should(HaveLen(1))
+
+			Expect(healthChecker.GetStatus(ip).State).To(Equal(api.StatusFailing))
+			Expect(fakeClient.GetCallCount()).To(Equal(0))
+		})
+
+		It("polls and reconnects when the stream ends", func() {
+			subscriber.Subscribe(ip, changed)
+			write(`{"state":"failing"}`)
+			Eventually(changes).Should(HaveLen(1))
+
+			Expect(streamWriter.Close()).To(Succeed())
+			Eventually(func() api.HealthStatus {
+				return healthChecker.GetStatus(ip).State
+			}).Should(Equal(api.StatusRunning))
+			Expect(fakeClient.GetCallCount()).To(BeNumerically(">", 0))
+
+			fakeClock.WaitForWatcherAndIncrement(healthiness.StreamRetryInterval)
+			Eventually(fakeStreamClient.GetCallCount).Should(Equal(2))
+		})
+
+		It("reconnects when the stream stays silent", func() {
+			subscriber.Subscribe(ip, changed)
+			write(`{"state":"failing"}`)
+			Eventually(changes).Should(HaveLen(1))
+
+			fakeClock.WaitForWatcherAndIncrement(healthiness.StreamTimeout)
+			Eventually(func() api.HealthStatus {
+				return healthChecker.GetStatus(ip).State
+			}).Should(Equal(api.StatusRunning))
+
+			fakeClock.WaitForWatcherAndIncrement(healthiness.StreamRetryInterval)
+			Eventually(fakeStreamClient.GetCallCount).Should(Equal(2))
+		})
+
+		It("closes the stream when unsubscribing", func() {
+			subscriber.Subscribe(ip, changed)
+			write(`{"state":"running"}`)
+			Eventually(changes).Should(HaveLen(1))
+
+			subscriber.Unsubscribe(ip)
+			Eventually(func() error {
+				_, err := streamWriter.Write([]byte(`{"state":"failing"}` + "\n"))
+				return err
+			}).Should(MatchError(io.ErrClosedPipe))
+			Expect(changes()).To(HaveLen(1))
+		})
+
+		Context("when the instance does not provide a health stream", func() {
+			BeforeEach(func() {
+				fakeStreamClient.GetReturnsOnCall(0, &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(bytes.NewBufferString(""))}, nil)
+			})
+
+			It("keeps polling without reconnecting", func() {
+				subscriber.Subscribe(ip, changed)
+				Eventually(fakeStreamClient.GetCallCount).Should(Equal(1))
+
+				fakeClock.WaitForWatcherAndIncrement(healthiness.StreamRetryInterval)
+				Consistently(fakeStreamClient.GetCallCount).Should(Equal(1))
+				Expect(healthChecker.GetStatus(ip).State).To(Equal(api.StatusRunning))
+				Expect(fakeClient.GetCallCount()).To(Equal(1))
+			})
+
+			It("connects again after a while in case the instance has been upgraded", func() {
+				var streamReader *io.PipeReader
+				streamReader, streamWriter = io.Pipe()
+				fakeStreamClient.GetReturnsOnCall(1, &http.Response{StatusCode: http.StatusOK, Body: streamReader}, nil)
+
+				subscriber.Subscribe(ip, changed)
+				Eventually(fakeStreamClient.GetCallCount).Should(Equal(1))
+
+				fakeClock.WaitForWatcherAndIncrement(healthiness.StreamUnsupportedRetryInterval)
+				Eventually(fakeStreamClient.GetCallCount).Should(Equal(2))
+
+				write(`{"state":"failing"}`)
+				Eventually(changes).Should(Equal([]api.HealthResult{{State: api.StatusFailing}}))
+			})
+		})
+
+		Context("when the checker does not stream", func() {
+			It("does not connect", func() {
+				healthiness.NewHealthChecker(fakeClient, 8081, fakeLogger).(healthiness.HealthSubscriber).Subscribe(ip, changed)
+				Consistently(fakeStreamClient.GetCallCount).Should(Equal(0))
+			})
+		})
+	})
 })
@@ -0,0 +1,71 @@
+package healthiness
+
+import (
+	"math/rand"
+	"time"
+
+	"bosh-dns/healthcheck/api"
+)
+
+const (
+	// ScheduleJitter is the largest fraction of the interval that is taken off
+	// the delay before the next check of an IP, so that checks of IPs tracked at
+	// the same time spread out instead of firing together.
+	ScheduleJitter = 0.2
+
+	// RecentChecks is the number of stable checks after a change of the health
+	// state during which an IP keeps being checked at half the interval when
+	// back-off is enabled.
+	RecentChecks = 3
+
+	// BackoffChecks is the number of stable checks after which the interval of
+	// a healthy IP doubles, up to the max back-off factor times the
+	// configured interval.
+	BackoffChecks = 10
+)
+
+type checkSchedule struct {
+	lastCheck    time.Time
+	nextCheck    time.Time
+	interval     time.Duration
+	stableChecks int
+}
+
+// next schedules the following check of an IP. Without back-off, that is
+// with a maxBackoffFactor below 2, every IP is checked at the interval. With
+// back-off, IPs that are not running, that recently changed or that wait for
+// a change to be confirmed are checked at half the interval, and IPs that
+// have been running for a long time back off up to maxBackoffFactor times the
+// interval.
+func (s *checkSchedule) next(checkInterval time.Duration, maxBackoffFactor int, now time.Time, changed, pending bool, state api.HealthStatus) {
+	if changed || pending || state != api.StatusRunning {
+		s.stableChecks = 0
+	} else {
+		s.stableChecks++
+	}
+
+	s.interval = checkInterval
+	if maxBackoffFactor > 1 {
+		if state != api.StatusRunning || s.stableChecks < RecentChecks {
+			s.interval = checkInterval / 2
+		} else {
+			factor := 1 << uint((s.stableChecks-RecentChecks)/BackoffChecks)
+			if factor > maxBackoffFactor {
+				factor = maxBackoffFactor
+			}
+			s.interval = checkInterval * time.Duration(factor)
+		}
+	}
+
+	s.lastCheck = now
+	s.nextCheck = now.Add(s.interval - time.Duration(rand.Float64()*ScheduleJitter*float64(s.interval))) //nolint:gosec
+}
+
+func (s *checkSchedule) metadata() api.HealthCheckSchedule {
+	return api.HealthCheckSchedule{
+		LastCheck:    s.lastCheck,
+		NextCheck:    s.nextCheck,
+		Interval:     s.interval,
+		StableChecks: s.stableChecks,
+	}
+}
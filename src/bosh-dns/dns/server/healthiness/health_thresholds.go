@@ -0,0 +1,117 @@
+package healthiness
+
+import "bosh-dns/healthcheck/api"
+
+// HealthHistoryLength is the number of health transitions that are kept for
+// every IP.
+const HealthHistoryLength = 10
+
+// Thresholds are the numbers of consecutive check results needed before the
+// health state of an IP changes. Rise applies to changes to running, Fall to
+// every other change. Values below 1 are treated as 1.
+type Thresholds struct {
+	Rise int
+	Fall int
+}
+
+func (t Thresholds) required(to api.HealthStatus) int {
+	required := t.Fall
+	if to == api.StatusRunning {
+		required = t.Rise
+	}
+
+	if required < 1 {
+		return 1
+	}
+
+	return required
+}
+
+// pendingState counts the consecutive results that differ from the applied
+// state.
+type pendingState struct {
+	state api.HealthStatus
+	count int
+}
+
+func (t Thresholds) advance(applied, observed api.HealthStatus, pending *pendingState) api.HealthStatus {
+	if observed == applied {
+		*pending = pendingState{}
+		return applied
+	}
+
+	if pending.state != observed {
+		*pending = pendingState{state: observed}
+	}
+
+	pending.count++
+	if pending.count < t.required(observed) {
+		return applied
+	}
+
+	*pending = pendingState{}
+	return observed
+}
+
+type ipHealth struct {
+	pending       pendingState
+	pendingGroups map[string]*pendingState
+	transitions   []api.HealthTransition
+	schedule      *checkSchedule
+}
+
+// apply returns the health state after observing a check result. Groups that
+// are not part of the result are dropped, new groups take their observed
+// state right away.
+func (h *ipHealth) apply(thresholds Thresholds, applied, observed api.HealthResult) api.HealthResult {
+	result := api.HealthResult{
+		State: thresholds.advance(applied.State, observed.State, &h.pending),
+	}
+
+	pendingGroups := map[string]*pendingState{}
+	for group, observedState := range observed.GroupState {
+		if result.GroupState == nil {
+			result.GroupState = map[string]api.HealthStatus{}
+		}
+
+		appliedState, found := applied.GroupState[group]
+		if !found {
+			result.GroupState[group] = observedState
+			continue
+		}
+
+		pending := h.pendingGroups[group]
+		if pending == nil {
+			pending = &pendingState{}
+		}
+		pendingGroups[group] = pending
+
+		result.GroupState[group] = thresholds.advance(appliedState, observedState, pending)
+	}
+	h.pendingGroups = pendingGroups
+
+	return result
+}
+
+func (h *ipHealth) record(transition api.HealthTransition) {
+	h.transitions = append(h.transitions, transition)
+	if len(h.transitions) > HealthHistoryLength {
+		h.transitions = h.transitions[len(h.transitions)-HealthHistoryLength:]
+	}
+}
+
+// isPending reports whether a change of the state of the IP or of one of its
+// groups still waits to be confirmed.
+func (h *ipHealth) isPending() bool {
+	if h.pending.count > 0 {
+		return true
+	}
+
+	for _, pending := range h.pendingGroups {
+		if pending.count > 0 {
+			return true
+		}
+	}
+
+	return false
+}
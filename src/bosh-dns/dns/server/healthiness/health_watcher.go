@@ -1,6 +1,7 @@
 package healthiness
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"code.cloudfoundry.org/workpool"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
+	"bosh-dns/dns/server/gossip"
 	"bosh-dns/healthcheck/api"
 )
 
@@ -17,6 +19,24 @@ type HealthChecker interface {
 	GetStatus(ip string) api.HealthResult
 }
 
+//counterfeiter:generate . HealthSubscriber
+
+// HealthSubscriber is implemented by health checkers that can be notified of
+// health changes by the instances themselves.
+type HealthSubscriber interface {
+	Subscribe(ip string, changed func(api.HealthResult))
+	Unsubscribe(ip string)
+}
+
+//counterfeiter:generate . PeerHealth
+
+// PeerHealth shares health results with other bosh-dns instances. Only
+// observations that are fresh enough are returned, see gossip.Node.
+type PeerHealth interface {
+	Observe(ip string, result api.HealthResult)
+	Observation(ip string) (gossip.Observation, bool)
+}
+
 //counterfeiter:generate . HealthWatcher
 
 type HealthWatcher interface {
@@ -26,34 +46,50 @@ type HealthWatcher interface {
 	Untrack(ip string)
 	Run(signal <-chan struct{})
 	RunCheck(ip string) api.HealthResult
+	HealthTransitions(ip string) []api.HealthTransition
+	HealthCheckSchedule(ip string) (api.HealthCheckSchedule, bool)
 }
 
 type healthWatcher struct {
-	checker       HealthChecker
-	checkInterval time.Duration
-	clock         clock.Clock
-	workpoolSize  int
+	checker          HealthChecker
+	checkInterval    time.Duration
+	maxBackoffFactor int
+	thresholds       Thresholds
+	peers            PeerHealth
+	clock            clock.Clock
+	workpoolSize     int
 
 	checkWorkPool *workpool.WorkPool
 	state         map[string]api.HealthResult
+	health        map[string]*ipHealth
 	currentChecks map[string]*sync.Cond
 	stateMutex    *sync.RWMutex
+	rescheduled   chan struct{}
 	logger        boshlog.Logger
 }
 
-func NewHealthWatcher(workpoolSize int, checker HealthChecker, clock clock.Clock, checkInterval time.Duration, logger boshlog.Logger) *healthWatcher {
+// NewHealthWatcher returns a watcher that checks the health of tracked IPs.
+// When peers is not nil, results are shared with them and a scheduled check
+// is skipped when a peer checked the IP more recently. IPs that stay healthy
+// are checked up to maxBackoffFactor times less often than checkInterval.
+func NewHealthWatcher(workpoolSize int, checker HealthChecker, clock clock.Clock, checkInterval time.Duration, maxBackoffFactor int, thresholds Thresholds, peers PeerHealth, logger boshlog.Logger) *healthWatcher {
 	wp, _ := workpool.NewWorkPool(workpoolSize) //nolint:errcheck
 
 	return &healthWatcher{
-		checker:       checker,
-		checkInterval: checkInterval,
-		clock:         clock,
-		workpoolSize:  workpoolSize,
+		checker:          checker,
+		checkInterval:    checkInterval,
+		maxBackoffFactor: maxBackoffFactor,
+		thresholds:       thresholds,
+		peers:            peers,
+		clock:            clock,
+		workpoolSize:     workpoolSize,
 
 		checkWorkPool: wp,
 		state:         map[string]api.HealthResult{},
+		health:        map[string]*ipHealth{},
 		currentChecks: map[string]*sync.Cond{},
 		stateMutex:    &sync.RWMutex{},
+		rescheduled:   make(chan struct{}, 1),
 		logger:        logger,
 	}
 }
@@ -92,9 +128,16 @@ func (hw *healthWatcher) Untrack(ip string) {
 	hw.logger.Debug("healthWatcher", "Untrack IP %s", ip)
 	hw.stateMutex.Lock()
 	delete(hw.state, ip)
+	delete(hw.health, ip)
 	hw.stateMutex.Unlock()
+
+	if subscriber, ok := hw.checker.(HealthSubscriber); ok {
+		subscriber.Unsubscribe(ip)
+	}
 }
 
+// Run checks every tracked IP when its next check is due. The next check of
+// an IP is scheduled after each of its checks, see checkSchedule.
 func (hw *healthWatcher) Run(signal <-chan struct{}) {
 	timer := hw.clock.NewTimer(hw.checkInterval)
 	defer timer.Stop()
@@ -103,14 +146,19 @@ func (hw *healthWatcher) Run(signal <-chan struct{}) {
 		select {
 		case <-timer.C():
 			works := []func(){}
+			now := hw.clock.Now()
 
 			hw.stateMutex.RLock()
-			for ip := range hw.state {
+			for ip, health := range hw.health {
+				if health.schedule == nil || health.schedule.nextCheck.After(now) {
+					continue
+				}
+
 				// closing on ip, we need to ensure it's fixed within this context
 				ip := ip
 
 				works = append(works, func() {
-					hw.RunCheck(ip)
+					hw.scheduledCheck(ip)
 				})
 			}
 			hw.stateMutex.RUnlock()
@@ -118,13 +166,62 @@ func (hw *healthWatcher) Run(signal <-chan struct{}) {
 			throttler, _ := workpool.NewThrottler(hw.workpoolSize, works) //nolint:errcheck
 			throttler.Work()
 
-			timer.Reset(hw.checkInterval)
+			timer.Reset(hw.untilNextCheck())
+		case <-hw.rescheduled:
+			timer.Reset(hw.untilNextCheck())
 		case <-signal:
 			return
 		}
 	}
 }
 
+// scheduledCheck adopts the result of a peer that checked the IP after this
+// watcher did instead of checking it again. A peer can only confirm that an
+// IP is running, an IP that is not running here is only considered running
+// again after checking it. Synchronous checks through RunCheck always check
+// the IP.
+func (hw *healthWatcher) scheduledCheck(ip string) {
+	if hw.peers != nil {
+		if observation, found := hw.peers.Observation(ip); found && observation.Observer != "" {
+			hw.stateMutex.Lock()
+			health, tracked := hw.health[ip]
+			recovers := observation.Result.State == api.StatusRunning && hw.state[ip].State != api.StatusRunning
+			if tracked && !recovers && health.schedule != nil && observation.ObservedAt.After(health.schedule.lastCheck) {
+				hw.logger.Debug("healthWatcher", "Using state for IP <%s> observed by %s", ip, observation.Observer)
+				hw.update(ip, observation.Result)
+				hw.stateMutex.Unlock()
+				return
+			}
+			hw.stateMutex.Unlock()
+		}
+	}
+
+	hw.RunCheck(ip)
+}
+
+func (hw *healthWatcher) untilNextCheck() time.Duration {
+	hw.stateMutex.RLock()
+	defer hw.stateMutex.RUnlock()
+
+	var next time.Time
+	for _, health := range hw.health {
+		if health.schedule != nil && (next.IsZero() || health.schedule.nextCheck.Before(next)) {
+			next = health.schedule.nextCheck
+		}
+	}
+
+	if next.IsZero() {
+		return hw.checkInterval
+	}
+
+	wait := next.Sub(hw.clock.Now())
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
+
 func (hw *healthWatcher) RunCheck(ip string) api.HealthResult {
 	hw.stateMutex.Lock()
 	cond := hw.currentChecks[ip]
@@ -143,19 +240,137 @@ func (hw *healthWatcher) RunCheck(ip string) api.HealthResult {
 	hw.stateMutex.Lock()
 	hw.currentChecks[ip] = nil
 
-	wasHealthy, found := hw.state[ip]
-	hw.state[ip] = healthInfo
+	_, tracked := hw.state[ip]
+	newState := hw.update(ip, healthInfo)
+	cond.Broadcast() // wake other threads waiting on this update
+
+	hw.stateMutex.Unlock()
+
+	if hw.peers != nil {
+		hw.peers.Observe(ip, healthInfo)
+	}
+
+	if subscriber, ok := hw.checker.(HealthSubscriber); ok && !tracked {
+		subscriber.Subscribe(ip, func(result api.HealthResult) {
+			hw.push(ip, result)
+		})
+	}
+
+	return newState
+}
+
+// push applies a result the instance reported itself. Results for IPs that
+// are no longer tracked are dropped.
+func (hw *healthWatcher) push(ip string, healthInfo api.HealthResult) {
+	hw.stateMutex.Lock()
+	_, found := hw.state[ip]
+	if found {
+		hw.update(ip, healthInfo)
+	}
+	hw.stateMutex.Unlock()
+
+	if found && hw.peers != nil {
+		hw.peers.Observe(ip, healthInfo)
+	}
+}
+
+// update applies a check result to the state of the IP. The caller must hold
+// the state lock.
+func (hw *healthWatcher) update(ip string, healthInfo api.HealthResult) api.HealthResult {
+	oldState, found := hw.state[ip]
+	health := hw.health[ip]
+	if health == nil {
+		health = &ipHealth{}
+		hw.health[ip] = health
+	}
 
-	oldState := wasHealthy
 	newState := healthInfo
+	if found {
+		newState = health.apply(hw.thresholds, oldState, healthInfo)
+	} else {
+		oldState = api.HealthResult{State: StateUnchecked}
+	}
+	hw.state[ip] = newState
 
 	if !found {
 		hw.logger.Info("healthWatcher", "Initial state for IP <%s> is %s", ip, newState.State)
 	} else if oldState.State != newState.State {
 		hw.logger.Info("healthWatcher", "State for IP <%s> changed from %s to %s", ip, oldState.State, newState.State)
+	} else if healthInfo.State != newState.State {
+		hw.logger.Debug("healthWatcher", "State for IP <%s> stays %s until %s is confirmed", ip, newState.State, healthInfo.State)
 	}
-	cond.Broadcast() // wake other threads waiting on this update
+	changed := hw.recordTransitions(health, oldState, newState)
+	hw.schedule(health, changed, newState.State)
 
-	hw.stateMutex.Unlock()
 	return newState
 }
+
+// HealthTransitions returns the most recent changes of the health state of
+// the IP and of its groups, oldest first.
+func (hw *healthWatcher) HealthTransitions(ip string) []api.HealthTransition {
+	hw.stateMutex.RLock()
+	defer hw.stateMutex.RUnlock()
+
+	health, found := hw.health[ip]
+	if !found {
+		return []api.HealthTransition{}
+	}
+
+	return append([]api.HealthTransition{}, health.transitions...)
+}
+
+// HealthCheckSchedule returns when the IP was last checked and when it is
+// checked next. It is not found until the first check of the IP finished.
+func (hw *healthWatcher) HealthCheckSchedule(ip string) (api.HealthCheckSchedule, bool) {
+	hw.stateMutex.RLock()
+	defer hw.stateMutex.RUnlock()
+
+	health, found := hw.health[ip]
+	if !found || health.schedule == nil {
+		return api.HealthCheckSchedule{}, false
+	}
+
+	return health.schedule.metadata(), true
+}
+
+func (hw *healthWatcher) schedule(health *ipHealth, changed bool, state api.HealthStatus) {
+	if health.schedule == nil {
+		health.schedule = &checkSchedule{}
+	}
+	health.schedule.next(hw.checkInterval, hw.maxBackoffFactor, hw.clock.Now(), changed, health.isPending(), state)
+
+	select {
+	case hw.rescheduled <- struct{}{}:
+	default:
+	}
+}
+
+func (hw *healthWatcher) recordTransitions(health *ipHealth, oldState, newState api.HealthResult) bool {
+	now := hw.clock.Now()
+	changed := false
+
+	if oldState.State != newState.State {
+		health.record(api.HealthTransition{From: oldState.State, To: newState.State, At: now})
+		changed = true
+	}
+
+	groups := make([]string, 0, len(newState.GroupState))
+	for group := range newState.GroupState {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		from, found := oldState.GroupState[group]
+		if !found {
+			from = StateUnchecked
+		}
+
+		if to := newState.GroupState[group]; from != to {
+			health.record(api.HealthTransition{Group: group, From: from, To: to, At: now})
+			changed = true
+		}
+	}
+
+	return changed
+}
@@ -1,6 +1,7 @@
 package healthiness_test
 
 import (
+	"errors"
 	"sync"
 	"time"
 
@@ -9,11 +10,32 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"bosh-dns/dns/server/gossip"
 	"bosh-dns/dns/server/healthiness"
 	"bosh-dns/dns/server/healthiness/healthinessfakes"
 	"bosh-dns/healthcheck/api"
 )
 
+type subscribingHealthChecker struct {
+	*healthinessfakes.FakeHealthChecker
+	*healthinessfakes.FakeHealthSubscriber
+}
+
+// nodeTransport connects two in-process gossip nodes.
+type nodeTransport struct {
+	from  string
+	nodes map[string]*gossip.Node
+}
+
+func (t nodeTransport) Exchange(ip string, msg gossip.Message) (gossip.Message, error) {
+	node, found := t.nodes[ip]
+	if !found {
+		return gossip.Message{}, errors.New("connection refused")
+	}
+
+	return node.HandleExchange(t.from, msg), nil
+}
+
 var _ = Describe("HealthWatcher", func() {
 	var (
 		fakeChecker *healthinessfakes.FakeHealthChecker
@@ -31,7 +53,7 @@ var _ = Describe("HealthWatcher", func() {
 		fakeClock = fakeclock.NewFakeClock(time.Now())
 		fakeLogger = &loggerfakes.FakeLogger{}
 		interval = time.Second
-		healthWatcher = healthiness.NewHealthWatcher(1, fakeChecker, fakeClock, interval, fakeLogger)
+		healthWatcher = healthiness.NewHealthWatcher(1, fakeChecker, fakeClock, interval, 1, healthiness.Thresholds{}, nil, fakeLogger)
 		signal = make(chan struct{})
 		stopped = sync.WaitGroup{}
 		started := sync.WaitGroup{}
@@ -194,6 +216,462 @@ var _ = Describe("HealthWatcher", func() {
 		})
 	})
 
+	Describe("thresholds", func() {
+		var (
+			ip                  string
+			thresholdsWatcher   healthiness.HealthWatcher
+			thresholds          healthiness.Thresholds
+			initialHealthResult api.HealthResult
+		)
+
+		BeforeEach(func() {
+			ip = "127.0.0.1"
+			thresholds = healthiness.Thresholds{Rise: 2, Fall: 3}
+			initialHealthResult = api.HealthResult{State: api.StatusRunning}
+		})
+
+		JustBeforeEach(func() {
+			thresholdsWatcher = healthiness.NewHealthWatcher(1, fakeChecker, fakeClock, interval, 1, thresholds, nil, fakeLogger)
+
+			fakeChecker.GetStatusReturns(initialHealthResult)
+			Expect(thresholdsWatcher.RunCheck(ip)).To(Equal(initialHealthResult))
+		})
+
+		check := func(result api.HealthResult) api.HealthResult {
+			fakeChecker.GetStatusReturns(result)
+			return thresholdsWatcher.RunCheck(ip)
+		}
+
+		It("changes to failing after fall consecutive results", func() {
+			Expect(check(api.HealthResult{State: api.StatusFailing}).State).To(Equal(api.StatusRunning))
+			Expect(check(api.HealthResult{State: healthiness.StateUnknown}).State).To(Equal(api.StatusRunning))
+			Expect(check(api.HealthResult{State: api.StatusFailing}).State).To(Equal(api.StatusRunning))
+			Expect(check(api.HealthResult{State: api.StatusFailing}).State).To(Equal(api.StatusRunning))
+			Expect(check(api.HealthResult{State: api.StatusFailing}).State).To(Equal(api.StatusFailing))
+			Expect(thresholdsWatcher.HealthState(ip).State).To(Equal(api.StatusFailing))
+		})
+
+		It("keeps the state when a result matches it again", func() {
+			Expect(check(api.HealthResult{State: api.StatusFailing}).State).To(Equal(api.StatusRunning))
+			Expect(check(api.HealthResult{State: api.StatusFailing}).State).To(Equal(api.StatusRunning))
+			Expect(check(api.HealthResult{State: api.StatusRunning}).State).To(Equal(api.StatusRunning))
+			Expect(check(api.HealthResult{State: api.StatusFailing}).State).To(Equal(api.StatusRunning))
+			Expect(check(api.HealthResult{State: api.StatusFailing}).State).To(Equal(api.StatusRunning))
+		})
+
+		Context("when the instance is failing", func() {
+			BeforeEach(func() {
+				initialHealthResult = api.HealthResult{State: api.StatusFailing}
+			})
+
+			It("changes to running after rise consecutive results", func() {
+				Expect(check(api.HealthResult{State: api.StatusRunning}).State).To(Equal(api.StatusFailing))
+				Expect(check(api.HealthResult{State: api.StatusRunning}).State).To(Equal(api.StatusRunning))
+			})
+		})
+
+		Context("when the thresholds are not set", func() {
+			BeforeEach(func() {
+				thresholds = healthiness.Thresholds{}
+			})
+
+			It("changes the state with every result", func() {
+				Expect(check(api.HealthResult{State: healthiness.StateUnknown}).State).To(Equal(healthiness.StateUnknown))
+				Expect(check(api.HealthResult{State: api.StatusRunning}).State).To(Equal(api.StatusRunning))
+			})
+		})
+
+		Context("with group states", func() {
+			BeforeEach(func() {
+				thresholds = healthiness.Thresholds{Rise: 1, Fall: 2}
+				initialHealthResult = api.HealthResult{
+					State:      api.StatusRunning,
+					GroupState: map[string]api.HealthStatus{"1": api.StatusRunning},
+				}
+			})
+
+			It("applies the thresholds to every group", func() {
+				Expect(check(api.HealthResult{
+					State:      api.StatusRunning,
+					GroupState: map[string]api.HealthStatus{"1": api.StatusFailing, "2": api.StatusFailing},
+				})).To(Equal(api.HealthResult{
+					State:      api.StatusRunning,
+					GroupState: map[string]api.HealthStatus{"1": api.StatusRunning, "2": api.StatusFailing},
+				}))
+
+				Expect(check(api.HealthResult{
+					State:      api.StatusRunning,
+					GroupState: map[string]api.HealthStatus{"1": api.StatusFailing, "2": api.StatusRunning},
+				})).To(Equal(api.HealthResult{
+					State:      api.StatusRunning,
+					GroupState: map[string]api.HealthStatus{"1": api.StatusFailing, "2": api.StatusRunning},
+				}))
+			})
+
+			It("drops groups that are not part of the result", func() {
+				Expect(check(api.HealthResult{State: api.StatusRunning})).To(Equal(api.HealthResult{State: api.StatusRunning}))
+			})
+		})
+
+		Describe("HealthTransitions", func() {
+			BeforeEach(func() {
+				thresholds = healthiness.Thresholds{Rise: 1, Fall: 1}
+				initialHealthResult = api.HealthResult{
+					State:      api.StatusRunning,
+					GroupState: map[string]api.HealthStatus{"1": api.StatusRunning},
+				}
+			})
+
+			It("returns the transitions of the state and of the group states", func() {
+				start := fakeClock.Now()
+				fakeClock.Increment(time.Minute)
+				check(api.HealthResult{
+					State:      api.StatusFailing,
+					GroupState: map[string]api.HealthStatus{"1": api.StatusFailing},
+				})
+
+				Expect(thresholdsWatcher.HealthTransitions(ip)).To(Equal([]api.HealthTransition{
+					{From: healthiness.StateUnchecked, To: api.StatusRunning, At: start},
+					{Group: "1", From: healthiness.StateUnchecked, To: api.StatusRunning, At: start},
+					{From: api.StatusRunning, To: api.StatusFailing, At: start.Add(time.Minute)},
+					{Group: "1", From: api.StatusRunning, To: api.StatusFailing, At: start.Add(time.Minute)},
+				}))
+			})
+
+			It("keeps the most recent transitions", func() {
+				for i := 0; i < healthiness.HealthHistoryLength; i++ {
+					check(api.HealthResult{State: api.StatusFailing})
+					check(api.HealthResult{State: api.StatusRunning})
+				}
+
+				transitions := thresholdsWatcher.HealthTransitions(ip)
+				Expect(transitions).To(HaveLen(healthiness.HealthHistoryLength))
+				Expect(transitions[len(transitions)-1]).To(Equal(api.HealthTransition{From: api.StatusFailing, To: api.StatusRunning, At: fakeClock.Now()}))
+			})
+
+			It("returns no transitions for IPs that are not tracked", func() {
+				Expect(thresholdsWatcher.HealthTransitions("127.0.0.9")).To(BeEmpty())
+
+				thresholdsWatcher.Untrack(ip)
+				Expect(thresholdsWatcher.HealthTransitions(ip)).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("HealthCheckSchedule", func() {
+		var (
+			ip              string
+			scheduleWatcher healthiness.HealthWatcher
+		)
+
+		BeforeEach(func() {
+			ip = "127.0.0.1"
+			scheduleWatcher = healthiness.NewHealthWatcher(1, fakeChecker, fakeClock, interval, 1, healthiness.Thresholds{}, nil, fakeLogger)
+		})
+
+		check := func(state api.HealthStatus) api.HealthCheckSchedule {
+			fakeChecker.GetStatusReturns(api.HealthResult{State: state})
+			scheduleWatcher.RunCheck(ip)
+
+			schedule, found := scheduleWatcher.HealthCheckSchedule(ip)
+			Expect(found).To(BeTrue())
+			return schedule
+		}
+
+		expectNextCheck := func(schedule api.HealthCheckSchedule, interval time.Duration) {
+			Expect(schedule.Interval).To(Equal(interval))
+			Expect(schedule.LastCheck).To(Equal(fakeClock.Now()))
+			Expect(schedule.NextCheck).To(BeTemporally("<=", fakeClock.Now().Add(interval)))
+			Expect(schedule.NextCheck).To(BeTemporally(">=", fakeClock.Now().Add(interval-time.Duration(healthiness.ScheduleJitter*float64(interval)))))
+		}
+
+		It("is not found before the first check", func() {
+			_, found := scheduleWatcher.HealthCheckSchedule(ip)
+			Expect(found).To(BeFalse())
+		})
+
+		It("checks every IP at the interval by default", func() {
+			expectNextCheck(check(api.StatusRunning), interval)
+			expectNextCheck(check(api.StatusFailing), interval)
+
+			for i := 0; i < healthiness.RecentChecks+5*healthiness.BackoffChecks; i++ {
+				check(api.StatusRunning)
+			}
+			expectNextCheck(check(api.StatusRunning), interval)
+		})
+
+		Context("when a max back-off factor is configured", func() {
+			BeforeEach(func() {
+				scheduleWatcher = healthiness.NewHealthWatcher(1, fakeChecker, fakeClock, interval, 4, healthiness.Thresholds{}, nil, fakeLogger)
+			})
+
+			It("checks recently changed IPs at half the interval", func() {
+				expectNextCheck(check(api.StatusRunning), interval/2)
+
+				for i := 1; i < healthiness.RecentChecks; i++ {
+					expectNextCheck(check(api.StatusRunning), interval/2)
+				}
+
+				schedule := check(api.StatusRunning)
+				expectNextCheck(schedule, interval)
+				Expect(schedule.StableChecks).To(Equal(healthiness.RecentChecks))
+			})
+
+			It("checks failing IPs at half the interval", func() {
+				for i := 0; i < healthiness.RecentChecks+healthiness.BackoffChecks; i++ {
+					expectNextCheck(check(api.StatusFailing), interval/2)
+				}
+			})
+
+			It("backs off for IPs that have been running for a long time", func() {
+				for i := 0; i < healthiness.RecentChecks+healthiness.BackoffChecks; i++ {
+					check(api.StatusRunning)
+				}
+				expectNextCheck(check(api.StatusRunning), 2*interval)
+
+				for i := 0; i < 5*healthiness.BackoffChecks; i++ {
+					check(api.StatusRunning)
+				}
+				expectNextCheck(check(api.StatusRunning), 4*interval)
+
+				schedule := check(api.StatusFailing)
+				expectNextCheck(schedule, interval/2)
+				Expect(schedule.StableChecks).To(Equal(0))
+			})
+
+			Context("when a change waits to be confirmed", func() {
+				BeforeEach(func() {
+					scheduleWatcher = healthiness.NewHealthWatcher(1, fakeChecker, fakeClock, interval, 4, healthiness.Thresholds{Fall: 2}, nil, fakeLogger)
+				})
+
+				It("checks the IP at half the interval", func() {
+					for i := 0; i < healthiness.RecentChecks; i++ {
+						check(api.StatusRunning)
+					}
+					expectNextCheck(check(api.StatusRunning), interval)
+
+					fakeChecker.GetStatusReturns(api.HealthResult{State: api.StatusFailing})
+					Expect(scheduleWatcher.RunCheck(ip).State).To(Equal(api.StatusRunning))
+					schedule, _ := scheduleWatcher.HealthCheckSchedule(ip)
+					expectNextCheck(schedule, interval/2)
+				})
+			})
+		})
+
+		Context("when the watcher is running", func() {
+			BeforeEach(func() {
+				ip = "127.0.0.3"
+				fakeChecker.GetStatusReturns(api.HealthResult{State: api.StatusRunning})
+				healthWatcher.Track(ip)
+				Eventually(fakeChecker.GetStatusCallCount).Should(Equal(1))
+				Eventually(func() bool {
+					_, found := healthWatcher.HealthCheckSchedule(ip)
+					return found
+				}).Should(BeTrue())
+			})
+
+			It("checks every IP when its next check is due", func() {
+				schedule, _ := healthWatcher.HealthCheckSchedule(ip)
+
+				fakeClock.WaitForWatcherAndIncrement(schedule.NextCheck.Sub(fakeClock.Now()))
+				Eventually(fakeChecker.GetStatusCallCount).Should(Equal(2))
+				Eventually(func() time.Time {
+					schedule, _ := healthWatcher.HealthCheckSchedule(ip)
+					return schedule.LastCheck
+				}).Should(Equal(fakeClock.Now()))
+
+				fakeClock.Increment(time.Millisecond)
+				Consistently(fakeChecker.GetStatusCallCount).Should(Equal(2))
+			})
+		})
+	})
+
+	Describe("health subscriptions", func() {
+		var (
+			ip             string
+			fakeSubscriber *healthinessfakes.FakeHealthSubscriber
+			pushWatcher    healthiness.HealthWatcher
+		)
+
+		BeforeEach(func() {
+			ip = "127.0.0.1"
+			fakeSubscriber = &healthinessfakes.FakeHealthSubscriber{}
+			fakeChecker.GetStatusReturns(api.HealthResult{State: api.StatusRunning})
+			checker := subscribingHealthChecker{FakeHealthChecker: fakeChecker, FakeHealthSubscriber: fakeSubscriber}
+			pushWatcher = healthiness.NewHealthWatcher(1, checker, fakeClock, interval, 1, healthiness.Thresholds{}, nil, fakeLogger)
+
+			pushWatcher.RunCheck(ip)
+		})
+
+		push := func(result api.HealthResult) {
+			_, changed := fakeSubscriber.SubscribeArgsForCall(0)
+			changed(result)
+		}
+
+		It("subscribes once to the health of the IP", func() {
+			pushWatcher.RunCheck(ip)
+			Expect(fakeSubscriber.SubscribeCallCount()).To(Equal(1))
+			subscribedIP, _ := fakeSubscriber.SubscribeArgsForCall(0)
+			Expect(subscribedIP).To(Equal(ip))
+		})
+
+		It("applies the results reported by the instance", func() {
+			push(api.HealthResult{State: api.StatusFailing})
+			Expect(pushWatcher.HealthState(ip).State).To(Equal(api.StatusFailing))
+			Expect(fakeChecker.GetStatusCallCount()).To(Equal(1))
+
+			transitions := pushWatcher.HealthTransitions(ip)
+			Expect(transitions[len(transitions)-1]).To(Equal(api.HealthTransition{From: api.StatusRunning, To: api.StatusFailing, At: fakeClock.Now()}))
+		})
+
+		It("unsubscribes and ignores results once the IP is untracked", func() {
+			pushWatcher.Untrack(ip)
+			Expect(fakeSubscriber.UnsubscribeCallCount()).To(Equal(1))
+			Expect(fakeSubscriber.UnsubscribeArgsForCall(0)).To(Equal(ip))
+
+			push(api.HealthResult{State: api.StatusFailing})
+			Expect(pushWatcher.HealthState(ip).State).To(Equal(healthiness.StateUnchecked))
+		})
+	})
+
+	Describe("peer health", func() {
+		var (
+			ip          string
+			fakePeers   *healthinessfakes.FakePeerHealth
+			peers       healthiness.PeerHealth
+			peerWatcher healthiness.HealthWatcher
+			peerSignal  chan struct{}
+			peerStopped sync.WaitGroup
+		)
+
+		BeforeEach(func() {
+			ip = "127.0.0.1"
+			fakePeers = &healthinessfakes.FakePeerHealth{}
+			peers = fakePeers
+			fakeChecker.GetStatusReturns(api.HealthResult{State: api.StatusRunning})
+		})
+
+		JustBeforeEach(func() {
+			peerWatcher = healthiness.NewHealthWatcher(1, fakeChecker, fakeClock, interval, 1, healthiness.Thresholds{}, peers, fakeLogger)
+			peerSignal = make(chan struct{})
+			peerStopped = sync.WaitGroup{}
+			peerStopped.Add(1)
+
+			go func() {
+				defer peerStopped.Done()
+				peerWatcher.Run(peerSignal)
+			}()
+
+			Expect(peerWatcher.RunCheck(ip).State).To(Equal(api.StatusRunning))
+		})
+
+		AfterEach(func() {
+			close(peerSignal)
+			peerStopped.Wait()
+		})
+
+		nextCheck := func() {
+			schedule, _ := peerWatcher.HealthCheckSchedule(ip)
+			fakeClock.WaitForWatcherAndIncrement(schedule.NextCheck.Sub(fakeClock.Now()))
+		}
+
+		It("shares the results of its checks", func() {
+			Expect(fakePeers.ObserveCallCount()).To(Equal(1))
+			observedIP, result := fakePeers.ObserveArgsForCall(0)
+			Expect(observedIP).To(Equal(ip))
+			Expect(result).To(Equal(api.HealthResult{State: api.StatusRunning}))
+		})
+
+		It("uses a result a peer observed after the last check instead of checking", func() {
+			fakeClock.Increment(time.Millisecond)
+			fakePeers.ObservationReturns(gossip.Observation{IP: ip, Observer: "10.0.0.9", Result: api.HealthResult{State: api.StatusFailing}, ObservedAt: fakeClock.Now()}, true)
+
+			nextCheck()
+			Eventually(func() api.HealthStatus { return peerWatcher.HealthState(ip).State }).Should(Equal(api.StatusFailing))
+			Expect(fakeChecker.GetStatusCallCount()).To(Equal(1))
+			Expect(fakePeers.ObservationArgsForCall(0)).To(Equal(ip))
+		})
+
+		It("checks the IP when the peer observation is older than the last check", func() {
+			fakePeers.ObservationReturns(gossip.Observation{IP: ip, Observer: "10.0.0.9", Result: api.HealthResult{State: api.StatusFailing}, ObservedAt: fakeClock.Now().Add(-time.Second)}, true)
+
+			nextCheck()
+			Eventually(fakeChecker.GetStatusCallCount).Should(Equal(2))
+			Expect(peerWatcher.HealthState(ip).State).To(Equal(api.StatusRunning))
+		})
+
+		It("checks the IP when the observation is its own", func() {
+			fakeClock.Increment(time.Millisecond)
+			fakePeers.ObservationReturns(gossip.Observation{IP: ip, Result: api.HealthResult{State: api.StatusFailing}, ObservedAt: fakeClock.Now()}, true)
+
+			nextCheck()
+			Eventually(fakeChecker.GetStatusCallCount).Should(Equal(2))
+		})
+
+		It("checks the IP instead of using a result of a peer that reports it running again", func() {
+			fakeClock.Increment(time.Millisecond)
+			fakePeers.ObservationReturns(gossip.Observation{IP: ip, Observer: "10.0.0.9", Result: api.HealthResult{State: api.StatusFailing}, ObservedAt: fakeClock.Now()}, true)
+
+			nextCheck()
+			Eventually(func() api.HealthStatus { return peerWatcher.HealthState(ip).State }).Should(Equal(api.StatusFailing))
+
+			fakeChecker.GetStatusReturns(api.HealthResult{State: api.StatusFailing})
+			fakeClock.Increment(time.Millisecond)
+			fakePeers.ObservationReturns(gossip.Observation{IP: ip, Observer: "10.0.0.9", Result: api.HealthResult{State: api.StatusRunning}, ObservedAt: fakeClock.Now()}, true)
+
+			nextCheck()
+			Eventually(fakeChecker.GetStatusCallCount).Should(Equal(2))
+			Expect(peerWatcher.HealthState(ip).State).To(Equal(api.StatusFailing))
+		})
+
+		It("always checks the IP for synchronous checks", func() {
+			fakeClock.Increment(time.Millisecond)
+			fakePeers.ObservationReturns(gossip.Observation{IP: ip, Observer: "10.0.0.9", Result: api.HealthResult{State: api.StatusFailing}, ObservedAt: fakeClock.Now()}, true)
+
+			Expect(peerWatcher.RunCheck(ip).State).To(Equal(api.StatusRunning))
+			Expect(fakeChecker.GetStatusCallCount()).To(Equal(2))
+			Expect(fakePeers.ObservationCallCount()).To(Equal(0))
+		})
+
+		Context("with in-process gossip nodes", func() {
+			var (
+				node         *gossip.Node
+				otherChecker *healthinessfakes.FakeHealthChecker
+				otherWatcher healthiness.HealthWatcher
+			)
+
+			BeforeEach(func() {
+				nodes := map[string]*gossip.Node{}
+				config := gossip.Config{Interval: time.Millisecond, MaxAge: time.Minute}
+
+				config.LocalIPs = []string{"10.0.0.1"}
+				node = gossip.NewNode(config, nodeTransport{from: "10.0.0.1", nodes: nodes}, fakeClock, fakeLogger)
+				config.LocalIPs = []string{"10.0.0.2"}
+				otherNode := gossip.NewNode(config, nodeTransport{from: "10.0.0.2", nodes: nodes}, fakeClock, fakeLogger)
+				nodes["10.0.0.1"] = node
+				nodes["10.0.0.2"] = otherNode
+				node.Join("10.0.0.2")
+
+				otherChecker = &healthinessfakes.FakeHealthChecker{}
+				otherChecker.GetStatusReturns(api.HealthResult{State: api.StatusFailing})
+				otherWatcher = healthiness.NewHealthWatcher(1, otherChecker, fakeClock, interval, 1, healthiness.Thresholds{}, otherNode, fakeLogger)
+
+				peers = node
+			})
+
+			It("uses the results shared by the other node", func() {
+				fakeClock.Increment(2 * time.Millisecond)
+				Expect(otherWatcher.RunCheck(ip).State).To(Equal(api.StatusFailing))
+				node.Round()
+
+				nextCheck()
+				Eventually(func() api.HealthStatus { return peerWatcher.HealthState(ip).State }).Should(Equal(api.StatusFailing))
+				Expect(fakeChecker.GetStatusCallCount()).To(Equal(1))
+				Expect(otherChecker.GetStatusCallCount()).To(Equal(1))
+			})
+		})
+	})
+
 	Describe("Untrack", func() {
 		var ip string
 
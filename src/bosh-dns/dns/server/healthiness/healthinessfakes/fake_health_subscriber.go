@@ -0,0 +1,113 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package healthinessfakes
+
+import (
+	"bosh-dns/dns/server/healthiness"
+	"bosh-dns/healthcheck/api"
+	"sync"
+)
+
+type FakeHealthSubscriber struct {
+	SubscribeStub        func(string, func(api.HealthResult))
+	subscribeMutex       sync.RWMutex
+	subscribeArgsForCall []struct {
+		arg1 string
+		arg2 func(api.HealthResult)
+	}
+	UnsubscribeStub        func(string)
+	unsubscribeMutex       sync.RWMutex
+	unsubscribeArgsForCall []struct {
+		arg1 string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeHealthSubscriber) Subscribe(arg1 string, arg2 func(api.HealthResult)) {
+	fake.subscribeMutex.Lock()
+	fake.subscribeArgsForCall = append(fake.subscribeArgsForCall, struct {
+		arg1 string
+		arg2 func(api.HealthResult)
+	}{arg1, arg2})
+	stub := fake.SubscribeStub
+	fake.recordInvocation("Subscribe", []interface{}{arg1, arg2})
+	fake.subscribeMutex.Unlock()
+	if stub != nil {
+		fake.SubscribeStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeHealthSubscriber) SubscribeCallCount() int {
+	fake.subscribeMutex.RLock()
+	defer fake.subscribeMutex.RUnlock()
+	return len(fake.subscribeArgsForCall)
+}
+
+func (fake *FakeHealthSubscriber) SubscribeCalls(stub func(string, func(api.HealthResult))) {
+	fake.subscribeMutex.Lock()
+	defer fake.subscribeMutex.Unlock()
+	fake.SubscribeStub = stub
+}
+
+func (fake *FakeHealthSubscriber) SubscribeArgsForCall(i int) (string, func(api.HealthResult)) {
+	fake.subscribeMutex.RLock()
+	defer fake.subscribeMutex.RUnlock()
+	argsForCall := fake.subscribeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeHealthSubscriber) Unsubscribe(arg1 string) {
+	fake.unsubscribeMutex.Lock()
+	fake.unsubscribeArgsForCall = append(fake.unsubscribeArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.UnsubscribeStub
+	fake.recordInvocation("Unsubscribe", []interface{}{arg1})
+	fake.unsubscribeMutex.Unlock()
+	if stub != nil {
+		fake.UnsubscribeStub(arg1)
+	}
+}
+
+func (fake *FakeHealthSubscriber) UnsubscribeCallCount() int {
+	fake.unsubscribeMutex.RLock()
+	defer fake.unsubscribeMutex.RUnlock()
+	return len(fake.unsubscribeArgsForCall)
+}
+
+func (fake *FakeHealthSubscriber) UnsubscribeCalls(stub func(string)) {
+	fake.unsubscribeMutex.Lock()
+	defer fake.unsubscribeMutex.Unlock()
+	fake.UnsubscribeStub = stub
+}
+
+func (fake *FakeHealthSubscriber) UnsubscribeArgsForCall(i int) string {
+	fake.unsubscribeMutex.RLock()
+	defer fake.unsubscribeMutex.RUnlock()
+	argsForCall := fake.unsubscribeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHealthSubscriber) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeHealthSubscriber) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ healthiness.HealthSubscriber = new(FakeHealthSubscriber)
@@ -8,6 +8,19 @@ import (
 )
 
 type FakeHealthWatcher struct {
+	HealthCheckScheduleStub        func(string) (api.HealthCheckSchedule, bool)
+	healthCheckScheduleMutex       sync.RWMutex
+	healthCheckScheduleArgsForCall []struct {
+		arg1 string
+	}
+	healthCheckScheduleReturns struct {
+		result1 api.HealthCheckSchedule
+		result2 bool
+	}
+	healthCheckScheduleReturnsOnCall map[int]struct {
+		result1 api.HealthCheckSchedule
+		result2 bool
+	}
 	HealthStateStub        func(string) api.HealthResult
 	healthStateMutex       sync.RWMutex
 	healthStateArgsForCall []struct {
@@ -30,6 +43,17 @@ type FakeHealthWatcher struct {
 	healthStateStringReturnsOnCall map[int]struct {
 		result1 string
 	}
+	HealthTransitionsStub        func(string) []api.HealthTransition
+	healthTransitionsMutex       sync.RWMutex
+	healthTransitionsArgsForCall []struct {
+		arg1 string
+	}
+	healthTransitionsReturns struct {
+		result1 []api.HealthTransition
+	}
+	healthTransitionsReturnsOnCall map[int]struct {
+		result1 []api.HealthTransition
+	}
 	RunStub        func(<-chan struct{})
 	runMutex       sync.RWMutex
 	runArgsForCall []struct {
@@ -60,6 +84,70 @@ type FakeHealthWatcher struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeHealthWatcher) HealthCheckSchedule(arg1 string) (api.HealthCheckSchedule, bool) {
+	fake.healthCheckScheduleMutex.Lock()
+	ret, specificReturn := fake.healthCheckScheduleReturnsOnCall[len(fake.healthCheckScheduleArgsForCall)]
+	fake.healthCheckScheduleArgsForCall = append(fake.healthCheckScheduleArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.HealthCheckScheduleStub
+	fakeReturns := fake.healthCheckScheduleReturns
+	fake.recordInvocation("HealthCheckSchedule", []interface{}{arg1})
+	fake.healthCheckScheduleMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHealthWatcher) HealthCheckScheduleCallCount() int {
+	fake.healthCheckScheduleMutex.RLock()
+	defer fake.healthCheckScheduleMutex.RUnlock()
+	return len(fake.healthCheckScheduleArgsForCall)
+}
+
+func (fake *FakeHealthWatcher) HealthCheckScheduleCalls(stub func(string) (api.HealthCheckSchedule, bool)) {
+	fake.healthCheckScheduleMutex.Lock()
+	defer fake.healthCheckScheduleMutex.Unlock()
+	fake.HealthCheckScheduleStub = stub
+}
+
+func (fake *FakeHealthWatcher) HealthCheckScheduleArgsForCall(i int) string {
+	fake.healthCheckScheduleMutex.RLock()
+	defer fake.healthCheckScheduleMutex.RUnlock()
+	argsForCall := fake.healthCheckScheduleArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHealthWatcher) HealthCheckScheduleReturns(result1 api.HealthCheckSchedule, result2 bool) {
+	fake.healthCheckScheduleMutex.Lock()
+	defer fake.healthCheckScheduleMutex.Unlock()
+	fake.HealthCheckScheduleStub = nil
+	fake.healthCheckScheduleReturns = struct {
+		result1 api.HealthCheckSchedule
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeHealthWatcher) HealthCheckScheduleReturnsOnCall(i int, result1 api.HealthCheckSchedule, result2 bool) {
+	fake.healthCheckScheduleMutex.Lock()
+	defer fake.healthCheckScheduleMutex.Unlock()
+	fake.HealthCheckScheduleStub = nil
+	if fake.healthCheckScheduleReturnsOnCall == nil {
+		fake.healthCheckScheduleReturnsOnCall = make(map[int]struct {
+			result1 api.HealthCheckSchedule
+			result2 bool
+		})
+	}
+	fake.healthCheckScheduleReturnsOnCall[i] = struct {
+		result1 api.HealthCheckSchedule
+		result2 bool
+	}{result1, result2}
+}
+
 func (fake *FakeHealthWatcher) HealthState(arg1 string) api.HealthResult {
 	fake.healthStateMutex.Lock()
 	ret, specificReturn := fake.healthStateReturnsOnCall[len(fake.healthStateArgsForCall)]
@@ -182,6 +270,67 @@ func (fake *FakeHealthWatcher) HealthStateStringReturnsOnCall(i int, result1 str
 	}{result1}
 }
 
+func (fake *FakeHealthWatcher) HealthTransitions(arg1 string) []api.HealthTransition {
+	fake.healthTransitionsMutex.Lock()
+	ret, specificReturn := fake.healthTransitionsReturnsOnCall[len(fake.healthTransitionsArgsForCall)]
+	fake.healthTransitionsArgsForCall = append(fake.healthTransitionsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.HealthTransitionsStub
+	fakeReturns := fake.healthTransitionsReturns
+	fake.recordInvocation("HealthTransitions", []interface{}{arg1})
+	fake.healthTransitionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHealthWatcher) HealthTransitionsCallCount() int {
+	fake.healthTransitionsMutex.RLock()
+	defer fake.healthTransitionsMutex.RUnlock()
+	return len(fake.healthTransitionsArgsForCall)
+}
+
+func (fake *FakeHealthWatcher) HealthTransitionsCalls(stub func(string) []api.HealthTransition) {
+	fake.healthTransitionsMutex.Lock()
+	defer fake.healthTransitionsMutex.Unlock()
+	fake.HealthTransitionsStub = stub
+}
+
+func (fake *FakeHealthWatcher) HealthTransitionsArgsForCall(i int) string {
+	fake.healthTransitionsMutex.RLock()
+	defer fake.healthTransitionsMutex.RUnlock()
+	argsForCall := fake.healthTransitionsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHealthWatcher) HealthTransitionsReturns(result1 []api.HealthTransition) {
+	fake.healthTransitionsMutex.Lock()
+	defer fake.healthTransitionsMutex.Unlock()
+	fake.HealthTransitionsStub = nil
+	fake.healthTransitionsReturns = struct {
+		result1 []api.HealthTransition
+	}{result1}
+}
+
+func (fake *FakeHealthWatcher) HealthTransitionsReturnsOnCall(i int, result1 []api.HealthTransition) {
+	fake.healthTransitionsMutex.Lock()
+	defer fake.healthTransitionsMutex.Unlock()
+	fake.HealthTransitionsStub = nil
+	if fake.healthTransitionsReturnsOnCall == nil {
+		fake.healthTransitionsReturnsOnCall = make(map[int]struct {
+			result1 []api.HealthTransition
+		})
+	}
+	fake.healthTransitionsReturnsOnCall[i] = struct {
+		result1 []api.HealthTransition
+	}{result1}
+}
+
 func (fake *FakeHealthWatcher) Run(arg1 <-chan struct{}) {
 	fake.runMutex.Lock()
 	fake.runArgsForCall = append(fake.runArgsForCall, struct {
@@ -342,18 +491,6 @@ func (fake *FakeHealthWatcher) UntrackArgsForCall(i int) string {
 func (fake *FakeHealthWatcher) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.healthStateMutex.RLock()
-	defer fake.healthStateMutex.RUnlock()
-	fake.healthStateStringMutex.RLock()
-	defer fake.healthStateStringMutex.RUnlock()
-	fake.runMutex.RLock()
-	defer fake.runMutex.RUnlock()
-	fake.runCheckMutex.RLock()
-	defer fake.runCheckMutex.RUnlock()
-	fake.trackMutex.RLock()
-	defer fake.trackMutex.RUnlock()
-	fake.untrackMutex.RLock()
-	defer fake.untrackMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
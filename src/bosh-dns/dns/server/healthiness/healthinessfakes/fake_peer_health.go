@@ -0,0 +1,154 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package healthinessfakes
+
+import (
+	"bosh-dns/dns/server/gossip"
+	"bosh-dns/dns/server/healthiness"
+	"bosh-dns/healthcheck/api"
+	"sync"
+)
+
+type FakePeerHealth struct {
+	ObservationStub        func(string) (gossip.Observation, bool)
+	observationMutex       sync.RWMutex
+	observationArgsForCall []struct {
+		arg1 string
+	}
+	observationReturns struct {
+		result1 gossip.Observation
+		result2 bool
+	}
+	observationReturnsOnCall map[int]struct {
+		result1 gossip.Observation
+		result2 bool
+	}
+	ObserveStub        func(string, api.HealthResult)
+	observeMutex       sync.RWMutex
+	observeArgsForCall []struct {
+		arg1 string
+		arg2 api.HealthResult
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakePeerHealth) Observation(arg1 string) (gossip.Observation, bool) {
+	fake.observationMutex.Lock()
+	ret, specificReturn := fake.observationReturnsOnCall[len(fake.observationArgsForCall)]
+	fake.observationArgsForCall = append(fake.observationArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ObservationStub
+	fakeReturns := fake.observationReturns
+	fake.recordInvocation("Observation", []interface{}{arg1})
+	fake.observationMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakePeerHealth) ObservationCallCount() int {
+	fake.observationMutex.RLock()
+	defer fake.observationMutex.RUnlock()
+	return len(fake.observationArgsForCall)
+}
+
+func (fake *FakePeerHealth) ObservationCalls(stub func(string) (gossip.Observation, bool)) {
+	fake.observationMutex.Lock()
+	defer fake.observationMutex.Unlock()
+	fake.ObservationStub = stub
+}
+
+func (fake *FakePeerHealth) ObservationArgsForCall(i int) string {
+	fake.observationMutex.RLock()
+	defer fake.observationMutex.RUnlock()
+	argsForCall := fake.observationArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePeerHealth) ObservationReturns(result1 gossip.Observation, result2 bool) {
+	fake.observationMutex.Lock()
+	defer fake.observationMutex.Unlock()
+	fake.ObservationStub = nil
+	fake.observationReturns = struct {
+		result1 gossip.Observation
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakePeerHealth) ObservationReturnsOnCall(i int, result1 gossip.Observation, result2 bool) {
+	fake.observationMutex.Lock()
+	defer fake.observationMutex.Unlock()
+	fake.ObservationStub = nil
+	if fake.observationReturnsOnCall == nil {
+		fake.observationReturnsOnCall = make(map[int]struct {
+			result1 gossip.Observation
+			result2 bool
+		})
+	}
+	fake.observationReturnsOnCall[i] = struct {
+		result1 gossip.Observation
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakePeerHealth) Observe(arg1 string, arg2 api.HealthResult) {
+	fake.observeMutex.Lock()
+	fake.observeArgsForCall = append(fake.observeArgsForCall, struct {
+		arg1 string
+		arg2 api.HealthResult
+	}{arg1, arg2})
+	stub := fake.ObserveStub
+	fake.recordInvocation("Observe", []interface{}{arg1, arg2})
+	fake.observeMutex.Unlock()
+	if stub != nil {
+		fake.ObserveStub(arg1, arg2)
+	}
+}
+
+func (fake *FakePeerHealth) ObserveCallCount() int {
+	fake.observeMutex.RLock()
+	defer fake.observeMutex.RUnlock()
+	return len(fake.observeArgsForCall)
+}
+
+func (fake *FakePeerHealth) ObserveCalls(stub func(string, api.HealthResult)) {
+	fake.observeMutex.Lock()
+	defer fake.observeMutex.Unlock()
+	fake.ObserveStub = stub
+}
+
+func (fake *FakePeerHealth) ObserveArgsForCall(i int) (string, api.HealthResult) {
+	fake.observeMutex.RLock()
+	defer fake.observeMutex.RUnlock()
+	argsForCall := fake.observeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakePeerHealth) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakePeerHealth) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ healthiness.PeerHealth = new(FakePeerHealth)
@@ -28,3 +28,11 @@ func (hw *nopHealthWatcher) Run(signal <-chan struct{}) {
 func (hw *nopHealthWatcher) RunCheck(ip string) api.HealthResult {
 	return api.HealthResult{State: api.StatusRunning}
 }
+
+func (hw *nopHealthWatcher) HealthTransitions(ip string) []api.HealthTransition {
+	return []api.HealthTransition{}
+}
+
+func (hw *nopHealthWatcher) HealthCheckSchedule(ip string) (api.HealthCheckSchedule, bool) {
+	return api.HealthCheckSchedule{}, false
+}
@@ -0,0 +1,100 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package monitoringfakes
+
+import (
+	"bosh-dns/dns/server/monitoring"
+	"bosh-dns/dns/server/record"
+	"sync"
+)
+
+type FakeRecordsValidation struct {
+	ValidationStub        func() []record.Problem
+	validationMutex       sync.RWMutex
+	validationArgsForCall []struct {
+	}
+	validationReturns struct {
+		result1 []record.Problem
+	}
+	validationReturnsOnCall map[int]struct {
+		result1 []record.Problem
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRecordsValidation) Validation() []record.Problem {
+	fake.validationMutex.Lock()
+	ret, specificReturn := fake.validationReturnsOnCall[len(fake.validationArgsForCall)]
+	fake.validationArgsForCall = append(fake.validationArgsForCall, struct {
+	}{})
+	stub := fake.ValidationStub
+	fakeReturns := fake.validationReturns
+	fake.recordInvocation("Validation", []interface{}{})
+	fake.validationMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRecordsValidation) ValidationCallCount() int {
+	fake.validationMutex.RLock()
+	defer fake.validationMutex.RUnlock()
+	return len(fake.validationArgsForCall)
+}
+
+func (fake *FakeRecordsValidation) ValidationCalls(stub func() []record.Problem) {
+	fake.validationMutex.Lock()
+	defer fake.validationMutex.Unlock()
+	fake.ValidationStub = stub
+}
+
+func (fake *FakeRecordsValidation) ValidationReturns(result1 []record.Problem) {
+	fake.validationMutex.Lock()
+	defer fake.validationMutex.Unlock()
+	fake.ValidationStub = nil
+	fake.validationReturns = struct {
+		result1 []record.Problem
+	}{result1}
+}
+
+func (fake *FakeRecordsValidation) ValidationReturnsOnCall(i int, result1 []record.Problem) {
+	fake.validationMutex.Lock()
+	defer fake.validationMutex.Unlock()
+	fake.ValidationStub = nil
+	if fake.validationReturnsOnCall == nil {
+		fake.validationReturnsOnCall = make(map[int]struct {
+			result1 []record.Problem
+		})
+	}
+	fake.validationReturnsOnCall[i] = struct {
+		result1 []record.Problem
+	}{result1}
+}
+
+func (fake *FakeRecordsValidation) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRecordsValidation) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ monitoring.RecordsValidation = new(FakeRecordsValidation)
@@ -0,0 +1,25 @@
+package monitoring
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type RecordsRejectionCounter struct {
+	rejectedCounter prometheus.Counter
+}
+
+func NewRecordsRejectionCounter() RecordsRejectionCounter {
+	rejected := promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "boshdns",
+		Subsystem: "records",
+		Name:      "rejected_total",
+		Help:      "The count of records files that failed verification.",
+	})
+
+	return RecordsRejectionCounter{rejectedCounter: rejected}
+}
+
+func (c RecordsRejectionCounter) IncrementRejectedCounter() {
+	c.rejectedCounter.Inc()
+}
@@ -0,0 +1,48 @@
+package monitoring
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"bosh-dns/dns/server/record"
+)
+
+//counterfeiter:generate . RecordsValidation
+
+type RecordsValidation interface {
+	Validation() []record.Problem
+}
+
+// RecordsValidationCollector exports the number of problems of each kind in
+// the applied records files. It reads the current problems whenever metrics
+// are collected, kinds without problems are reported as 0.
+type RecordsValidationCollector struct {
+	validation RecordsValidation
+	problems   *prometheus.Desc
+}
+
+func NewRecordsValidationCollector(validation RecordsValidation) *RecordsValidationCollector {
+	return &RecordsValidationCollector{
+		validation: validation,
+		problems: prometheus.NewDesc(
+			prometheus.BuildFQName("boshdns", "records", "problems"),
+			"The number of problems found in the applied records files.",
+			[]string{"kind"},
+			nil,
+		),
+	}
+}
+
+func (c *RecordsValidationCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.problems
+}
+
+func (c *RecordsValidationCollector) Collect(metrics chan<- prometheus.Metric) {
+	counts := map[string]int{}
+	for _, problem := range c.validation.Validation() {
+		counts[problem.Kind]++
+	}
+
+	for _, kind := range record.ProblemKinds {
+		metrics <- prometheus.MustNewConstMetric(c.problems, prometheus.GaugeValue, float64(counts[kind]), kind)
+	}
+}
@@ -0,0 +1,64 @@
+package monitoring_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"bosh-dns/dns/server/monitoring"
+	"bosh-dns/dns/server/monitoring/monitoringfakes"
+	"bosh-dns/dns/server/record"
+)
+
+var _ = Describe("RecordsValidationCollector", func() {
+	var (
+		fakeValidation *monitoringfakes.FakeRecordsValidation
+		registry       *prometheus.Registry
+	)
+
+	gauges := func() map[string]float64 {
+		families, err := registry.Gather()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(families).To(HaveLen(1))
+		Expect(families[0].GetName()).To(Equal("boshdns_records_problems"))
+
+		values := map[string]float64{}
+		for _, metric := range families[0].GetMetric() {
+			Expect(metric.GetLabel()).To(HaveLen(1))
+			values[metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+		}
+
+		return values
+	}
+
+	BeforeEach(func() {
+		fakeValidation = &monitoringfakes.FakeRecordsValidation{}
+		registry = prometheus.NewRegistry()
+		Expect(registry.Register(monitoring.NewRecordsValidationCollector(fakeValidation))).To(Succeed())
+	})
+
+	It("reports every kind of problem", func() {
+		Expect(gauges()).To(Equal(map[string]float64{
+			"missing_column":        0,
+			"unbalanced_row":        0,
+			"invalid_value":         0,
+			"missing_value":         0,
+			"duplicate_ip":          0,
+			"duplicate_instance_id": 0,
+			"dangling_alias":        0,
+		}))
+	})
+
+	It("counts the current problems by kind", func() {
+		fakeValidation.ValidationReturns([]record.Problem{
+			{Kind: record.DuplicateIPProblem, Source: "/records.json"},
+			{Kind: record.DuplicateIPProblem, Source: "/other.json"},
+			{Kind: record.UnbalancedRowProblem, Source: "/records.json"},
+		})
+
+		values := gauges()
+		Expect(values["duplicate_ip"]).To(Equal(2.0))
+		Expect(values["unbalanced_row"]).To(Equal(1.0))
+		Expect(values["dangling_alias"]).To(Equal(0.0))
+	})
+})
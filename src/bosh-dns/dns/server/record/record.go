@@ -1,5 +1,7 @@
 package record
 
+import "time"
+
 type Host struct {
 	IP   string
 	FQDN string
@@ -19,4 +21,76 @@ type Record struct {
 	AZID          string
 	AgentID       string
 	InstanceIndex string
+	Bootstrap     bool
+	Lifecycle     string
+	InstanceState string
+}
+
+// Lifecycles and states of instances as the director reports them. Records
+// without a lifecycle or state are taken to be started service instances.
+const (
+	LifecycleService = "service"
+	LifecycleErrand  = "errand"
+
+	InstanceStateStarted  = "started"
+	InstanceStateStopped  = "stopped"
+	InstanceStateDetached = "detached"
+)
+
+// BlobVersion describes a records file that was applied, and how the records
+// changed compared to the one applied before it.
+type BlobVersion struct {
+	Source    string
+	Version   uint64
+	AppliedAt time.Time
+	Records   int
+	Added     int
+	Removed   int
+	Forced    bool
+}
+
+// Conflict is a domain or IP that more than one records source provides.
+// Sources lists the source that is used for a domain first.
+type Conflict struct {
+	Kind    string
+	Value   string
+	Sources []string
+}
+
+const (
+	DomainConflict = "domain"
+	IPConflict     = "ip"
+)
+
+// Problem is something wrong with a records file: a row that was skipped or
+// records that make answers ambiguous. Line and Record locate the row in the
+// records file when the problem is about a single row, Line is 0 otherwise.
+type Problem struct {
+	Kind    string
+	Source  string
+	Line    int
+	Record  int
+	Field   string
+	Message string
+}
+
+const (
+	MissingColumnProblem       = "missing_column"
+	UnbalancedRowProblem       = "unbalanced_row"
+	InvalidValueProblem        = "invalid_value"
+	MissingValueProblem        = "missing_value"
+	DuplicateIPProblem         = "duplicate_ip"
+	DuplicateInstanceIDProblem = "duplicate_instance_id"
+	DanglingAliasProblem       = "dangling_alias"
+)
+
+// ProblemKinds are all kinds of problems, in the order they are reported in.
+var ProblemKinds = []string{
+	MissingColumnProblem,
+	UnbalancedRowProblem,
+	InvalidValueProblem,
+	MissingValueProblem,
+	DuplicateIPProblem,
+	DuplicateInstanceIDProblem,
+	DanglingAliasProblem,
 }
@@ -79,6 +79,10 @@ func NewQueryEncoder(d AliasDefinition) *QueryEncoder {
 	q.InitialHealthCheck = d.InitialHealthCheck
 	q.GroupID = d.GroupID
 	q.RootDomain = d.RootDomain
+	q.Bootstrap = d.Bootstrap
+	q.Lifecycle = d.Lifecycle
+	q.InstanceState = d.InstanceState
+	q.Exclude = d.Exclude
 	return &q
 }
 
@@ -87,6 +91,18 @@ func (q *QueryEncoder) encode() string {
 	var sb strings.Builder
 	sb.WriteString("q-")
 
+	if q.Bootstrap {
+		sb.WriteString("b1")
+	}
+
+	if code := instanceStateCode(q.InstanceState); code != "" {
+		sb.WriteString("e" + code)
+	}
+
+	if code := lifecycleCode(q.Lifecycle); code != "" {
+		sb.WriteString("l" + code)
+	}
+
 	if q.NumID != "" {
 		sb.WriteString(fmt.Sprintf("m%s", q.NumID)) //nolint:staticcheck
 	}
@@ -94,6 +110,8 @@ func (q *QueryEncoder) encode() string {
 	switch q.HealthFilter {
 	case "unhealthy":
 		sb.WriteString("s1")
+	case "ordered":
+		sb.WriteString("s2")
 	case "healthy":
 		sb.WriteString("s3")
 	case "all":
@@ -102,6 +120,8 @@ func (q *QueryEncoder) encode() string {
 		sb.WriteString("s0")
 	}
 
+	q.encodeExclusions(&sb)
+
 	switch q.InitialHealthCheck {
 	case "asynchronous":
 		sb.WriteString("y0")
@@ -113,6 +133,29 @@ func (q *QueryEncoder) encode() string {
 	return sb.String()
 }
 
+// Manually kept alphabetized by the negated key
+func (q *QueryEncoder) encodeExclusions(sb *strings.Builder) {
+	for _, azID := range q.Exclude.AZIDs {
+		sb.WriteString(fmt.Sprintf("xa%s", azID)) //nolint:staticcheck
+	}
+
+	if q.Exclude.Bootstrap {
+		sb.WriteString("xb1")
+	}
+
+	if code := instanceStateCode(q.Exclude.InstanceState); code != "" {
+		sb.WriteString("xe" + code)
+	}
+
+	for _, index := range q.Exclude.InstanceIndexes {
+		sb.WriteString(fmt.Sprintf("xi%s", index)) //nolint:staticcheck
+	}
+
+	if code := lifecycleCode(q.Exclude.Lifecycle); code != "" {
+		sb.WriteString("xl" + code)
+	}
+}
+
 func (a *AliasEncoder) encodeDomains() map[string][]string {
 	ret := make(map[string][]string)
 	for domain, queryEncoders := range a.aliases {
@@ -126,3 +169,27 @@ func (a *AliasEncoder) encodeDomains() map[string][]string {
 	}
 	return ret
 }
+
+func instanceStateCode(state string) string {
+	switch state {
+	case record.InstanceStateStarted:
+		return "0"
+	case record.InstanceStateStopped:
+		return "1"
+	case record.InstanceStateDetached:
+		return "2"
+	}
+
+	return ""
+}
+
+func lifecycleCode(lifecycle string) string {
+	switch lifecycle {
+	case record.LifecycleService:
+		return "0"
+	case record.LifecycleErrand:
+		return "1"
+	}
+
+	return ""
+}
@@ -135,6 +135,32 @@ var _ = Describe("DNS encoding", func() {
 				})
 			})
 
+			Context("when ordered", func() {
+				BeforeEach(func() {
+					aliasDefinitions = map[string][]records.AliasDefinition{
+						"custom-alias.": []records.AliasDefinition{
+							{
+								GroupID:      "1",
+								RootDomain:   "a2_domain1",
+								HealthFilter: "ordered",
+							},
+						},
+					}
+				})
+
+				It("includes correct s filter", func() {
+					encodedAliases := aliasEncoder.EncodeAliasesIntoQueries(
+						[]record.Record{{GroupIDs: []string{"1"}, Domain: "a2_domain1."}},
+						aliasDefinitions,
+					)
+					Expect(encodedAliases).To(
+						Equal(
+							map[string][]string{"custom-alias.": {"q-s2.q-g1.a2_domain1."}},
+						),
+					)
+				})
+			})
+
 			Context("when unhealthy", func() {
 				BeforeEach(func() {
 					aliasDefinitions = map[string][]records.AliasDefinition{
@@ -222,6 +248,94 @@ var _ = Describe("DNS encoding", func() {
 			})
 		})
 
+		Context("with bootstrap, lifecycle and instance_state", func() {
+			BeforeEach(func() {
+				aliasDefinitions = map[string][]records.AliasDefinition{
+					"custom-alias.": []records.AliasDefinition{
+						{
+							GroupID:       "1",
+							RootDomain:    "a2_domain1",
+							Bootstrap:     true,
+							Lifecycle:     "service",
+							InstanceState: "started",
+						},
+					},
+				}
+			})
+
+			It("includes correct b, e and l filters", func() {
+				encodedAliases := aliasEncoder.EncodeAliasesIntoQueries(
+					[]record.Record{{GroupIDs: []string{"1"}, Domain: "a2_domain1."}},
+					aliasDefinitions,
+				)
+				Expect(encodedAliases).To(
+					Equal(
+						map[string][]string{"custom-alias.": {"q-b1e0l0s0.q-g1.a2_domain1."}},
+					),
+				)
+			})
+
+			Context("when selecting stopped errands", func() {
+				BeforeEach(func() {
+					aliasDefinitions = map[string][]records.AliasDefinition{
+						"custom-alias.": []records.AliasDefinition{
+							{
+								GroupID:       "1",
+								RootDomain:    "a2_domain1",
+								Lifecycle:     "errand",
+								InstanceState: "stopped",
+							},
+						},
+					}
+				})
+
+				It("includes correct e and l filters", func() {
+					encodedAliases := aliasEncoder.EncodeAliasesIntoQueries(
+						[]record.Record{{GroupIDs: []string{"1"}, Domain: "a2_domain1."}},
+						aliasDefinitions,
+					)
+					Expect(encodedAliases).To(
+						Equal(
+							map[string][]string{"custom-alias.": {"q-e1l1s0.q-g1.a2_domain1."}},
+						),
+					)
+				})
+			})
+		})
+
+		Context("with exclude", func() {
+			BeforeEach(func() {
+				aliasDefinitions = map[string][]records.AliasDefinition{
+					"custom-alias.": []records.AliasDefinition{
+						{
+							GroupID:            "1",
+							RootDomain:         "a2_domain1",
+							InitialHealthCheck: "synchronous",
+							Exclude: records.AliasExclusion{
+								AZIDs:           []string{"2", "3"},
+								InstanceIndexes: []string{"0"},
+								Bootstrap:       true,
+								Lifecycle:       "errand",
+								InstanceState:   "stopped",
+							},
+						},
+					},
+				}
+			})
+
+			It("includes negated filters between the s and y filters", func() {
+				encodedAliases := aliasEncoder.EncodeAliasesIntoQueries(
+					[]record.Record{{GroupIDs: []string{"1"}, Domain: "a2_domain1."}},
+					aliasDefinitions,
+				)
+				Expect(encodedAliases).To(
+					Equal(
+						map[string][]string{"custom-alias.": {"q-s0xa2xa3xb1xe1xi0xl1y1.q-g1.a2_domain1."}},
+					),
+				)
+			})
+		})
+
 		Context("with placeholder_type", func() {
 			Context("when uuid", func() {
 				BeforeEach(func() {
@@ -7,10 +7,21 @@ import (
 )
 
 type FakeRecordSet struct {
-	ResolveStub        func(domain string) ([]string, error)
+	OrderedAnswersStub        func(string) bool
+	orderedAnswersMutex       sync.RWMutex
+	orderedAnswersArgsForCall []struct {
+		arg1 string
+	}
+	orderedAnswersReturns struct {
+		result1 bool
+	}
+	orderedAnswersReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	ResolveStub        func(string) ([]string, error)
 	resolveMutex       sync.RWMutex
 	resolveArgsForCall []struct {
-		domain string
+		arg1 string
 	}
 	resolveReturns struct {
 		result1 []string
@@ -24,21 +35,84 @@ type FakeRecordSet struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeRecordSet) Resolve(domain string) ([]string, error) {
+func (fake *FakeRecordSet) OrderedAnswers(arg1 string) bool {
+	fake.orderedAnswersMutex.Lock()
+	ret, specificReturn := fake.orderedAnswersReturnsOnCall[len(fake.orderedAnswersArgsForCall)]
+	fake.orderedAnswersArgsForCall = append(fake.orderedAnswersArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.OrderedAnswersStub
+	fakeReturns := fake.orderedAnswersReturns
+	fake.recordInvocation("OrderedAnswers", []interface{}{arg1})
+	fake.orderedAnswersMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRecordSet) OrderedAnswersCallCount() int {
+	fake.orderedAnswersMutex.RLock()
+	defer fake.orderedAnswersMutex.RUnlock()
+	return len(fake.orderedAnswersArgsForCall)
+}
+
+func (fake *FakeRecordSet) OrderedAnswersCalls(stub func(string) bool) {
+	fake.orderedAnswersMutex.Lock()
+	defer fake.orderedAnswersMutex.Unlock()
+	fake.OrderedAnswersStub = stub
+}
+
+func (fake *FakeRecordSet) OrderedAnswersArgsForCall(i int) string {
+	fake.orderedAnswersMutex.RLock()
+	defer fake.orderedAnswersMutex.RUnlock()
+	argsForCall := fake.orderedAnswersArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRecordSet) OrderedAnswersReturns(result1 bool) {
+	fake.orderedAnswersMutex.Lock()
+	defer fake.orderedAnswersMutex.Unlock()
+	fake.OrderedAnswersStub = nil
+	fake.orderedAnswersReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeRecordSet) OrderedAnswersReturnsOnCall(i int, result1 bool) {
+	fake.orderedAnswersMutex.Lock()
+	defer fake.orderedAnswersMutex.Unlock()
+	fake.OrderedAnswersStub = nil
+	if fake.orderedAnswersReturnsOnCall == nil {
+		fake.orderedAnswersReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.orderedAnswersReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeRecordSet) Resolve(arg1 string) ([]string, error) {
 	fake.resolveMutex.Lock()
 	ret, specificReturn := fake.resolveReturnsOnCall[len(fake.resolveArgsForCall)]
 	fake.resolveArgsForCall = append(fake.resolveArgsForCall, struct {
-		domain string
-	}{domain})
-	fake.recordInvocation("Resolve", []interface{}{domain})
+		arg1 string
+	}{arg1})
+	stub := fake.ResolveStub
+	fakeReturns := fake.resolveReturns
+	fake.recordInvocation("Resolve", []interface{}{arg1})
 	fake.resolveMutex.Unlock()
-	if fake.ResolveStub != nil {
-		return fake.ResolveStub(domain)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.resolveReturns.result1, fake.resolveReturns.result2
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeRecordSet) ResolveCallCount() int {
@@ -47,13 +121,22 @@ func (fake *FakeRecordSet) ResolveCallCount() int {
 	return len(fake.resolveArgsForCall)
 }
 
+func (fake *FakeRecordSet) ResolveCalls(stub func(string) ([]string, error)) {
+	fake.resolveMutex.Lock()
+	defer fake.resolveMutex.Unlock()
+	fake.ResolveStub = stub
+}
+
 func (fake *FakeRecordSet) ResolveArgsForCall(i int) string {
 	fake.resolveMutex.RLock()
 	defer fake.resolveMutex.RUnlock()
-	return fake.resolveArgsForCall[i].domain
+	argsForCall := fake.resolveArgsForCall[i]
+	return argsForCall.arg1
 }
 
 func (fake *FakeRecordSet) ResolveReturns(result1 []string, result2 error) {
+	fake.resolveMutex.Lock()
+	defer fake.resolveMutex.Unlock()
 	fake.ResolveStub = nil
 	fake.resolveReturns = struct {
 		result1 []string
@@ -62,6 +145,8 @@ func (fake *FakeRecordSet) ResolveReturns(result1 []string, result2 error) {
 }
 
 func (fake *FakeRecordSet) ResolveReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.resolveMutex.Lock()
+	defer fake.resolveMutex.Unlock()
 	fake.ResolveStub = nil
 	if fake.resolveReturnsOnCall == nil {
 		fake.resolveReturnsOnCall = make(map[int]struct {
@@ -78,9 +163,11 @@ func (fake *FakeRecordSet) ResolveReturnsOnCall(i int, result1 []string, result2
 func (fake *FakeRecordSet) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.resolveMutex.RLock()
-	defer fake.resolveMutex.RUnlock()
-	return fake.invocations
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
 }
 
 func (fake *FakeRecordSet) recordInvocation(key string, args []interface{}) {
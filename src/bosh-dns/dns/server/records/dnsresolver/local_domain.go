@@ -23,6 +23,7 @@ type LocalDomain struct {
 
 type RecordSet interface {
 	Resolve(domain string) ([]string, error)
+	OrderedAnswers(domain string) bool
 }
 
 func NewLocalDomain(logger logger.Logger, recordSet RecordSet, truncater ResponseTruncater) LocalDomain {
@@ -103,9 +104,11 @@ func (d LocalDomain) resolve(question dns.Question) ([]dns.RR, int) {
 		}
 	}
 
-	rand.Shuffle(len(answers), func(i, j int) {
-		answers[i], answers[j] = answers[j], answers[i]
-	})
+	if len(answers) > 1 && !d.recordSet.OrderedAnswers(lowercaseName) {
+		rand.Shuffle(len(answers), func(i, j int) {
+			answers[i], answers[j] = answers[j], answers[i]
+		})
+	}
 
 	return answers, dns.RcodeSuccess
 }
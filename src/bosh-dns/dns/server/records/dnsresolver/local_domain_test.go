@@ -108,6 +108,31 @@ var _ = Describe("LocalDomain", func() {
 			Expect(responseMsg.Rcode).To(Equal(dns.RcodeSuccess))
 		})
 
+		Context("when the answers are ordered by health", func() {
+			BeforeEach(func() {
+				fakeRecordSet.ResolveReturns([]string{"1.1.1.1", "2.2.2.2", "3.3.3.3", "4.4.4.4", "5.5.5.5"}, nil)
+				fakeRecordSet.OrderedAnswersReturns(true)
+			})
+
+			It("keeps the order of the answers", func() {
+				req := &dns.Msg{}
+				SetQuestion(req, nil, "Q-S2.group-1.network-name.deployment-name.bosh.", dns.TypeA)
+				responseMsg := localDomain.Resolve(
+					fakeWriter,
+					req,
+				)
+
+				var answerStrings []string
+				for _, a := range responseMsg.Answer {
+					answerStrings = append(answerStrings, a.(*dns.A).A.String())
+				}
+				Expect(answerStrings).To(Equal([]string{"1.1.1.1", "2.2.2.2", "3.3.3.3", "4.4.4.4", "5.5.5.5"}))
+
+				Expect(fakeRecordSet.OrderedAnswersCallCount()).To(Equal(1))
+				Expect(fakeRecordSet.OrderedAnswersArgsForCall(0)).To(Equal("q-s2.group-1.network-name.deployment-name.bosh."))
+			})
+		})
+
 		Context("when there are too many records to fit into 512 bytes", func() {
 			var (
 				request *dns.Msg
@@ -3,6 +3,7 @@ package records
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sync"
 	"time"
@@ -11,10 +12,21 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	"github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/cloudfoundry/bosh-utils/system"
+	"github.com/fsnotify/fsnotify"
 )
 
 const logTag string = "RecordsRepo"
 
+const (
+	// PollInterval is how often the records file is checked when it cannot be
+	// watched for changes.
+	PollInterval = time.Second
+
+	// DebounceInterval is how long the records file has to stay untouched
+	// after a change before it is read, so that partial writes are skipped.
+	DebounceInterval = 100 * time.Millisecond
+)
+
 //counterfeiter:generate . FileReader
 
 type FileReader interface {
@@ -23,7 +35,6 @@ type FileReader interface {
 }
 
 type autoUpdatingRepo struct {
-	// updateImmediately chan struct{}
 	recordsFilePath string
 	fileSystem      system.FileSystem
 	clock           clock.Clock
@@ -33,10 +44,21 @@ type autoUpdatingRepo struct {
 	cache           []byte
 	cacheErr        error
 
-	subscribers []chan bool
+	verifier         Verifier
+	verifierStat     os.FileInfo
+	rejectionCounter RejectionCounter
+
+	subscriptions *subscriptions
 }
 
 func NewFileReader(recordsFilePath string, fileSys system.FileSystem, clock clock.Clock, logger logger.Logger, shutdownChan chan struct{}) FileReader {
+	return NewVerifyingFileReader(recordsFilePath, nil, nil, fileSys, clock, logger, shutdownChan)
+}
+
+// NewVerifyingFileReader only accepts contents of the records file that pass
+// the verifier. Rejected contents are counted and logged, and the last
+// accepted contents keep being served. A nil verifier accepts everything.
+func NewVerifyingFileReader(recordsFilePath string, verifier Verifier, rejectionCounter RejectionCounter, fileSys system.FileSystem, clock clock.Clock, logger logger.Logger, shutdownChan chan struct{}) FileReader {
 	repo := &autoUpdatingRepo{
 		recordsFilePath: recordsFilePath,
 		fileSystem:      fileSys,
@@ -44,7 +66,10 @@ func NewFileReader(recordsFilePath string, fileSys system.FileSystem, clock cloc
 		logger:          logger,
 		rwlock:          &sync.RWMutex{},
 
-		subscribers: []chan bool{},
+		verifier:         verifier,
+		rejectionCounter: rejectionCounter,
+
+		subscriptions: &subscriptions{},
 	}
 
 	_, fileContents, err := repo.needNewFromDisk()
@@ -54,41 +79,193 @@ func NewFileReader(recordsFilePath string, fileSys system.FileSystem, clock cloc
 		logger.Error(logTag, fmt.Sprintf("Unable to open records file at: %s", recordsFilePath))
 	}
 
+	watcher, err := repo.newWatcher()
+	if err != nil {
+		logger.Info(logTag, "Polling records file every %s: %s", PollInterval, err.Error())
+	}
+
 	go func() {
-		for {
-			select {
-			case <-shutdownChan:
-				break //nolint:staticcheck
-			default:
-				clock.Sleep(time.Second)
-
-				newData, data, err := repo.needNewFromDisk()
-				if newData && err == nil {
-					repo.atomicallyUpdateCache(data, err)
-					for _, c := range repo.subscribers {
-						c <- true
-					}
-				}
-			}
+		defer repo.subscriptions.close()
+
+		if watcher == nil {
+			repo.poll(shutdownChan)
+			return
 		}
+
+		repo.watch(watcher, shutdownChan)
 	}()
 
 	return repo
 }
 
 func (r *autoUpdatingRepo) Subscribe() <-chan bool {
-	c := make(chan bool)
-	r.subscribers = append(r.subscribers, c)
+	return r.subscriptions.subscribe()
+}
+
+// newWatcher watches the directory of the records file rather than the file
+// itself so that the watch survives the file being replaced by a rename.
+func (r *autoUpdatingRepo) newWatcher() (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating records file watcher")
+	}
+
+	for _, path := range r.watchedPaths() {
+		dir := filepath.Dir(path)
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close() //nolint:errcheck
+			return nil, bosherr.WrapErrorf(err, "Watching records directory '%s'", dir)
+		}
+	}
+
+	return watcher, nil
+}
+
+func (r *autoUpdatingRepo) watchedPaths() []string {
+	paths := []string{filepath.Clean(r.recordsFilePath)}
+	if r.verifier != nil {
+		paths = append(paths, filepath.Clean(r.verifier.Path()))
+	}
+
+	return paths
+}
+
+func (r *autoUpdatingRepo) isWatched(path string) bool {
+	for _, watchedPath := range r.watchedPaths() {
+		if filepath.Clean(path) == watchedPath {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *autoUpdatingRepo) watch(watcher *fsnotify.Watcher, shutdownChan chan struct{}) {
+	defer watcher.Close() //nolint:errcheck
+
+	var (
+		debounce  clock.Timer
+		debounceC <-chan time.Time
+	)
+
+	for {
+		select {
+		case <-shutdownChan:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				r.poll(shutdownChan)
+				return
+			}
+
+			if !r.isWatched(event.Name) {
+				continue
+			}
+
+			if debounce == nil {
+				debounce = r.clock.NewTimer(DebounceInterval)
+			} else {
+				debounce.Reset(DebounceInterval)
+			}
+			debounceC = debounce.C()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				r.poll(shutdownChan)
+				return
+			}
+
+			// Events may have been dropped, so look at the file directly.
+			r.logger.Warn(logTag, "Watching records file: %s", err.Error())
+			r.refresh()
+		case <-debounceC:
+			debounceC = nil
+			r.refresh()
+		}
+	}
+}
+
+func (r *autoUpdatingRepo) poll(shutdownChan chan struct{}) {
+	for {
+		timer := r.clock.NewTimer(PollInterval)
+
+		select {
+		case <-shutdownChan:
+			timer.Stop()
+			return
+		case <-timer.C():
+			r.refresh()
+		}
+	}
+}
+
+func (r *autoUpdatingRepo) refresh() {
+	newData, data, err := r.needNewFromDisk()
+	if newData && err == nil {
+		r.atomicallyUpdateCache(data, err)
+		r.subscriptions.notify()
+	}
+}
+
+type subscriptions struct {
+	mutex       sync.Mutex
+	subscribers []chan bool
+	closed      bool
+}
+
+func (s *subscriptions) subscribe() <-chan bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// A single pending notification is enough for a subscriber to pick up
+	// the latest contents, so the buffer never has to grow.
+	c := make(chan bool, 1)
+	if s.closed {
+		close(c)
+		return c
+	}
+
+	s.subscribers = append(s.subscribers, c)
 	return c
 }
 
+// notify never blocks: a subscriber that has not consumed its previous
+// notification already knows that it has to read the contents again.
+func (s *subscriptions) notify() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, c := range s.subscribers {
+		select {
+		case c <- true:
+		default:
+		}
+	}
+}
+
+func (s *subscriptions) close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, c := range s.subscribers {
+		close(c)
+	}
+	s.subscribers = nil
+	s.closed = true
+}
+
 func (r *autoUpdatingRepo) needNewFromDisk() (bool, []byte, error) {
 	newStat, err := r.fileSystem.StatWithOpts(r.recordsFilePath, system.StatOpts{Quiet: true})
 	if err != nil {
 		return false, nil, bosherr.Errorf("Error stating records file '%s': %s", r.recordsFilePath, err.Error())
 	}
 
-	if reflect.DeepEqual(r.cacheStat, newStat) {
+	// A changed signature or checksum can make the current contents valid.
+	var newVerifierStat os.FileInfo
+	if r.verifier != nil {
+		newVerifierStat, _ = r.fileSystem.StatWithOpts(r.verifier.Path(), system.StatOpts{Quiet: true}) //nolint:errcheck
+	}
+
+	if reflect.DeepEqual(r.cacheStat, newStat) && reflect.DeepEqual(r.verifierStat, newVerifierStat) {
 		return false, nil, nil
 	}
 
@@ -99,6 +276,18 @@ func (r *autoUpdatingRepo) needNewFromDisk() (bool, []byte, error) {
 	}
 
 	r.cacheStat = newStat
+	r.verifierStat = newVerifierStat
+
+	if r.verifier != nil {
+		if err := r.verifier.Verify(buf); err != nil {
+			r.logger.Error(logTag, "Rejecting records file '%s': %s", r.recordsFilePath, err.Error())
+			if r.rejectionCounter != nil {
+				r.rejectionCounter.IncrementRejectedCounter()
+			}
+
+			return true, nil, bosherr.WrapErrorf(err, "Rejecting records file '%s'", r.recordsFilePath)
+		}
+	}
 
 	return true, buf, nil
 }
@@ -2,8 +2,11 @@ package records_test
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"time"
 
+	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/clock/fakeclock"
 	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -12,6 +15,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"bosh-dns/dns/server/records"
+	"bosh-dns/dns/server/records/recordsfakes"
 )
 
 var _ = Describe("RecordsFileReader", func() {
@@ -409,5 +413,186 @@ var _ = Describe("RecordsFileReader", func() {
 			fakeClock.WaitForWatcherAndIncrement(time.Second)
 			Eventually(c).Should(Receive())
 		})
+
+		It("does not block on subscribers that have not consumed earlier notifications", func() {
+			slow := fileReader.Subscribe()
+			fast := fileReader.Subscribe()
+
+			for i := 0; i < 3; i++ {
+				fakeFileSystem.RegisterOpenFile(recordsFile.Name(), &fakes.FakeFile{
+					Stats: &fakes.FakeFileStats{
+						ModTime: fakeClock.Now().Add(time.Duration(i) * time.Second),
+					},
+				})
+
+				fakeClock.WaitForWatcherAndIncrement(time.Second)
+				Eventually(fast).Should(Receive())
+			}
+
+			Expect(slow).To(Receive())
+			Expect(slow).NotTo(Receive())
+		})
+
+		It("closes the subscriptions on shutdown", func() {
+			c := fileReader.Subscribe()
+
+			close(shutdownChan)
+			Eventually(c).Should(BeClosed())
+			Expect(fileReader.Subscribe()).To(BeClosed())
+
+			shutdownChan = make(chan struct{})
+		})
+	})
+})
+
+var _ = Describe("RecordsFileReader with verification", func() {
+	var (
+		shutdownChan         chan struct{}
+		recordsFile          boshsys.File
+		fileReader           records.FileReader
+		fakeClock            *fakeclock.FakeClock
+		fakeLogger           *loggerfakes.FakeLogger
+		fakeFileSystem       *fakes.FakeFileSystem
+		fakeVerifier         *recordsfakes.FakeVerifier
+		fakeRejectionCounter *recordsfakes.FakeRejectionCounter
+		fileContents         string
+	)
+
+	BeforeEach(func() {
+		shutdownChan = make(chan struct{})
+		fakeFileSystem = fakes.NewFakeFileSystem()
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		fakeLogger = &loggerfakes.FakeLogger{}
+		recordsFile = fakes.NewFakeFile("/fake/file", fakeFileSystem)
+
+		fileContents = `{"record_keys": ["id"], "record_infos": [["my-instance"]]}`
+		Expect(fakeFileSystem.WriteFileString(recordsFile.Name(), fileContents)).To(Succeed())
+
+		fakeVerifier = &recordsfakes.FakeVerifier{}
+		fakeVerifier.PathReturns("/fake/file.sig")
+		fakeRejectionCounter = &recordsfakes.FakeRejectionCounter{}
+
+		Expect(fakeFileSystem.WriteFileString("/fake/file.sig", "signature")).To(Succeed())
+	})
+
+	AfterEach(func() {
+		close(shutdownChan)
+	})
+
+	It("rejects contents that fail verification", func() {
+		fakeVerifier.VerifyReturns(errors.New("bad signature"))
+
+		fileReader = records.NewVerifyingFileReader(recordsFile.Name(), fakeVerifier, fakeRejectionCounter, fakeFileSystem, fakeClock, fakeLogger, shutdownChan)
+
+		_, err := fileReader.Get()
+		Expect(err).To(MatchError("Rejecting records file '/fake/file': bad signature"))
+		Expect(fakeVerifier.VerifyArgsForCall(0)).To(Equal([]byte(fileContents)))
+		Expect(fakeRejectionCounter.IncrementRejectedCounterCallCount()).To(Equal(1))
+	})
+
+	Context("when a verified file is replaced", func() {
+		var subscription <-chan bool
+
+		BeforeEach(func() {
+			fileReader = records.NewVerifyingFileReader(recordsFile.Name(), fakeVerifier, fakeRejectionCounter, fakeFileSystem, fakeClock, fakeLogger, shutdownChan)
+			subscription = fileReader.Subscribe()
+
+			fakeVerifier.VerifyReturns(errors.New("bad signature"))
+			Expect(fakeFileSystem.WriteFileString(recordsFile.Name(), `{"tampered": true}`)).To(Succeed())
+			fakeFileSystem.RegisterOpenFile(recordsFile.Name(), &fakes.FakeFile{
+				Stats: &fakes.FakeFileStats{
+					ModTime: fakeClock.Now().Add(time.Second),
+				},
+			})
+
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(fakeRejectionCounter.IncrementRejectedCounterCallCount).Should(Equal(1))
+		})
+
+		It("keeps the last verified contents when the new ones are rejected", func() {
+			Expect(fileReader.Get()).To(Equal([]byte(fileContents)))
+			Consistently(subscription).ShouldNot(Receive())
+
+			Expect(fakeLogger.ErrorCallCount()).To(Equal(1))
+			_, message, _ := fakeLogger.ErrorArgsForCall(0)
+			Expect(message).To(ContainSubstring("Rejecting records file"))
+		})
+
+		It("verifies the contents again when the signature changes", func() {
+			fakeVerifier.VerifyReturns(nil)
+			fakeFileSystem.RegisterOpenFile("/fake/file.sig", &fakes.FakeFile{
+				Stats: &fakes.FakeFileStats{
+					ModTime: fakeClock.Now().Add(time.Second),
+				},
+			})
+
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(subscription).Should(Receive())
+			Expect(fileReader.Get()).To(Equal([]byte(`{"tampered": true}`)))
+		})
+	})
+})
+
+var _ = Describe("RecordsFileReader on a watched file", func() {
+	var (
+		shutdownChan chan struct{}
+		fileReader   records.FileReader
+		fakeLogger   *loggerfakes.FakeLogger
+		dir          string
+		recordsPath  string
+	)
+
+	BeforeEach(func() {
+		shutdownChan = make(chan struct{})
+		fakeLogger = &loggerfakes.FakeLogger{}
+
+		dir = GinkgoT().TempDir()
+		recordsPath = filepath.Join(dir, "records.json")
+		Expect(os.WriteFile(recordsPath, []byte(`{"record_keys": ["id"], "record_infos": []}`), 0644)).To(Succeed())
+
+		fileReader = records.NewFileReader(recordsPath, boshsys.NewOsFileSystem(fakeLogger), clock.NewClock(), fakeLogger, shutdownChan)
+	})
+
+	AfterEach(func() {
+		close(shutdownChan)
+	})
+
+	It("picks up changes as soon as they are written", func() {
+		c := fileReader.Subscribe()
+
+		Expect(os.WriteFile(recordsPath, []byte(`{"record_keys": []}`), 0644)).To(Succeed())
+
+		Eventually(c).Should(Receive())
+		Expect(fileReader.Get()).To(Equal([]byte(`{"record_keys": []}`)))
+	})
+
+	It("picks up a file that was replaced by a rename", func() {
+		c := fileReader.Subscribe()
+
+		tmpPath := filepath.Join(dir, "records.json.tmp")
+		Expect(os.WriteFile(tmpPath, []byte(`{"record_keys": ["id"]}`), 0644)).To(Succeed())
+		Expect(os.Rename(tmpPath, recordsPath)).To(Succeed())
+
+		Eventually(c).Should(Receive())
+		Expect(fileReader.Get()).To(Equal([]byte(`{"record_keys": ["id"]}`)))
+	})
+
+	It("waits for writes to settle before notifying", func() {
+		c := fileReader.Subscribe()
+
+		f, err := os.OpenFile(recordsPath, os.O_WRONLY|os.O_TRUNC, 0644)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.WriteString(`{"record_keys": `)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Sync()).To(Succeed())
+
+		Consistently(c, records.DebounceInterval/2).ShouldNot(Receive())
+
+		_, err = f.WriteString(`[]}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+
+		Eventually(c).Should(Receive())
+		Expect(fileReader.Get()).To(Equal([]byte(`{"record_keys": []}`)))
 	})
 })
@@ -72,21 +72,27 @@ func (q *healthFilter) Filter(mm criteria.MatchMaker, recs []record.Record) []re
 		q.processRecords(crit, records)
 	}
 
-	healthyRecords, unhealthyRecords, maybeHealthyRecords := q.sortRecords(records, crit["g"])
+	healthyRecords, unhealthyRecords, unknownRecords, uncheckedRecords := q.sortRecords(records, crit["g"])
 
 	switch healthStrategy {
 	case "1": // unhealthy ones
 		return unhealthyRecords
+	case "2": // all, ordered by health
+		orderedRecords := make([]record.Record, 0, len(records))
+		orderedRecords = append(orderedRecords, healthyRecords...)
+		orderedRecords = append(orderedRecords, uncheckedRecords...)
+		orderedRecords = append(orderedRecords, unknownRecords...)
+		return append(orderedRecords, unhealthyRecords...)
 	case "3": // healthy
 		return healthyRecords
 	case "4": // all
 		return records
 	default: // smart strategy
-		if len(maybeHealthyRecords) == 0 {
+		if len(healthyRecords)+len(uncheckedRecords) == 0 {
 			return records
 		}
 
-		return maybeHealthyRecords
+		return append(healthyRecords, uncheckedRecords...)
 	}
 }
 
@@ -129,25 +135,23 @@ func (q *healthFilter) waitForWaitGroupOrTimeout() {
 	}
 }
 
-func (q *healthFilter) sortRecords(records []record.Record, queriedGroupIDs []string) (healthyRecords, unhealthyRecords, maybeHealthyRecords []record.Record) {
-	var unknownRecords, uncheckedRecords []record.Record
-
+// sortRecords keeps the order of the records within each health state.
+// Records in states other than running, failing and unchecked are unknown.
+func (q *healthFilter) sortRecords(records []record.Record, queriedGroupIDs []string) (healthyRecords, unhealthyRecords, unknownRecords, uncheckedRecords []record.Record) {
 	for _, r := range records {
 		switch q.interpretHealthState(r.IP, queriedGroupIDs) {
 		case api.StatusRunning:
 			healthyRecords = append(healthyRecords, r)
 		case api.StatusFailing:
 			unhealthyRecords = append(unhealthyRecords, r)
-		case healthiness.StateUnknown:
-			unknownRecords = append(unknownRecords, r) //nolint:staticcheck
 		case healthiness.StateUnchecked:
 			uncheckedRecords = append(uncheckedRecords, r)
+		default:
+			unknownRecords = append(unknownRecords, r)
 		}
 	}
 
-	maybeHealthyRecords = append(healthyRecords, uncheckedRecords...)
-
-	return healthyRecords, unhealthyRecords, maybeHealthyRecords
+	return healthyRecords, unhealthyRecords, unknownRecords, uncheckedRecords
 }
 
 func (q *healthFilter) interpretHealthState(ip string, queriedGroupIDs []string) api.HealthStatus {
@@ -180,7 +184,13 @@ func (q *healthFilter) synchronousHealthCheck(strategy, ip string) bool {
 		}
 		usedWaitGroup = true
 	case "2":
-		// q.runCheck(ip) to be implemented in a future story
+		// always check, concurrent checks of the same IP are coalesced by the watcher
+		q.wg.Add(1)
+		q.filterWorkPool.Submit(func() {
+			defer q.wg.Done()
+			q.w.RunCheck(ip)
+		})
+		usedWaitGroup = true
 	}
 
 	return usedWaitGroup
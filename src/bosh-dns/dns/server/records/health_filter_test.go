@@ -165,6 +165,34 @@ var _ = Describe("HealthFilter", func() {
 				Entry("unchecked", record.Record{IP: "4.4.4.4"}, false),
 			)
 		})
+		Context("health strategy ordered by health", func() {
+			BeforeEach(func() {
+				healthStrategy = "2"
+			})
+
+			It("returns every record ordered healthy, unchecked, unknown and failing", func() {
+				recs := []record.Record{
+					record.Record{IP: "2.2.2.2", ID: "failing"},
+					record.Record{IP: "3.3.3.3"},
+					record.Record{IP: "4.4.4.4"},
+					record.Record{IP: "1.1.1.1"},
+					record.Record{IP: "2.2.2.2", ID: "failing-too"},
+					record.Record{IP: "5.5.5.5"},
+				}
+				fakeFilter.FilterReturns(recs)
+
+				results := healthFilter.Filter(crit, recs)
+				Expect(results).To(Equal([]record.Record{
+					record.Record{IP: "1.1.1.1"},
+					record.Record{IP: "4.4.4.4"},
+					record.Record{IP: "3.3.3.3"},
+					record.Record{IP: "5.5.5.5"},
+					record.Record{IP: "2.2.2.2", ID: "failing"},
+					record.Record{IP: "2.2.2.2", ID: "failing-too"},
+				}))
+			})
+		})
+
 		Context("health strategy all records", func() {
 			BeforeEach(func() {
 				healthStrategy = "4"
@@ -442,6 +470,68 @@ var _ = Describe("HealthFilter", func() {
 					})
 				})
 			})
+			Context("syncStrategy 2", func() {
+				var recs []record.Record
+				BeforeEach(func() {
+					syncStrategy = "2"
+					healthStrategy = "3"
+					recs = []record.Record{
+						record.Record{IP: "1.1.1.1"},
+						record.Record{IP: "2.2.2.2"},
+					}
+					fakeFilter.FilterReturns(recs)
+
+					fakeHealthWatcher.HealthStateStub = nil
+					fakeHealthWatcher.HealthStateReturns(api.HealthResult{State: api.StatusFailing})
+					fakeHealthWatcher.RunCheckStub = func(ip string) api.HealthResult {
+						fakeHealthWatcher.HealthStateReturns(api.HealthResult{State: api.StatusRunning})
+						return api.HealthResult{State: api.StatusRunning}
+					}
+				})
+
+				It("performs a healthcheck of every record before returning results, whatever their state", func() {
+					results := healthFilter.Filter(crit, recs)
+
+					Expect(results).To(ConsistOf(recs))
+					Expect(fakeHealthWatcher.RunCheckCallCount()).To(Equal(2))
+					Expect([]string{
+						fakeHealthWatcher.RunCheckArgsForCall(0),
+						fakeHealthWatcher.RunCheckArgsForCall(1),
+					}).To(ConsistOf("1.1.1.1", "2.2.2.2"))
+				})
+
+				It("performs a healthcheck on every query", func() {
+					healthFilter.Filter(crit, recs)
+					Eventually(healthChan).Should(Receive())
+					Eventually(healthChan).Should(Receive())
+
+					healthFilter.Filter(crit, recs)
+
+					Expect(fakeHealthWatcher.RunCheckCallCount()).To(Equal(4))
+				})
+
+				Context("when it takes too long to check health", func() {
+					BeforeEach(func() {
+						recs = []record.Record{
+							record.Record{IP: "2.2.2.2"},
+						}
+						fakeFilter.FilterReturns(recs)
+
+						fakeHealthWatcher.RunCheckStub = func(ip string) api.HealthResult {
+							clock.WaitForWatcherAndIncrement(2 * time.Second)
+							return api.HealthResult{State: api.StatusRunning}
+						}
+					})
+
+					It("answers with the health known when timing out", func() {
+						waitGroup.Add(1)
+						results := healthFilter.Filter(crit, recs)
+						waitGroup.Done()
+
+						Expect(results).To(BeEmpty())
+					})
+				})
+			})
 		})
 	})
 
@@ -0,0 +1,242 @@
+package records
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cloudfoundry/bosh-utils/system"
+
+	"bosh-dns/dns/config"
+	"bosh-dns/tlsclient"
+)
+
+// RemoteMaxBackoff is the longest time between two attempts to fetch remote
+// records after failures, unless the poll interval itself is longer.
+const RemoteMaxBackoff = 5 * time.Minute
+
+//counterfeiter:generate . HTTPClient
+
+type HTTPClient interface {
+	GetCustomized(endpoint string, f func(*http.Request)) (*http.Response, error)
+}
+
+type httpRepo struct {
+	url          string
+	client       HTTPClient
+	pollInterval time.Duration
+	fallbackPath string
+	fileSystem   system.FileSystem
+	clock        clock.Clock
+	logger       logger.Logger
+	rwlock       *sync.RWMutex
+	cache        []byte
+	cacheErr     error
+
+	etag         string
+	lastModified string
+
+	subscriptions *subscriptions
+}
+
+// NewRemoteFileReader fetches the records file from the configured URL with
+// mutual TLS.
+func NewRemoteFileReader(remote config.RemoteRecords, timeout time.Duration, fileSys system.FileSystem, clock clock.Clock, logger logger.Logger, shutdownChan chan struct{}) (FileReader, error) {
+	serverName := remote.ServerName
+	if serverName == "" {
+		remoteURL, err := url.Parse(remote.URL)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing remote records URL '%s'", remote.URL)
+		}
+		serverName = remoteURL.Hostname()
+	}
+
+	client, err := tlsclient.NewFromFiles(serverName, remote.CAFile, remote.CertificateFile, remote.PrivateKeyFile, timeout, logger)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Configuring remote records client")
+	}
+
+	return NewHTTPFileReader(remote.URL, client, time.Duration(remote.PollInterval), remote.FallbackFile, fileSys, clock, logger, shutdownChan), nil
+}
+
+// NewHTTPFileReader polls the URL with conditional requests and serves the
+// last records file it received. Every received records file is written to
+// fallbackPath, which is served until the URL can be reached again after a
+// restart. Failed requests back off exponentially up to RemoteMaxBackoff.
+func NewHTTPFileReader(url string, client HTTPClient, pollInterval time.Duration, fallbackPath string, fileSys system.FileSystem, clock clock.Clock, logger logger.Logger, shutdownChan chan struct{}) FileReader {
+	if pollInterval <= 0 {
+		pollInterval = PollInterval
+	}
+
+	repo := &httpRepo{
+		url:          url,
+		client:       client,
+		pollInterval: pollInterval,
+		fallbackPath: fallbackPath,
+		fileSystem:   fileSys,
+		clock:        clock,
+		logger:       logger,
+		rwlock:       &sync.RWMutex{},
+		cacheErr:     bosherr.Errorf("No records fetched from '%s' yet", url),
+
+		subscriptions: &subscriptions{},
+	}
+
+	repo.loadFallback()
+
+	failures := 0
+	if _, err := repo.fetch(); err != nil {
+		logger.Error(logTag, "Unable to fetch records from '%s': %s", url, err.Error())
+		failures++
+	}
+
+	go func() {
+		defer repo.subscriptions.close()
+		repo.poll(shutdownChan, failures)
+	}()
+
+	return repo
+}
+
+func (r *httpRepo) Get() ([]byte, error) {
+	r.rwlock.RLock()
+	defer r.rwlock.RUnlock()
+
+	if r.cacheErr != nil {
+		return nil, r.cacheErr
+	}
+
+	return r.cache, nil
+}
+
+func (r *httpRepo) Subscribe() <-chan bool {
+	return r.subscriptions.subscribe()
+}
+
+func (r *httpRepo) poll(shutdownChan chan struct{}, failures int) {
+	for {
+		timer := r.clock.NewTimer(r.nextInterval(failures))
+
+		select {
+		case <-shutdownChan:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		changed, err := r.fetch()
+		if err != nil {
+			failures++
+			r.logger.Warn(logTag, "Unable to fetch records from '%s', retrying in %s: %s", r.url, r.nextInterval(failures), err.Error())
+			continue
+		}
+
+		failures = 0
+		if changed {
+			r.subscriptions.notify()
+		}
+	}
+}
+
+func (r *httpRepo) nextInterval(failures int) time.Duration {
+	maxBackoff := RemoteMaxBackoff
+	if r.pollInterval > maxBackoff {
+		maxBackoff = r.pollInterval
+	}
+
+	interval := r.pollInterval
+	for i := 0; i < failures && interval < maxBackoff; i++ {
+		interval *= 2
+	}
+
+	if interval > maxBackoff {
+		return maxBackoff
+	}
+
+	return interval
+}
+
+// fetch reports whether the served contents changed.
+func (r *httpRepo) fetch() (bool, error) {
+	etag, lastModified := r.etag, r.lastModified
+	response, err := r.client.GetCustomized(r.url, func(request *http.Request) {
+		if etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+		if lastModified != "" {
+			request.Header.Set("If-Modified-Since", lastModified)
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	switch response.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+	default:
+		return false, bosherr.Errorf("Unexpected status %d", response.StatusCode)
+	}
+
+	contents, err := io.ReadAll(newCappedReader(response.Body, MaxRecordsSize))
+	if err != nil {
+		return false, bosherr.WrapError(err, "Reading records response")
+	}
+
+	r.etag = response.Header.Get("ETag")
+	r.lastModified = response.Header.Get("Last-Modified")
+
+	if current, err := r.Get(); err == nil && bytes.Equal(current, contents) {
+		return false, nil
+	}
+
+	r.rwlock.Lock()
+	r.cache = contents
+	r.cacheErr = nil
+	r.rwlock.Unlock()
+
+	r.writeFallback(contents)
+
+	return true, nil
+}
+
+func (r *httpRepo) loadFallback() {
+	if r.fallbackPath == "" || !r.fileSystem.FileExists(r.fallbackPath) {
+		return
+	}
+
+	contents, err := r.fileSystem.ReadFile(r.fallbackPath)
+	if err != nil {
+		r.logger.Warn(logTag, "Unable to read fallback records file '%s': %s", r.fallbackPath, err.Error())
+		return
+	}
+
+	r.cache = contents
+	r.cacheErr = nil
+}
+
+// writeFallback replaces the fallback file with a rename so that it is never
+// left partially written.
+func (r *httpRepo) writeFallback(contents []byte) {
+	if r.fallbackPath == "" {
+		return
+	}
+
+	tmpPath := r.fallbackPath + ".tmp"
+	if err := r.fileSystem.WriteFile(tmpPath, contents); err != nil {
+		r.logger.Warn(logTag, "Unable to write fallback records file '%s': %s", tmpPath, err.Error())
+		return
+	}
+
+	if err := r.fileSystem.Rename(tmpPath, r.fallbackPath); err != nil {
+		r.logger.Warn(logTag, "Unable to replace fallback records file '%s': %s", r.fallbackPath, err.Error())
+	}
+}
@@ -0,0 +1,185 @@
+package records_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+	"github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bosh-dns/dns/server/records"
+	"bosh-dns/dns/server/records/recordsfakes"
+)
+
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = ' '
+	}
+
+	return len(p), nil
+}
+
+var _ = Describe("HTTPFileReader", func() {
+	const recordsURL = "https://records.example.com/records.json"
+
+	var (
+		shutdownChan   chan struct{}
+		fakeClient     *recordsfakes.FakeHTTPClient
+		fakeClock      *fakeclock.FakeClock
+		fakeLogger     *loggerfakes.FakeLogger
+		fakeFileSystem *fakes.FakeFileSystem
+	)
+
+	response := func(status int, body string, headers map[string]string) *http.Response {
+		header := http.Header{}
+		for name, value := range headers {
+			header.Set(name, value)
+		}
+
+		return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+	}
+
+	requestHeaders := func(call int) http.Header {
+		endpoint, customize := fakeClient.GetCustomizedArgsForCall(call)
+		Expect(endpoint).To(Equal(recordsURL))
+
+		request, err := http.NewRequest("GET", endpoint, nil)
+		Expect(err).NotTo(HaveOccurred())
+		customize(request)
+
+		return request.Header
+	}
+
+	newReader := func() records.FileReader {
+		return records.NewHTTPFileReader(recordsURL, fakeClient, 10*time.Second, "/fallback/records.json", fakeFileSystem, fakeClock, fakeLogger, shutdownChan)
+	}
+
+	BeforeEach(func() {
+		shutdownChan = make(chan struct{})
+		fakeClient = &recordsfakes.FakeHTTPClient{}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		fakeLogger = &loggerfakes.FakeLogger{}
+		fakeFileSystem = fakes.NewFakeFileSystem()
+	})
+
+	AfterEach(func() {
+		close(shutdownChan)
+	})
+
+	It("serves the fetched records and keeps a fallback copy", func() {
+		fakeClient.GetCustomizedReturns(response(http.StatusOK, `{"Version": 1}`, nil), nil)
+
+		reader := newReader()
+
+		contents, err := reader.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal(`{"Version": 1}`))
+
+		fallback, err := fakeFileSystem.ReadFileString("/fallback/records.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fallback).To(Equal(`{"Version": 1}`))
+		Expect(fakeFileSystem.FileExists("/fallback/records.json.tmp")).To(BeFalse())
+	})
+
+	It("serves the fallback copy until the URL can be reached", func() {
+		Expect(fakeFileSystem.WriteFileString("/fallback/records.json", `{"Version": 1}`)).To(Succeed())
+		fakeClient.GetCustomizedReturns(nil, errors.New("fake-connection-err"))
+
+		reader := newReader()
+
+		contents, err := reader.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal(`{"Version": 1}`))
+		Expect(fakeLogger.ErrorCallCount()).To(Equal(1))
+	})
+
+	It("returns an error until anything has been fetched", func() {
+		fakeClient.GetCustomizedReturns(response(http.StatusInternalServerError, "", nil), nil)
+
+		reader := newReader()
+
+		_, err := reader.Get()
+		Expect(err).To(MatchError("No records fetched from 'https://records.example.com/records.json' yet"))
+	})
+
+	It("does not accept responses beyond the maximum size", func() {
+		Expect(fakeFileSystem.WriteFileString("/fallback/records.json", `{"Version": 1}`)).To(Succeed())
+		fakeClient.GetCustomizedReturns(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(endlessReader{})}, nil)
+
+		reader := newReader()
+
+		contents, err := reader.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal(`{"Version": 1}`))
+		Expect(fakeLogger.ErrorCallCount()).To(Equal(1))
+		_, _, args := fakeLogger.ErrorArgsForCall(0)
+		Expect(fmt.Sprint(args...)).To(ContainSubstring(fmt.Sprintf("Records exceed the maximum size of %d bytes", records.MaxRecordsSize)))
+	})
+
+	It("makes conditional requests and notifies subscribers only about new contents", func() {
+		fakeClient.GetCustomizedReturnsOnCall(0, response(http.StatusOK, `{"Version": 1}`, map[string]string{
+			"ETag":          `"v1"`,
+			"Last-Modified": "Mon, 19 Oct 2026 10:00:00 GMT",
+		}), nil)
+		fakeClient.GetCustomizedReturnsOnCall(1, response(http.StatusNotModified, "", nil), nil)
+		fakeClient.GetCustomizedReturnsOnCall(2, response(http.StatusOK, `{"Version": 2}`, map[string]string{"ETag": `"v2"`}), nil)
+
+		reader := newReader()
+		subscription := reader.Subscribe()
+
+		Expect(requestHeaders(0).Get("If-None-Match")).To(BeEmpty())
+
+		fakeClock.WaitForWatcherAndIncrement(10 * time.Second)
+		Eventually(fakeClient.GetCustomizedCallCount).Should(Equal(2))
+		Expect(requestHeaders(1).Get("If-None-Match")).To(Equal(`"v1"`))
+		Expect(requestHeaders(1).Get("If-Modified-Since")).To(Equal("Mon, 19 Oct 2026 10:00:00 GMT"))
+		Consistently(subscription).ShouldNot(Receive())
+
+		fakeClock.WaitForWatcherAndIncrement(10 * time.Second)
+		Eventually(subscription).Should(Receive(BeTrue()))
+		Expect(requestHeaders(2).Get("If-None-Match")).To(Equal(`"v1"`))
+
+		contents, err := reader.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal(`{"Version": 2}`))
+	})
+
+	It("backs off after failed requests", func() {
+		fakeClient.GetCustomizedReturns(nil, errors.New("fake-connection-err"))
+
+		newReader()
+		Expect(fakeClient.GetCustomizedCallCount()).To(Equal(1))
+
+		fakeClock.WaitForWatcherAndIncrement(10 * time.Second)
+		Consistently(fakeClient.GetCustomizedCallCount).Should(Equal(1))
+
+		fakeClock.Increment(10 * time.Second)
+		Eventually(fakeClient.GetCustomizedCallCount).Should(Equal(2))
+
+		fakeClient.GetCustomizedReturns(response(http.StatusOK, `{"Version": 1}`, nil), nil)
+		fakeClock.WaitForWatcherAndIncrement(40 * time.Second)
+		Eventually(fakeClient.GetCustomizedCallCount).Should(Equal(3))
+
+		fakeClock.WaitForWatcherAndIncrement(10 * time.Second)
+		Eventually(fakeClient.GetCustomizedCallCount).Should(Equal(4))
+	})
+
+	It("closes subscriptions on shutdown", func() {
+		fakeClient.GetCustomizedReturns(response(http.StatusNotModified, "", nil), nil)
+
+		subscription := newReader().Subscribe()
+		close(shutdownChan)
+		shutdownChan = make(chan struct{})
+
+		Eventually(subscription).Should(BeClosed())
+	})
+})
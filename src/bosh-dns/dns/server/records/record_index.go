@@ -0,0 +1,161 @@
+package records
+
+import (
+	"sort"
+
+	"bosh-dns/dns/server/criteria"
+	"bosh-dns/dns/server/record"
+)
+
+// recordIndex maps the values that queries select on to the positions of the
+// records and hosts holding them. Positions are ascending, so records taken
+// from the index keep the order of the records file.
+type recordIndex struct {
+	byDomain     map[string][]int
+	byGroupID    map[string][]int
+	byGroup      map[string][]int
+	byDeployment map[string][]int
+	byNetwork    map[string][]int
+	byIP         map[string][]int
+	byAgentID    map[string][]int
+	hostsByIP    map[string][]int
+}
+
+func newRecordIndex(records []record.Record, hosts []record.Host) *recordIndex {
+	idx := &recordIndex{
+		byDomain:     map[string][]int{},
+		byGroupID:    map[string][]int{},
+		byGroup:      map[string][]int{},
+		byDeployment: map[string][]int{},
+		byNetwork:    map[string][]int{},
+		byIP:         map[string][]int{},
+		byAgentID:    map[string][]int{},
+		hostsByIP:    map[string][]int{},
+	}
+
+	for i, rec := range records {
+		idx.byDomain[rec.Domain] = append(idx.byDomain[rec.Domain], i)
+		idx.byGroup[rec.Group] = append(idx.byGroup[rec.Group], i)
+		idx.byDeployment[rec.Deployment] = append(idx.byDeployment[rec.Deployment], i)
+		idx.byNetwork[rec.Network] = append(idx.byNetwork[rec.Network], i)
+		idx.byIP[rec.IP] = append(idx.byIP[rec.IP], i)
+
+		if rec.AgentID != "" {
+			idx.byAgentID[rec.AgentID] = append(idx.byAgentID[rec.AgentID], i)
+		}
+
+		for j, groupID := range rec.GroupIDs {
+			if containsString(rec.GroupIDs[:j], groupID) {
+				continue
+			}
+			idx.byGroupID[groupID] = append(idx.byGroupID[groupID], i)
+		}
+	}
+
+	for i, host := range hosts {
+		idx.hostsByIP[host.IP] = append(idx.hostsByIP[host.IP], i)
+	}
+
+	return idx
+}
+
+// candidates returns the records that can match the criteria, narrowed down
+// by the most selective indexed field. The criteria still have to be matched
+// against them; criteria without an indexed field return all records.
+func (idx *recordIndex) candidates(crit criteria.Criteria, records []record.Record) []record.Record {
+	indexedFields := []struct {
+		key   string
+		index map[string][]int
+	}{
+		{"agentID", idx.byAgentID},
+		{"g", idx.byGroupID},
+		{"instanceGroupName", idx.byGroup},
+		{"deployment", idx.byDeployment},
+		{"network", idx.byNetwork},
+		{"domain", idx.byDomain},
+	}
+
+	var (
+		positions []int
+		indexed   bool
+	)
+
+	for _, field := range indexedFields {
+		values := crit[field.key]
+		if len(values) == 0 || containsGlob(values) {
+			continue
+		}
+
+		fieldPositions := field.index[values[0]]
+		for _, value := range values[1:] {
+			fieldPositions = unionPositions(fieldPositions, field.index[value])
+		}
+
+		if !indexed || len(fieldPositions) < len(positions) {
+			positions = fieldPositions
+			indexed = true
+		}
+	}
+
+	if !indexed {
+		return records
+	}
+
+	candidates := make([]record.Record, len(positions))
+	for i, position := range positions {
+		candidates[i] = records[position]
+	}
+
+	return candidates
+}
+
+func (idx *recordIndex) hasIP(ip string) bool {
+	return len(idx.byIP[ip]) > 0
+}
+
+func (idx *recordIndex) hosts(ip string, hosts []record.Host) []record.Host {
+	positions := idx.hostsByIP[ip]
+
+	ipHosts := make([]record.Host, len(positions))
+	for i, position := range positions {
+		ipHosts[i] = hosts[position]
+	}
+
+	return ipHosts
+}
+
+func unionPositions(a, b []int) []int {
+	union := make([]int, 0, len(a)+len(b))
+	union = append(union, a...)
+	union = append(union, b...)
+	sort.Ints(union)
+
+	unique := union[:0]
+	for i, position := range union {
+		if i == 0 || position != union[i-1] {
+			unique = append(unique, position)
+		}
+	}
+
+	return unique
+}
+
+func containsGlob(values []string) bool {
+	for _, value := range values {
+		if criteria.IsGlob(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
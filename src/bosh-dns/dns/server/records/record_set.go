@@ -3,13 +3,14 @@ package records
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 
 import (
-	"encoding/json"
 	"errors"
 	"net"
-	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/miekg/dns"
 
@@ -21,13 +22,30 @@ import (
 )
 
 type AliasDefinition struct {
-	GroupID            string `json:"group_id"`
-	RootDomain         string `json:"root_domain"`
-	PlaceholderType    string `json:"placeholder_type"`
-	HealthFilter       string `json:"health_filter"`
-	InitialHealthCheck string `json:"initial_health_check"`
+	GroupID            string         `json:"group_id"`
+	RootDomain         string         `json:"root_domain"`
+	PlaceholderType    string         `json:"placeholder_type"`
+	HealthFilter       string         `json:"health_filter"`
+	InitialHealthCheck string         `json:"initial_health_check"`
+	Bootstrap          bool           `json:"bootstrap"`
+	Lifecycle          string         `json:"lifecycle"`
+	InstanceState      string         `json:"instance_state"`
+	Exclude            AliasExclusion `json:"exclude"`
 }
 
+// AliasExclusion lists the instances that an alias definition leaves out.
+type AliasExclusion struct {
+	AZIDs           []string `json:"az_ids"`
+	InstanceIndexes []string `json:"instance_indexes"`
+	Bootstrap       bool     `json:"bootstrap"`
+	Lifecycle       string   `json:"lifecycle"`
+	InstanceState   string   `json:"instance_state"`
+}
+
+// VersionHistoryLength is the number of applied records file versions that
+// are kept for inspection.
+const VersionHistoryLength = 10
+
 type recordGroup map[*record.Record]struct{} //nolint:unused
 
 var (
@@ -35,24 +53,38 @@ var (
 	DomainError   = errors.New("no records match requested domain") //nolint:staticcheck
 )
 
+// RecordSet answers queries from an immutable snapshot of the records that is
+// replaced as a whole whenever records or aliases change, so queries never
+// wait for an update.
 type RecordSet struct {
-	recordFileReader    FileReader
-	recordsMutex        sync.RWMutex
+	sources             []*sourceState
+	snapshot            atomic.Pointer[recordSnapshot]
+	updateMutex         sync.Mutex
 	subscriberssMutex   sync.RWMutex
 	subscribers         []chan bool
+	subscribersClosed   bool
 	logger              boshlog.Logger
+	clock               clock.Clock
 	aliasList           aliases.Config
-	mergedAliasList     aliases.Config
 	healthWatcher       healthiness.HealthWatcher
 	healthChan          chan record.Host
 	trackerSubscription chan []record.Record
 	filtererFactory     FiltererFactory
 	aliasQueryEncoder   AliasQueryEncoder
 
-	domains []string
-	records []record.Record
-	hosts   []record.Host
-	version uint64
+	history []record.BlobVersion
+}
+
+// recordSnapshot must not be modified once it has been stored in a RecordSet.
+type recordSnapshot struct {
+	domains         []string
+	records         []record.Record
+	hosts           []record.Host
+	index           *recordIndex
+	recordAliases   aliases.Config
+	mergedAliasList aliases.Config
+	conflicts       []record.Conflict
+	problems        []record.Problem
 }
 
 func NewRecordSet(
@@ -62,55 +94,110 @@ func NewRecordSet(
 	maximumTrackedDomains uint,
 	shutdownChan chan struct{},
 	logger boshlog.Logger,
+	clock clock.Clock,
+	filtererFactory FiltererFactory,
+	AliasQueryEncoder AliasQueryEncoder,
+) (*RecordSet, error) {
+	return NewMultiSourceRecordSet(
+		[]Source{{Name: "records", Reader: recordFileReader}},
+		aliasList,
+		healthWatcher,
+		maximumTrackedDomains,
+		shutdownChan,
+		logger,
+		clock,
+		filtererFactory,
+		AliasQueryEncoder,
+	)
+}
+
+// NewMultiSourceRecordSet serves the records of all sources as one set. See
+// Source for how records of different sources are merged.
+func NewMultiSourceRecordSet(
+	sources []Source,
+	aliasList aliases.Config,
+	healthWatcher healthiness.HealthWatcher,
+	maximumTrackedDomains uint,
+	shutdownChan chan struct{},
+	logger boshlog.Logger,
+	clock clock.Clock,
 	filtererFactory FiltererFactory,
 	AliasQueryEncoder AliasQueryEncoder,
 ) (*RecordSet, error) {
 	r := &RecordSet{
-		recordFileReader:    recordFileReader,
+		sources:             newSourceStates(sources),
 		logger:              logger,
+		clock:               clock,
 		aliasList:           aliasList,
 		aliasQueryEncoder:   AliasQueryEncoder,
-		mergedAliasList:     aliases.NewConfig().Merge(aliasList),
 		healthWatcher:       healthWatcher,
 		healthChan:          make(chan record.Host, 2),
-		trackerSubscription: make(chan []record.Record),
+		trackerSubscription: make(chan []record.Record, 1),
 		filtererFactory:     filtererFactory,
 	}
+	r.snapshot.Store(&recordSnapshot{
+		index:           newRecordIndex(nil, nil),
+		recordAliases:   aliases.NewConfig(),
+		mergedAliasList: aliases.NewConfig().Merge(aliasList),
+	})
 
 	trackedDomains := tracker.NewPriorityLimitedTranscript(maximumTrackedDomains)
 	tracker.Start(shutdownChan, r.trackerSubscription, r.healthChan, trackedDomains, healthWatcher, filtererFactory.NewQueryFilterer(), logger)
 
-	r.update()
+	for _, source := range r.sources {
+		r.apply(source, false) //nolint:errcheck
+	}
+
+	watchers := &sync.WaitGroup{}
+	for _, source := range r.sources {
+		watchers.Add(1)
+		go func(source *sourceState) {
+			defer watchers.Done()
 
-	go func() {
-		subscriptionChan := recordFileReader.Subscribe()
+			subscriptionChan := source.Reader.Subscribe()
 
-		defer func() {
-			r.subscriberssMutex.RLock()
-			for _, subscriber := range r.subscribers {
-				close(subscriber)
+			// A nil channel never delivers, so sources without a delta only
+			// wait for their records file.
+			var deltaSubscriptionChan <-chan bool
+			if source.DeltaReader != nil {
+				deltaSubscriptionChan = source.DeltaReader.Subscribe()
 			}
-			r.subscriberssMutex.RUnlock()
-		}()
-
-		for {
-			select {
-			case <-shutdownChan:
-				return
-			case ok := <-subscriptionChan:
-				if !ok {
+
+			for {
+				select {
+				case <-shutdownChan:
 					return
+				case ok := <-subscriptionChan:
+					if !ok {
+						return
+					}
+
+					if _, err := r.apply(source, false); err == nil {
+						r.notifySubscribers()
+					}
+				case ok := <-deltaSubscriptionChan:
+					if !ok {
+						deltaSubscriptionChan = nil
+						continue
+					}
+
+					if _, err := r.applyDelta(source); err == nil {
+						r.notifySubscribers()
+					}
 				}
+			}
+		}(source)
+	}
 
-				r.update()
+	go func() {
+		watchers.Wait()
 
-				r.subscriberssMutex.RLock()
-				for _, subscriber := range r.subscribers {
-					subscriber <- true
-				}
-				r.subscriberssMutex.RUnlock()
-			}
+		r.subscriberssMutex.Lock()
+		for _, subscriber := range r.subscribers {
+			close(subscriber)
 		}
+		r.subscribersClosed = true
+		r.subscriberssMutex.Unlock()
 	}()
 
 	return r, nil
@@ -119,19 +206,38 @@ func NewRecordSet(
 func (r *RecordSet) Subscribe() <-chan bool {
 	r.subscriberssMutex.Lock()
 	defer r.subscriberssMutex.Unlock()
-	c := make(chan bool)
+	// A single pending notification is enough for a subscriber to pick up
+	// the latest records.
+	c := make(chan bool, 1)
 	r.subscribers = append(r.subscribers, c)
 	return c
 }
 
+// notifySubscribers never blocks, so that a slow subscriber cannot hold up
+// updates of the records.
+func (r *RecordSet) notifySubscribers() {
+	r.subscriberssMutex.RLock()
+	defer r.subscriberssMutex.RUnlock()
+
+	if r.subscribersClosed {
+		return
+	}
+
+	for _, subscriber := range r.subscribers {
+		select {
+		case subscriber <- true:
+		default:
+		}
+	}
+}
+
 func (r *RecordSet) Resolve(fqdnRaw string) ([]string, error) {
-	r.recordsMutex.RLock()
-	defer r.recordsMutex.RUnlock()
+	snapshot := r.snapshot.Load()
 
 	var fqdn = strings.ToLower(fqdnRaw)
 	r.logger.Debug("RecordSet", "FQDN lower-cased from '%s' to '%s'", fqdnRaw, fqdn)
 
-	aliasExpansions := r.unsafeExpandAliases(fqdn)
+	aliasExpansions := snapshot.expandAliases(fqdn)
 	r.logger.Debug("RecordSet", "Expand %s to %v", fqdn, aliasExpansions)
 
 	aliasIPs := []string{}
@@ -141,7 +247,7 @@ func (r *RecordSet) Resolve(fqdnRaw string) ([]string, error) {
 		}
 	}
 
-	finalRecords, err := r.unsafeResolveRecords(aliasExpansions, true)
+	finalRecords, err := r.resolveRecords(snapshot, aliasExpansions, true)
 	if err != nil {
 		if !errors.Is(err, DomainError) || len(aliasIPs) == 0 {
 			return nil, err
@@ -157,23 +263,45 @@ func (r *RecordSet) Resolve(fqdnRaw string) ([]string, error) {
 	return finalIPs, nil
 }
 
-func (r *RecordSet) ResolveRecords(domains []string, shouldTrack bool) ([]record.Record, error) {
-	r.recordsMutex.RLock()
-	defer r.recordsMutex.RUnlock()
+// OrderedAnswers reports whether the answers for fqdn are ordered by health
+// and must not be shuffled, which is the case when every query it expands to
+// uses the s2 health strategy.
+func (r *RecordSet) OrderedAnswers(fqdnRaw string) bool {
+	snapshot := r.snapshot.Load()
+
+	ordered := false
+	for _, expansion := range snapshot.expandAliases(strings.ToLower(fqdnRaw)) {
+		if net.ParseIP(expansion) != nil {
+			continue
+		}
+
+		crit, err := criteria.NewCriteria(expansion, snapshot.domains)
+		if err != nil || len(crit["s"]) == 0 || crit["s"][0] != "2" {
+			return false
+		}
+		ordered = true
+	}
 
-	return r.unsafeResolveRecords(domains, shouldTrack)
+	return ordered
 }
 
-func (r *RecordSet) unsafeResolveRecords(domains []string, shouldTrack bool) ([]record.Record, error) {
+func (r *RecordSet) ResolveRecords(domains []string, shouldTrack bool) ([]record.Record, error) {
+	return r.resolveRecords(r.snapshot.Load(), domains, shouldTrack)
+}
+
+func (r *RecordSet) resolveRecords(snapshot *recordSnapshot, domains []string, shouldTrack bool) ([]record.Record, error) {
 	domainFilter := r.filtererFactory.NewQueryFilterer()
 	healthFilter := r.filtererFactory.NewHealthFilterer(r.healthChan, shouldTrack)
 
-	allCriteria, err := r.parseCriteria(domains)
+	allCriteria, err := parseCriteria(domains, snapshot.domains)
 	if err != nil {
 		r.logger.Debug("RecordSet", "Error parsing domains %v: %v", domains, err)
 		return nil, CriteriaError
 	}
-	domainRecords := r.filterRecords(domainFilter, allCriteria, r.records)
+	domainRecords := []record.Record{}
+	for _, crit := range allCriteria {
+		domainRecords = append(domainRecords, domainFilter.Filter(crit, snapshot.index.candidates(crit, snapshot.records))...)
+	}
 	if len(domainRecords) == 0 {
 		r.logger.Debug("RecordSet", "No records match domains %v", domains)
 		return nil, DomainError
@@ -188,25 +316,22 @@ func (r *RecordSet) unsafeResolveRecords(domains []string, shouldTrack bool) ([]
 }
 
 func (r *RecordSet) ExpandAliases(fqdn string) []string {
-	r.recordsMutex.RLock()
-	defer r.recordsMutex.RUnlock()
-
-	return r.unsafeExpandAliases(fqdn)
+	return r.snapshot.Load().expandAliases(fqdn)
 }
 
-func (r *RecordSet) unsafeExpandAliases(fqdn string) []string {
-	resolutions := r.mergedAliasList.Resolutions(fqdn)
+func (s *recordSnapshot) expandAliases(fqdn string) []string {
+	resolutions := s.mergedAliasList.Resolutions(fqdn)
 	if len(resolutions) == 0 {
 		resolutions = []string{fqdn}
 	}
 	return resolutions
 }
 
-func (r *RecordSet) parseCriteria(resolutions []string) ([]criteria.Criteria, error) {
+func parseCriteria(resolutions []string, domains []string) ([]criteria.Criteria, error) {
 	crits := []criteria.Criteria{}
 
 	for _, resolution := range resolutions {
-		crit, err := criteria.NewCriteria(resolution, r.domains)
+		crit, err := criteria.NewCriteria(resolution, domains)
 		if err != nil {
 			return nil, err
 		} else {
@@ -228,40 +353,26 @@ func (r *RecordSet) filterRecords(filterer Filterer, filterCriteria []criteria.C
 }
 
 func (r *RecordSet) AllRecords() []record.Record {
-	r.recordsMutex.RLock()
-	defer r.recordsMutex.RUnlock()
-	return r.records
+	return r.snapshot.Load().records
 }
 
 func (r *RecordSet) HasIP(ip string) bool {
-	r.recordsMutex.RLock()
-	defer r.recordsMutex.RUnlock()
-
-	for _, r := range r.records {
-		if r.IP == ip {
-			return true
-		}
-	}
-	return false
+	return r.snapshot.Load().index.hasIP(ip)
 }
 
 func (r *RecordSet) GetFQDNs(ip string) []string {
-	r.recordsMutex.RLock()
-	defer r.recordsMutex.RUnlock()
+	snapshot := r.snapshot.Load()
 
 	uniqueFqnds := make(map[string]bool)
-	for _, alias := range r.mergedAliasList.AliasResolutions(ip) {
+	for _, alias := range snapshot.mergedAliasList.AliasResolutions(ip) {
 		uniqueFqnds[alias] = true
 	}
 
-	for _, host := range r.hosts {
-		if host.IP == ip {
-			domain := dns.Fqdn(host.FQDN)
-			uniqueFqnds[domain] = true
-			for _, alias := range r.mergedAliasList.AliasResolutions(domain) {
-				uniqueFqnds[alias] = true
-			}
-
+	for _, host := range snapshot.index.hosts(ip, snapshot.hosts) {
+		domain := dns.Fqdn(host.FQDN)
+		uniqueFqnds[domain] = true
+		for _, alias := range snapshot.mergedAliasList.AliasResolutions(domain) {
+			uniqueFqnds[alias] = true
 		}
 	}
 	fqdns := []string{}
@@ -273,248 +384,349 @@ func (r *RecordSet) GetFQDNs(ip string) []string {
 }
 
 func (r *RecordSet) Domains() []string {
-	r.recordsMutex.RLock()
-	defer r.recordsMutex.RUnlock()
+	snapshot := r.snapshot.Load()
 
-	return append(r.domains, r.mergedAliasList.AliasHosts()...)
+	domains := make([]string, 0, len(snapshot.domains))
+	domains = append(domains, snapshot.domains...)
+	return append(domains, snapshot.mergedAliasList.AliasHosts()...)
 }
 
-func (r *RecordSet) update() {
-	contents, err := r.recordFileReader.Get()
-	if err != nil {
-		return
-	}
-	records, updatedAliases, hosts, version, err := createFromJSON(contents, r.logger, r.aliasQueryEncoder)
-	if err != nil {
-		return
-	}
+// UpdateAliases replaces the aliases that were loaded from the alias files.
+// Aliases defined by the records file are kept.
+func (r *RecordSet) UpdateAliases(aliasList aliases.Config) {
+	r.updateMutex.Lock()
+	defer r.updateMutex.Unlock()
 
-	r.recordsMutex.Lock()
-	defer r.recordsMutex.Unlock()
+	r.aliasList = aliasList
 
-	if r.version != version {
-		r.logger.Info("RecordSet", "DNS blob updated from %d to %d", r.version, version)
+	snapshot := *r.snapshot.Load()
+	snapshot.mergedAliasList = aliases.NewConfig().Merge(aliasList).Merge(snapshot.recordAliases)
+	r.snapshot.Store(&snapshot)
+}
+
+// History returns the records file versions that were applied, newest first.
+func (r *RecordSet) History() []record.BlobVersion {
+	r.updateMutex.Lock()
+	defer r.updateMutex.Unlock()
+
+	history := make([]record.BlobVersion, len(r.history))
+	for i, applied := range r.history {
+		history[len(r.history)-1-i] = applied
 	}
 
-	r.version = version
-	r.records = records
-	r.hosts = hosts
+	return history
+}
 
-	r.mergedAliasList = aliases.NewConfig().Merge(r.aliasList).Merge(updatedAliases)
+// Conflicts returns the domains and IPs that are provided by more than one
+// records source.
+func (r *RecordSet) Conflicts() []record.Conflict {
+	return r.snapshot.Load().conflicts
+}
 
-	r.trackerSubscription <- records
+// Validation returns the problems found in the applied records files.
+func (r *RecordSet) Validation() []record.Problem {
+	return r.snapshot.Load().problems
+}
 
-	domains := make(map[string]struct{})
-	for _, recordSetRecord := range r.records {
-		domains[recordSetRecord.Domain] = struct{}{}
+// AcceptRecords applies the current records files even when their version is
+// older than the applied one. Operators use it to deliberately roll back.
+// Nothing is applied unless the records files of all sources can be read.
+func (r *RecordSet) AcceptRecords() ([]record.BlobVersion, error) {
+	prepared := make([]preparedRecords, len(r.sources))
+	for i, source := range r.sources {
+		var err error
+		prepared[i], err = r.prepare(source)
+		if err != nil {
+			return nil, err
+		}
 	}
-	r.domains = make([]string, len(domains))
-	i := 0
-	for domain := range domains {
-		r.domains[i] = domain
-		i++
+
+	applied := []record.BlobVersion{}
+
+	r.updateMutex.Lock()
+	for i, source := range r.sources {
+		if err := r.unsafeApply(source, prepared[i], true); err != nil {
+			r.updateMutex.Unlock()
+			return nil, err
+		}
+
+		applied = append(applied, *source.applied)
 	}
+	r.unsafeMerge()
+	r.updateMutex.Unlock()
+
+	if len(applied) > 0 {
+		r.notifySubscribers()
+	}
+
+	return applied, nil
 }
 
-//counterfeiter:generate . AliasQueryEncoder
-type AliasQueryEncoder interface {
-	EncodeAliasesIntoQueries([]record.Record, map[string][]AliasDefinition) map[string][]string
+// preparedRecords are the records file of a source, read and ready to be
+// applied.
+type preparedRecords struct {
+	blob         recordsBlob
+	delta        *recordsBlob
+	records      []record.Record
+	hosts        []record.Host
+	aliasQueries map[string][]string
+	aliases      aliases.Config
 }
 
-func createFromJSON(j []byte, logger boshlog.Logger, aliasEncoder AliasQueryEncoder) ([]record.Record, aliases.Config, []record.Host, uint64, error) {
-	swap := struct {
-		Keys    []string                     `json:"record_keys"`
-		Infos   [][]interface{}              `json:"record_infos"`
-		Aliases map[string][]AliasDefinition `json:"aliases"`
-		Version uint64                       `json:"Version"`
-		Records [][2]string                  `json:"records"` // ip -> domain
-	}{}
+func (r *RecordSet) prepare(source *sourceState) (preparedRecords, error) {
+	blob, err := readRecordsBlob(source.Reader, r.logger)
+	if err != nil {
+		return preparedRecords{}, bosherr.WrapErrorf(err, "Applying records from %s", source.Name)
+	}
+	if blob.isDelta() {
+		return preparedRecords{}, bosherr.Errorf("Records from %s are a delta, a complete records file is expected", source.Name)
+	}
+
+	// A missing or unusable delta leaves the complete records file in effect.
+	var delta *recordsBlob
+	if source.DeltaReader != nil {
+		delta, _ = r.readDelta(source) //nolint:errcheck
+	}
 
-	err := json.Unmarshal(j, &swap)
+	records, hosts := source.inScope(blob.Records, blob.Hosts, r.logger)
+	aliasQueries, updatedAliases, err := r.encodeAliases(records, blob.AliasDefinitions)
 	if err != nil {
-		logger.Warn("RecordSet", "Unable to parse records file. Error: %v", err)
-		return nil, aliases.NewConfig(), nil, 0, err
-	}
-	logger.Debug("RecordSet", "Read DNS blob version %d", swap.Version)
-
-	records := make([]record.Record, 0, len(swap.Infos))
-	hosts := make([]record.Host, 0, len(swap.Records))
-
-	idIndex := -1
-	numIDIndex := -1
-	groupIndex := -1
-	networkIndex := -1
-	networkIDIndex := -1
-	deploymentIndex := -1
-	ipIndex := -1
-	domainIndex := -1
-	azIndex := -1
-	azIDIndex := -1
-	instanceIndexIndex := -1
-	groupIdsIndex := -1
-	agentIdIndex := -1
-
-	for i, k := range swap.Keys {
-		switch k {
-		case "id":
-			idIndex = i
-		case "num_id":
-			numIDIndex = i
-		case "instance_group":
-			groupIndex = i
-		case "group_ids":
-			groupIdsIndex = i
-		case "network":
-			networkIndex = i
-		case "network_id":
-			networkIDIndex = i
-		case "deployment":
-			deploymentIndex = i
-		case "ip":
-			ipIndex = i
-		case "domain":
-			domainIndex = i
-		case "az":
-			azIndex = i
-		case "az_id":
-			azIDIndex = i
-		case "instance_index":
-			instanceIndexIndex = i
-		case "agent_id":
-			agentIdIndex = i
-		default:
-			continue
-		}
+		return preparedRecords{}, bosherr.WrapErrorf(err, "Applying aliases from %s", source.Name)
 	}
 
-	countKeys := len(swap.Keys)
+	return preparedRecords{
+		blob:         blob,
+		delta:        delta,
+		records:      records,
+		hosts:        hosts,
+		aliasQueries: aliasQueries,
+		aliases:      updatedAliases,
+	}, nil
+}
 
-	for index, info := range swap.Infos {
-		countInfo := len(info)
-		if countInfo != countKeys {
-			logger.Warn("RecordSet", "Unbalanced records structure. Found %d fields of an expected %d at record #%d", countInfo, countKeys, index)
-			continue
-		}
+func (r *RecordSet) apply(source *sourceState, force bool) (record.BlobVersion, error) {
+	prepared, err := r.prepare(source)
+	if err != nil {
+		return record.BlobVersion{}, err
+	}
 
-		var domainIndexStr string
-		if !requiredStringValue(&domainIndexStr, info, domainIndex, "domain", index, logger) {
-			continue
-		}
+	r.updateMutex.Lock()
+	defer r.updateMutex.Unlock()
 
-		domain := dns.Fqdn(domainIndexStr)
+	if err := r.unsafeApply(source, prepared, force); err != nil {
+		return record.BlobVersion{}, err
+	}
 
-		newRecord := record.Record{Domain: domain}
+	r.unsafeMerge()
 
-		if !requiredStringValue(&newRecord.ID, info, idIndex, "id", index, logger) {
-			continue
-		} else if !requiredStringValue(&newRecord.Group, info, groupIndex, "group", index, logger) {
-			continue
-		} else if !requiredStringValue(&newRecord.Network, info, networkIndex, "network", index, logger) {
-			continue
-		} else if !requiredStringValue(&newRecord.Deployment, info, deploymentIndex, "deployment", index, logger) {
-			continue
-		} else if !requiredStringValue(&newRecord.IP, info, ipIndex, "ip", index, logger) {
-			continue
-		} else if !optionalStringValue(&newRecord.AZ, info, azIndex, "az", index, logger) {
-			continue
-		} else if !optionalStringValue(&newRecord.AZID, info, azIDIndex, "az_id", index, logger) {
-			continue
-		} else if !optionalStringValue(&newRecord.NetworkID, info, networkIDIndex, "network_id", index, logger) {
-			continue
-		} else if !optionalStringValue(&newRecord.NumID, info, numIDIndex, "num_id", index, logger) {
-			continue
-		} else if !optionalStringValue(&newRecord.AgentID, info, agentIdIndex, "agent_id", index, logger) {
-			continue
-		} else if groupIdsIndex >= 0 && !assertStringArrayOfStringValue(&newRecord.GroupIDs, info, groupIdsIndex, "group_ids", index, logger) {
-			continue
-		}
+	return *source.applied, nil
+}
+
+// unsafeApply replaces the records of the source, the merged records are left
+// to the caller to update.
+func (r *RecordSet) unsafeApply(source *sourceState, prepared preparedRecords, force bool) error {
+	blob := prepared.blob
 
-		assertStringIntegerValue(&newRecord.InstanceIndex, info, instanceIndexIndex, "instance_index", index, logger)
+	if blob.Version < source.version && !force {
+		r.logger.Warn("RecordSet", "Refusing DNS blob version %d from %s, version %d is already applied", blob.Version, source.Name, source.version)
+		return bosherr.Errorf("Records version %d from %s is older than the applied version %d", blob.Version, source.Name, source.version)
+	}
 
-		records = append(records, newRecord)
+	if source.version != blob.Version {
+		r.logger.Info("RecordSet", "DNS blob from %s updated from %d to %d", source.Name, source.version, blob.Version)
 	}
 
-	var updatedAliases aliases.Config
-	aliasesToConfigure := aliasEncoder.EncodeAliasesIntoQueries(records, swap.Aliases)
-	if updatedAliases, err = aliases.NewConfigFromMap(aliasesToConfigure); err != nil {
-		logger.Warn("RecordSet", "Unable to configure aliases from records. Error: %v", err)
-		// TODO: return records?
-		return nil, aliases.NewConfig(), nil, 0, err
+	if source.applied == nil || source.version != blob.Version || force {
+		r.unsafeAppendHistory(source, blob.Version, prepared.records, force)
 	}
 
-	for _, hostArr := range swap.Records {
-		hosts = append(hosts, record.Host{
-			IP:   hostArr[0],
-			FQDN: hostArr[1],
-		})
+	source.version = blob.Version
+	source.records = prepared.records
+	source.hosts = prepared.hosts
+	source.aliasDefinitions = blob.AliasDefinitions
+	source.aliasQueries = prepared.aliasQueries
+	source.aliases = prepared.aliases
+	source.problems = blob.Problems
+	source.deltaProblems = nil
+
+	if prepared.delta != nil && *prepared.delta.BaseVersion == source.version {
+		r.unsafeApplyDelta(source, *prepared.delta) //nolint:errcheck
 	}
 
-	return records, updatedAliases, hosts, swap.Version, nil
+	return nil
 }
 
-func assertStringIntegerValue(field *string, info []interface{}, fieldIdx int, fieldName string, infoIdx int, logger boshlog.Logger) bool {
-	if fieldIdx < 0 {
-		return false
+// applyDelta applies the delta of a source in place. A delta that is not
+// based on the applied version makes the complete records file be applied
+// again, together with the delta if it is based on that file.
+func (r *RecordSet) applyDelta(source *sourceState) (record.BlobVersion, error) {
+	delta, err := r.readDelta(source)
+	if err != nil {
+		return record.BlobVersion{}, err
+	}
+
+	r.updateMutex.Lock()
+
+	if delta.Version <= source.version {
+		r.updateMutex.Unlock()
+		return record.BlobVersion{}, bosherr.Errorf("Records delta version %d from %s is not newer than the applied version %d", delta.Version, source.Name, source.version)
 	}
 
-	float64Value, ok := info[fieldIdx].(float64) // golang default type for numeric fields
-	if !ok {
-		logger.Warn("RecordSet", "Value %d (%s) of record %d is not expected type of %s: %#+v", fieldIdx, fieldName, infoIdx, "numeric", info[fieldIdx])
+	if *delta.BaseVersion != source.version {
+		r.logger.Info("RecordSet", "Records delta version %d from %s is based on version %d but version %d is applied, applying the complete records file", delta.Version, source.Name, *delta.BaseVersion, source.version)
+		r.updateMutex.Unlock()
+		return r.apply(source, false)
 	}
 
-	*field = strconv.Itoa(int(float64Value))
-	return ok
+	defer r.updateMutex.Unlock()
+
+	if err := r.unsafeApplyDelta(source, *delta); err != nil {
+		return record.BlobVersion{}, err
+	}
+
+	r.unsafeMerge()
+
+	return *source.applied, nil
 }
 
-func convertToStringValue(field *string, info []interface{}, fieldIdx int, fieldName string, infoIdx int, logger boshlog.Logger) bool {
-	var ok bool
-	*field, ok = info[fieldIdx].(string)
+func (r *RecordSet) readDelta(source *sourceState) (*recordsBlob, error) {
+	delta, err := readRecordsBlob(source.DeltaReader, r.logger)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Applying records delta to %s", source.Name)
+	}
+
+	if !delta.isDelta() {
+		return nil, bosherr.Errorf("Records delta for %s has no base_version", source.Name)
+	}
+
+	return &delta, nil
+}
+
+func (r *RecordSet) unsafeApplyDelta(source *sourceState, delta recordsBlob) error {
+	delta.Records, delta.Hosts = source.inScope(delta.Records, delta.Hosts, r.logger)
+	records, hosts, aliasDefinitions := applyDelta(source, delta)
+
+	aliasQueries, updatedAliases, err := r.encodeAliases(records, aliasDefinitions)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Applying aliases from %s", source.Name)
+	}
+
+	r.logger.Info("RecordSet", "DNS blob from %s updated from %d to %d by a delta", source.Name, source.version, delta.Version)
+	r.unsafeAppendHistory(source, delta.Version, records, false)
 
-	if !ok {
-		logger.Warn("RecordSet", "Value %d (%s) of record %d is not expected type of %s: %#+v", fieldIdx, fieldName, infoIdx, "string", info[fieldIdx])
+	source.version = delta.Version
+	source.records = records
+	source.hosts = hosts
+	source.aliasDefinitions = aliasDefinitions
+	source.aliasQueries = aliasQueries
+	source.aliases = updatedAliases
+	source.deltaProblems = delta.Problems
+
+	return nil
+}
+
+func (r *RecordSet) encodeAliases(records []record.Record, aliasDefinitions map[string][]AliasDefinition) (map[string][]string, aliases.Config, error) {
+	aliasQueries := r.aliasQueryEncoder.EncodeAliasesIntoQueries(records, aliasDefinitions)
+	updatedAliases, err := aliases.NewConfigFromMap(aliasQueries)
+	if err != nil {
+		r.logger.Warn("RecordSet", "Unable to configure aliases from records. Error: %v", err)
+		return nil, aliases.NewConfig(), err
 	}
 
-	return ok
+	return aliasQueries, updatedAliases, nil
 }
 
-func optionalStringValue(field *string, info []interface{}, fieldIdx int, fieldName string, infoIdx int, logger boshlog.Logger) bool {
-	if fieldIdx >= 0 {
-		if info[fieldIdx] == nil {
-			info[fieldIdx] = ""
-			return true
+func (r *RecordSet) unsafeMerge() {
+	merged := mergeSources(r.sources)
+	previous := r.snapshot.Load()
+
+	snapshot := &recordSnapshot{
+		records:         merged.records,
+		hosts:           merged.hosts,
+		index:           newRecordIndex(merged.records, merged.hosts),
+		recordAliases:   merged.aliases,
+		mergedAliasList: aliases.NewConfig().Merge(r.aliasList).Merge(merged.aliases),
+		conflicts:       merged.conflicts,
+	}
+
+	domains := make(map[string]struct{})
+	for _, recordSetRecord := range snapshot.records {
+		domains[recordSetRecord.Domain] = struct{}{}
+	}
+	snapshot.domains = make([]string, len(domains))
+	i := 0
+	for domain := range domains {
+		snapshot.domains[i] = domain
+		i++
+	}
+
+	snapshot.problems = validateSources(r.sources, snapshot)
+	if len(snapshot.problems) != len(previous.problems) {
+		r.logger.Info("RecordSet", "Records validation found %d problems", len(snapshot.problems))
+	}
+
+	for _, conflict := range merged.conflicts {
+		if !containsConflict(previous.conflicts, conflict) {
+			r.logger.Warn("RecordSet", "Records conflict: %s %s is provided by %s", conflict.Kind, conflict.Value, strings.Join(conflict.Sources, ", "))
 		}
-		return convertToStringValue(field, info, fieldIdx, fieldName, infoIdx, logger)
 	}
 
-	return true
+	r.snapshot.Store(snapshot)
+	r.unsafeUpdateTracker(snapshot.records)
 }
 
-func requiredStringValue(field *string, info []interface{}, fieldIdx int, fieldName string, infoIdx int, logger boshlog.Logger) bool {
-	if fieldIdx < 0 {
-		return false
+// unsafeUpdateTracker never blocks: the tracker only needs the latest records,
+// so an update it has not picked up yet is replaced.
+func (r *RecordSet) unsafeUpdateTracker(records []record.Record) {
+	select {
+	case <-r.trackerSubscription:
+	default:
 	}
 
-	return convertToStringValue(field, info, fieldIdx, fieldName, infoIdx, logger)
+	r.trackerSubscription <- records
+}
+
+type recordKey struct {
+	id, group, network, deployment, domain, ip string
+}
+
+func newRecordKey(rec record.Record) recordKey {
+	return recordKey{rec.ID, rec.Group, rec.Network, rec.Deployment, rec.Domain, rec.IP}
 }
 
-func assertStringArrayOfStringValue(field *[]string, info []interface{}, fieldIdx int, fieldName string, infoIdx int, logger boshlog.Logger) bool {
-	var ok bool
-	var intermediateField []interface{}
+func (r *RecordSet) unsafeAppendHistory(source *sourceState, version uint64, records []record.Record, forced bool) {
+	previous := make(map[recordKey]struct{}, len(source.records))
+	for _, rec := range source.records {
+		previous[newRecordKey(rec)] = struct{}{}
+	}
 
-	intermediateField, ok = info[fieldIdx].([]interface{})
-	if !ok {
-		logger.Warn("RecordSet", "Value %d (%s) of record %d is not expected type of %s: %#+v", fieldIdx, fieldName, infoIdx, "array of string", info[fieldIdx])
+	applied := record.BlobVersion{
+		Source:    source.Name,
+		Version:   version,
+		AppliedAt: r.clock.Now(),
+		Records:   len(records),
+		Forced:    forced,
 	}
-	out := make([]string, len(intermediateField))
-	for i, v := range intermediateField {
-		out[i], ok = v.(string)
-		if !ok {
-			logger.Warn("RecordSet", "Value %d (%s) of record %d is not expected type of %s: %#+v", fieldIdx, fieldName, infoIdx, "array of string", info[fieldIdx])
-			return ok
+
+	for _, rec := range records {
+		key := newRecordKey(rec)
+		if _, found := previous[key]; found {
+			delete(previous, key)
+			continue
 		}
+		applied.Added++
 	}
+	applied.Removed = len(previous)
+
+	source.applied = &applied
 
-	*field = out
+	r.history = append(r.history, applied)
+	if len(r.history) > VersionHistoryLength {
+		r.history = r.history[len(r.history)-VersionHistoryLength:]
+	}
+}
 
-	return ok
+//counterfeiter:generate . AliasQueryEncoder
+type AliasQueryEncoder interface {
+	EncodeAliasesIntoQueries([]record.Record, map[string][]AliasDefinition) map[string][]string
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -84,7 +85,7 @@ var _ = Describe("Record Set Performance", func() {
 
 		fileReader.GetReturns(jsonBytes, nil)
 
-		recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, filtererFactory, fakeAliasQueryEncoder)
+		recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeclock.NewFakeClock(time.Now()), filtererFactory, fakeAliasQueryEncoder)
 
 		Expect(err).ToNot(HaveOccurred())
 	})
@@ -1,12 +1,16 @@
 package records_test
 
 import (
+	"bytes"
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
+	"unsafe"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -39,6 +43,7 @@ var _ = Describe("RecordSet", func() {
 	var (
 		recordSet             *records.RecordSet
 		fakeLogger            *loggerfakes.FakeLogger
+		fakeClock             *fakeclock.FakeClock
 		fileReader            *recordsfakes.FakeFileReader
 		aliasList             aliases.Config
 		shutdownChan          chan struct{}
@@ -51,6 +56,7 @@ var _ = Describe("RecordSet", func() {
 
 	BeforeEach(func() {
 		fakeLogger = &loggerfakes.FakeLogger{}
+		fakeClock = fakeclock.NewFakeClock(time.Unix(1500000000, 0))
 		fileReader = &recordsfakes.FakeFileReader{}
 		fakeQueryFilterer = &recordsfakes.FakeFilterer{}
 		fakeHealthFilterer = &recordsfakes.FakeFilterer{}
@@ -86,7 +92,7 @@ var _ = Describe("RecordSet", func() {
 				fileReader.GetReturns(jsonBytes, nil)
 
 				var err error
-				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -138,7 +144,7 @@ var _ = Describe("RecordSet", func() {
 				fileReader.GetReturns(jsonBytes, nil)
 
 				var err error
-				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 
 				Expect(err).ToNot(HaveOccurred())
 			})
@@ -283,7 +289,7 @@ var _ = Describe("RecordSet", func() {
 			fileReader.GetReturns(jsonBytes, nil)
 
 			var err error
-			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 
 			Expect(err).ToNot(HaveOccurred())
 		})
@@ -315,7 +321,7 @@ var _ = Describe("RecordSet", func() {
 			fileReader.GetReturns(jsonBytes, nil)
 
 			var err error
-			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(recordSet.Domains()).To(ConsistOf("withadot.", "nodot.", "domain.", "alias1."))
@@ -341,7 +347,7 @@ var _ = Describe("RecordSet", func() {
 			fileReader.GetReturns(jsonBytes, nil)
 
 			var err error
-			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(recordSet.HasIP("123.123.123.123")).To(Equal(true))
@@ -377,7 +383,7 @@ var _ = Describe("RecordSet", func() {
 			fileReader.GetReturns(jsonBytes, nil)
 
 			var err error
-			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(recordSet.GetFQDNs("123.123.123.123")).To(ConsistOf("alias1.", "instance0.my-group.my-network.my-deployment.withadot.", "0.my-group.my-network.my-deployment.withadot."))
@@ -409,7 +415,7 @@ var _ = Describe("RecordSet", func() {
 			})
 
 			var err error
-			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 			Expect(err).ToNot(HaveOccurred())
 
 			_, err = recordSet.Resolve("instance0.my-group.my-network.my-deployment.bosh.")
@@ -555,6 +561,635 @@ var _ = Describe("RecordSet", func() {
 		})
 	})
 
+	Describe("updating records while the health tracker is busy", func() {
+		var (
+			subscriptionChan chan bool
+			trackBlocked     chan struct{}
+		)
+
+		recordsWithIPs := func(ips ...string) []byte {
+			infos := []string{}
+			for i, ip := range ips {
+				infos = append(infos, fmt.Sprintf(`["instance%d", "%d", "my-group", "az1", "1", "my-network", "1", "my-deployment", "%s", "bosh."]`, i, i, ip))
+			}
+
+			return []byte(fmt.Sprintf(`{
+				"record_keys": ["id", "num_id", "instance_group", "az", "az_id", "network", "network_id", "deployment", "ip", "domain"],
+				"record_infos": [%s]
+			}`, strings.Join(infos, ",")))
+		}
+
+		BeforeEach(func() {
+			subscriptionChan = make(chan bool, 1)
+			fileReader.SubscribeReturns(subscriptionChan)
+			fileReader.GetReturns(recordsWithIPs("10.0.0.1", "10.0.0.2"), nil)
+
+			trackBlocked = make(chan struct{})
+			fakeHealthWatcher.TrackStub = func(string) {
+				<-trackBlocked
+			}
+
+			var err error
+			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, records.NewHealthFiltererFactory(fakeHealthWatcher, time.Second), fakeAliasQueryEncoder)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = recordSet.Resolve("q-s0.my-group.my-network.my-deployment.bosh.")
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fakeHealthWatcher.TrackCallCount).Should(Equal(1))
+		})
+
+		AfterEach(func() {
+			close(trackBlocked)
+		})
+
+		It("keeps applying new records and answering queries", func() {
+			for i := 3; i <= 5; i++ {
+				ip := fmt.Sprintf("10.0.0.%d", i)
+				fileReader.GetReturns(recordsWithIPs(ip), nil)
+				subscriptionChan <- true
+
+				Eventually(func() bool { return recordSet.HasIP(ip) }).Should(BeTrue())
+			}
+
+			recs, err := recordSet.ResolveRecords([]string{"q-s0.my-group.my-network.my-deployment.bosh."}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recs).To(HaveLen(1))
+			Expect(recs[0].IP).To(Equal("10.0.0.5"))
+		})
+	})
+
+	Describe("versions", func() {
+		var (
+			subscriptionChan chan bool
+		)
+
+		blob := func(version int, ips ...string) []byte {
+			infos := []string{}
+			for _, ip := range ips {
+				infos = append(infos, fmt.Sprintf(`["instance-%s", "my-group", "my-network", "my-deployment", "%s", "bosh."]`, ip, ip))
+			}
+
+			return []byte(fmt.Sprintf(`{
+				"record_keys": ["id", "instance_group", "network", "deployment", "ip", "domain"],
+				"record_infos": [%s],
+				"Version": %d
+			}`, strings.Join(infos, ","), version))
+		}
+
+		ips := func() []string {
+			ips := []string{}
+			for _, r := range recordSet.AllRecords() {
+				ips = append(ips, r.IP)
+			}
+			return ips
+		}
+
+		BeforeEach(func() {
+			subscriptionChan = make(chan bool, 1)
+			fileReader.SubscribeReturns(subscriptionChan)
+			fileReader.GetReturns(blob(5, "1.1.1.1", "2.2.2.2"), nil)
+
+			var err error
+			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("records the initial version", func() {
+			Expect(recordSet.History()).To(Equal([]record.BlobVersion{
+				{Source: "records", Version: 5, AppliedAt: fakeClock.Now(), Records: 2, Added: 2},
+			}))
+		})
+
+		Context("when a newer version is written", func() {
+			BeforeEach(func() {
+				fakeClock.Increment(time.Minute)
+				fileReader.GetReturns(blob(6, "2.2.2.2", "3.3.3.3", "4.4.4.4"), nil)
+				subscriptionChan <- true
+			})
+
+			It("applies it and records what changed, newest first", func() {
+				Eventually(ips).Should(Equal([]string{"2.2.2.2", "3.3.3.3", "4.4.4.4"}))
+				Expect(recordSet.History()).To(Equal([]record.BlobVersion{
+					{Source: "records", Version: 6, AppliedAt: fakeClock.Now(), Records: 3, Added: 2, Removed: 1},
+					{Source: "records", Version: 5, AppliedAt: fakeClock.Now().Add(-time.Minute), Records: 2, Added: 2},
+				}))
+			})
+		})
+
+		Context("when an older version is written", func() {
+			var subscriber <-chan bool
+
+			BeforeEach(func() {
+				subscriber = recordSet.Subscribe()
+				fileReader.GetReturns(blob(4, "9.9.9.9"), nil)
+				subscriptionChan <- true
+			})
+
+			It("refuses it and keeps the applied records", func() {
+				Eventually(fakeLogger.WarnCallCount).Should(Equal(1))
+				_, msg, args := fakeLogger.WarnArgsForCall(0)
+				Expect(fmt.Sprintf(msg, args...)).To(Equal("Refusing DNS blob version 4 from records, version 5 is already applied"))
+
+				Expect(ips()).To(Equal([]string{"1.1.1.1", "2.2.2.2"}))
+				Expect(recordSet.History()).To(HaveLen(1))
+				Consistently(subscriber).ShouldNot(Receive())
+			})
+
+			Context("when an operator accepts it", func() {
+				BeforeEach(func() {
+					Eventually(fakeLogger.WarnCallCount).Should(Equal(1))
+				})
+
+				It("applies it anyway and notifies subscribers", func() {
+					applied, err := recordSet.AcceptRecords()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(applied).To(Equal([]record.BlobVersion{
+						{Source: "records", Version: 4, AppliedAt: fakeClock.Now(), Records: 1, Added: 1, Removed: 2, Forced: true},
+					}))
+
+					Expect(ips()).To(Equal([]string{"9.9.9.9"}))
+					Expect(recordSet.History()[0]).To(Equal(applied[0]))
+					Eventually(subscriber).Should(Receive(BeTrue()))
+				})
+			})
+		})
+
+		Context("when the records file cannot be read", func() {
+			It("does not accept anything", func() {
+				fileReader.GetReturns(nil, errors.New("no read"))
+
+				_, err := recordSet.AcceptRecords()
+				Expect(err).To(MatchError(ContainSubstring("no read")))
+				Expect(recordSet.History()).To(HaveLen(1))
+			})
+		})
+
+		It("keeps a limited number of versions", func() {
+			for version := 6; version < 6+records.VersionHistoryLength; version++ {
+				fileReader.GetReturns(blob(version, "1.1.1.1"), nil)
+				subscriptionChan <- true
+
+				Eventually(func() uint64 {
+					return recordSet.History()[0].Version
+				}).Should(Equal(uint64(version)))
+			}
+
+			Expect(recordSet.History()).To(HaveLen(records.VersionHistoryLength))
+			Expect(recordSet.History()[records.VersionHistoryLength-1].Version).To(Equal(uint64(6)))
+		})
+	})
+
+	Describe("records files", func() {
+		var (
+			deltaReader       *recordsfakes.FakeFileReader
+			deltaSubscription chan bool
+			subscriber        <-chan bool
+		)
+
+		blob := func(version int, ips ...string) []byte {
+			infos := []string{}
+			hosts := []string{}
+			for _, ip := range ips {
+				infos = append(infos, fmt.Sprintf(`["instance-%s", "my-group", "my-network", "my-deployment", "%s", "bosh."]`, ip[:1], ip))
+				hosts = append(hosts, fmt.Sprintf(`["%s", "instance-%s.bosh"]`, ip, ip[:1]))
+			}
+
+			return []byte(fmt.Sprintf(`{
+				"record_keys": ["id", "instance_group", "network", "deployment", "ip", "domain"],
+				"record_infos": [%s],
+				"records": [%s],
+				"Version": %d
+			}`, strings.Join(infos, ","), strings.Join(hosts, ","), version))
+		}
+
+		gzipped := func(contents []byte) []byte {
+			var compressed bytes.Buffer
+			writer := gzip.NewWriter(&compressed)
+			_, err := writer.Write(contents)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(writer.Close()).To(Succeed())
+
+			return compressed.Bytes()
+		}
+
+		ips := func() []string {
+			ips := []string{}
+			for _, r := range recordSet.AllRecords() {
+				ips = append(ips, r.IP)
+			}
+			return ips
+		}
+
+		BeforeEach(func() {
+			fileReader.SubscribeReturns(make(chan bool))
+			fileReader.GetReturns(blob(5, "1.1.1.1", "2.2.2.2"), nil)
+
+			deltaSubscription = make(chan bool, 1)
+			deltaReader = &recordsfakes.FakeFileReader{}
+			deltaReader.SubscribeReturns(deltaSubscription)
+			deltaReader.GetReturns(nil, errors.New("no delta yet"))
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			recordSet, err = records.NewMultiSourceRecordSet(
+				[]records.Source{{Name: "records", Reader: fileReader, DeltaReader: deltaReader}},
+				aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder,
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			subscriber = recordSet.Subscribe()
+		})
+
+		Context("when the records file is gzip compressed", func() {
+			BeforeEach(func() {
+				fileReader.GetReturns(gzipped(blob(5, "1.1.1.1", "2.2.2.2")), nil)
+			})
+
+			It("serves its records", func() {
+				Expect(ips()).To(Equal([]string{"1.1.1.1", "2.2.2.2"}))
+				Expect(recordSet.GetFQDNs("2.2.2.2")).To(Equal([]string{"instance-2.bosh."}))
+			})
+
+			It("does not accept records that decompress beyond the maximum size", func() {
+				var compressed bytes.Buffer
+				writer, err := gzip.NewWriterLevel(&compressed, gzip.BestSpeed)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = writer.Write([]byte(`{"Version": 6,`))
+				Expect(err).NotTo(HaveOccurred())
+				whitespace := bytes.Repeat([]byte(" "), 1<<20)
+				for written := 0; written <= records.MaxRecordsSize; written += len(whitespace) {
+					_, err = writer.Write(whitespace)
+					Expect(err).NotTo(HaveOccurred())
+				}
+				_, err = writer.Write([]byte(`"record_keys": []}`))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(writer.Close()).To(Succeed())
+
+				fileReader.GetReturns(compressed.Bytes(), nil)
+
+				_, err = recordSet.AcceptRecords()
+				Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("Records exceed the maximum size of %d bytes", records.MaxRecordsSize))))
+				Expect(recordSet.History()[0].Version).To(Equal(uint64(5)))
+			})
+		})
+
+		Context("when the records file is a delta", func() {
+			BeforeEach(func() {
+				fileReader.GetReturns([]byte(`{"Version": 5, "base_version": 4}`), nil)
+			})
+
+			It("refuses it", func() {
+				_, err := recordSet.AcceptRecords()
+				Expect(err).To(MatchError("Records from records are a delta, a complete records file is expected"))
+			})
+		})
+
+		Context("when a delta is written", func() {
+			BeforeEach(func() {
+				deltaReader.GetReturns(gzipped([]byte(`{
+					"record_keys": ["id", "instance_group", "network", "deployment", "ip", "domain"],
+					"record_infos": [
+						["instance-1", "my-group", "my-network", "my-deployment", "7.7.7.7", "bosh."],
+						["instance-3", "my-group", "my-network", "my-deployment", "3.3.3.3", "bosh."]
+					],
+					"records": [["7.7.7.7", "instance-1.bosh"]],
+					"removed_record_infos": [["instance-2", "my-group", "my-network", "my-deployment", "", "bosh."]],
+					"removed_records": [["1.1.1.1", "instance-1.bosh"], ["2.2.2.2", "instance-2.bosh"]],
+					"aliases": {"alias.bosh.": [{"root_domain": "bosh.", "group_id": "1"}]},
+					"base_version": 5,
+					"Version": 6
+				}`)), nil)
+			})
+
+			Context("when it is written after the record set started", func() {
+				BeforeEach(func() {
+					deltaReader.GetReturnsOnCall(0, nil, errors.New("no delta yet"))
+				})
+
+				It("adds, updates and removes records in place", func() {
+					deltaSubscription <- true
+
+					Eventually(subscriber).Should(Receive(BeTrue()))
+					Expect(ips()).To(Equal([]string{"7.7.7.7", "3.3.3.3"}))
+					Expect(recordSet.HasIP("2.2.2.2")).To(BeFalse())
+					Expect(recordSet.GetFQDNs("1.1.1.1")).To(BeEmpty())
+					Expect(recordSet.GetFQDNs("7.7.7.7")).To(Equal([]string{"instance-1.bosh."}))
+					Expect(recordSet.History()[0]).To(Equal(record.BlobVersion{
+						Source: "records", Version: 6, AppliedAt: fakeClock.Now(), Records: 2, Added: 2, Removed: 2,
+					}))
+
+					callCount := fakeAliasQueryEncoder.EncodeAliasesIntoQueriesCallCount()
+					_, aliasDefinitions := fakeAliasQueryEncoder.EncodeAliasesIntoQueriesArgsForCall(callCount - 1)
+					Expect(aliasDefinitions).To(Equal(map[string][]records.AliasDefinition{
+						"alias.bosh.": {{RootDomain: "bosh.", GroupID: "1"}},
+					}))
+				})
+			})
+
+			Context("when it is written before the records set starts", func() {
+				It("is applied on top of the records file", func() {
+					Expect(ips()).To(Equal([]string{"7.7.7.7", "3.3.3.3"}))
+					Expect(recordSet.History()).To(HaveLen(2))
+				})
+			})
+
+			Context("when it is based on another version", func() {
+				BeforeEach(func() {
+					deltaReader.GetReturnsOnCall(0, nil, errors.New("no delta yet"))
+					fileReader.GetReturnsOnCall(0, blob(4, "1.1.1.1", "2.2.2.2", "9.9.9.9"), nil)
+				})
+
+				It("applies the records file again with the delta", func() {
+					Expect(ips()).To(Equal([]string{"1.1.1.1", "2.2.2.2", "9.9.9.9"}))
+
+					deltaSubscription <- true
+
+					Eventually(ips).Should(Equal([]string{"7.7.7.7", "3.3.3.3"}))
+					Expect(fakeLogger.InfoCallCount()).To(BeNumerically(">", 0))
+					Expect(recordSet.History()[0].Version).To(Equal(uint64(6)))
+					Expect(recordSet.History()[1].Version).To(Equal(uint64(5)))
+				})
+			})
+
+			Context("when it is not newer than the applied version", func() {
+				It("ignores it", func() {
+					Expect(ips()).To(Equal([]string{"7.7.7.7", "3.3.3.3"}))
+
+					deltaSubscription <- true
+
+					Consistently(subscriber).ShouldNot(Receive())
+					Expect(recordSet.History()).To(HaveLen(2))
+				})
+			})
+		})
+	})
+
+	Describe("Validation", func() {
+		BeforeEach(func() {
+			fakeAliasQueryEncoder.EncodeAliasesIntoQueriesReturns(map[string][]string{
+				"good.bosh.":     {"q-s0.q-g1.bosh."},
+				"dangling.bosh.": {"q-s0.q-g9.bosh.", "q-s0.q-g8.bosh."},
+			})
+		})
+
+		It("reports problems of the records file", func() {
+			fileReader.GetReturns([]byte(`{
+				"record_keys": ["id", "instance_group", "group_ids", "network", "deployment", "ip", "domain"],
+				"record_infos": [
+					["instance0", "my-group", ["1"], "my-network", "my-deployment", "10.0.0.1", "bosh."],
+					["instance1", "my-group", ["1"], "my-network", "my-deployment", "10.0.0.1", "bosh."],
+					["instance2", "my-group", ["1"], "my-network", "my-deployment", "10.0.0.2"],
+					["instance3", "my-group", ["1"], "my-network", 7, "10.0.0.3", "bosh."],
+					["instance4", "my-group", ["1"], "my-network", "my-deployment", "", "bosh."],
+					["instance0", "other-group", ["2"], "my-network", "my-deployment", "10.0.0.5", "bosh."]
+				],
+				"aliases": {"good.bosh.": [], "dangling.bosh.": []}
+			}`), nil)
+
+			var err error
+			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(recordSet.Validation()).To(Equal([]record.Problem{
+				{Kind: record.UnbalancedRowProblem, Source: "records", Line: 6, Record: 2, Message: "Found 6 fields of an expected 7 at record #2"},
+				{Kind: record.InvalidValueProblem, Source: "records", Line: 7, Record: 3, Field: "deployment", Message: "Value 4 (deployment) is not expected type of string: 7"},
+				{Kind: record.MissingValueProblem, Source: "records", Line: 8, Record: 4, Field: "ip", Message: "Record 4 has an empty ip"},
+				{Kind: record.DuplicateIPProblem, Source: "records", Field: "ip", Message: "IP 10.0.0.1 is used by instances instance0, instance1"},
+				{Kind: record.DuplicateInstanceIDProblem, Source: "records", Field: "id", Message: "Instance instance0 has 2 records on network my-network in bosh."},
+				{Kind: record.DanglingAliasProblem, Source: "records", Field: "aliases", Message: "Alias dangling.bosh. does not match any records with q-s0.q-g9.bosh., q-s0.q-g8.bosh."},
+			}))
+		})
+
+		It("reports missing columns", func() {
+			fileReader.GetReturns([]byte(`{
+				"record_keys": ["id", "instance_group", "network", "deployment", "domain"],
+				"record_infos": [["instance0", "my-group", "my-network", "my-deployment", "bosh."]]
+			}`), nil)
+
+			var err error
+			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(recordSet.Validation()).To(ContainElement(record.Problem{
+				Kind: record.MissingColumnProblem, Source: "records", Field: "ip", Message: "record_keys does not include ip",
+			}))
+		})
+
+		It("has no problems for valid records", func() {
+			fileReader.GetReturns([]byte(`{
+				"record_keys": ["id", "instance_group", "group_ids", "network", "deployment", "ip", "domain"],
+				"record_infos": [
+					["instance0", "my-group", ["1"], "my-network", "my-deployment", "10.0.0.1", "bosh."],
+					["instance1", "my-group", ["1"], "my-network", "my-deployment", "10.0.0.2", "bosh."]
+				]
+			}`), nil)
+			fakeAliasQueryEncoder.EncodeAliasesIntoQueriesReturns(map[string][]string{"good.bosh.": {"q-s0.q-g1.bosh."}})
+
+			var err error
+			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(recordSet.Validation()).To(BeEmpty())
+		})
+	})
+
+	Describe("multiple sources", func() {
+		var (
+			primaryReader      *recordsfakes.FakeFileReader
+			otherReader        *recordsfakes.FakeFileReader
+			otherSubscription  chan bool
+			otherDomains       []string
+			otherPrecedence    int
+			primarySubscribers []<-chan bool
+		)
+
+		blob := func(version int, domain string, ips ...string) []byte {
+			infos := []string{}
+			for _, ip := range ips {
+				infos = append(infos, fmt.Sprintf(`["instance-%s", "my-group", "my-network", "my-deployment", "%s", "%s"]`, ip, ip, domain))
+			}
+
+			return []byte(fmt.Sprintf(`{
+				"record_keys": ["id", "instance_group", "network", "deployment", "ip", "domain"],
+				"record_infos": [%s],
+				"Version": %d
+			}`, strings.Join(infos, ","), version))
+		}
+
+		ips := func() []string {
+			ips := []string{}
+			for _, r := range recordSet.AllRecords() {
+				ips = append(ips, r.IP)
+			}
+			return ips
+		}
+
+		BeforeEach(func() {
+			primaryReader = &recordsfakes.FakeFileReader{}
+			primaryReader.SubscribeReturns(make(chan bool))
+			primaryReader.GetReturns(blob(10, "bosh", "10.0.0.1", "10.0.0.2"), nil)
+
+			otherSubscription = make(chan bool, 1)
+			otherReader = &recordsfakes.FakeFileReader{}
+			otherReader.SubscribeReturns(otherSubscription)
+			otherReader.GetReturns(blob(2, "other", "10.1.0.1"), nil)
+
+			otherDomains = nil
+			otherPrecedence = 0
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			recordSet, err = records.NewMultiSourceRecordSet(
+				[]records.Source{
+					{Name: "/primary.json", Reader: primaryReader},
+					{Name: "/other.json", Reader: otherReader, Domains: otherDomains, Precedence: otherPrecedence},
+				},
+				aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder,
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			primarySubscribers = []<-chan bool{recordSet.Subscribe()}
+		})
+
+		It("serves the records of all sources", func() {
+			Expect(ips()).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.1.0.1"}))
+			Expect(recordSet.Domains()).To(ConsistOf("bosh.", "other."))
+			Expect(recordSet.Conflicts()).To(BeEmpty())
+		})
+
+		It("keeps a version per source", func() {
+			history := recordSet.History()
+			Expect(history).To(HaveLen(2))
+			Expect(history[0].Source).To(Equal("/other.json"))
+			Expect(history[0].Version).To(Equal(uint64(2)))
+			Expect(history[1].Source).To(Equal("/primary.json"))
+			Expect(history[1].Version).To(Equal(uint64(10)))
+		})
+
+		It("applies updates of any source", func() {
+			otherReader.GetReturns(blob(3, "other", "10.1.0.1", "10.1.0.2"), nil)
+			otherSubscription <- true
+
+			Eventually(ips).Should(Equal([]string{"10.0.0.1", "10.0.0.2", "10.1.0.1", "10.1.0.2"}))
+			Eventually(primarySubscribers[0]).Should(Receive(BeTrue()))
+		})
+
+		It("does not block on subscribers that have not consumed earlier notifications", func() {
+			slow := recordSet.Subscribe()
+
+			for version := 3; version < 6; version++ {
+				ip := fmt.Sprintf("10.1.0.%d", version)
+				otherReader.GetReturns(blob(version, "other", ip), nil)
+				otherSubscription <- true
+
+				Eventually(ips).Should(ContainElement(ip))
+				Eventually(primarySubscribers[0]).Should(Receive(BeTrue()))
+			}
+
+			Expect(slow).To(Receive(BeTrue()))
+			Expect(slow).NotTo(Receive())
+		})
+
+		It("accepts the records of all sources", func() {
+			applied, err := recordSet.AcceptRecords()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(applied).To(HaveLen(2))
+			Expect(applied[0].Source).To(Equal("/primary.json"))
+			Expect(applied[1].Source).To(Equal("/other.json"))
+		})
+
+		It("accepts the records of no source when one of them cannot be read", func() {
+			primaryReader.GetReturns(blob(9, "bosh", "10.0.0.3"), nil)
+			otherReader.GetReturns(nil, errors.New("fake-read-err"))
+
+			applied, err := recordSet.AcceptRecords()
+			Expect(err).To(MatchError(ContainSubstring("fake-read-err")))
+			Expect(applied).To(BeEmpty())
+
+			Expect(ips()).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.1.0.1"}))
+			Expect(recordSet.History()).To(HaveLen(2))
+			Consistently(primarySubscribers[0]).ShouldNot(Receive())
+		})
+
+		Context("when a source is scoped to domains", func() {
+			BeforeEach(func() {
+				otherDomains = []string{"Other"}
+				otherReader.GetReturns([]byte(`{
+					"record_keys": ["id", "instance_group", "network", "deployment", "ip", "domain"],
+					"record_infos": [
+						["a", "my-group", "my-network", "my-deployment", "10.1.0.1", "other."],
+						["b", "my-group", "my-network", "my-deployment", "10.1.0.2", "bosh."]
+					],
+					"Version": 2
+				}`), nil)
+			})
+
+			It("ignores its records outside of those domains", func() {
+				Expect(ips()).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.1.0.1"}))
+
+				Expect(fakeLogger.WarnCallCount()).To(Equal(1))
+				_, msg, args := fakeLogger.WarnArgsForCall(0)
+				Expect(fmt.Sprintf(msg, args...)).To(Equal("Ignoring 1 records from /other.json outside of other."))
+			})
+		})
+
+		Context("when sources provide the same domain", func() {
+			BeforeEach(func() {
+				otherReader.GetReturns(blob(2, "bosh", "10.1.0.1"), nil)
+			})
+
+			It("uses the first source and reports the conflict", func() {
+				Expect(ips()).To(Equal([]string{"10.0.0.1", "10.0.0.2"}))
+				Expect(recordSet.Conflicts()).To(Equal([]record.Conflict{
+					{Kind: record.DomainConflict, Value: "bosh.", Sources: []string{"/primary.json", "/other.json"}},
+				}))
+
+				Expect(fakeLogger.WarnCallCount()).To(Equal(1))
+				_, msg, args := fakeLogger.WarnArgsForCall(0)
+				Expect(fmt.Sprintf(msg, args...)).To(Equal("Records conflict: domain bosh. is provided by /primary.json, /other.json"))
+			})
+
+			Context("when the other source has a higher precedence", func() {
+				BeforeEach(func() {
+					otherPrecedence = 1
+				})
+
+				It("uses the other source", func() {
+					Expect(ips()).To(Equal([]string{"10.1.0.1"}))
+					Expect(recordSet.Conflicts()).To(Equal([]record.Conflict{
+						{Kind: record.DomainConflict, Value: "bosh.", Sources: []string{"/other.json", "/primary.json"}},
+					}))
+				})
+			})
+
+			Context("when the conflict is resolved", func() {
+				It("no longer reports it", func() {
+					otherReader.GetReturns(blob(3, "other", "10.1.0.1"), nil)
+					otherSubscription <- true
+
+					Eventually(recordSet.Conflicts).Should(BeEmpty())
+					Expect(ips()).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.1.0.1"}))
+				})
+			})
+		})
+
+		Context("when sources use the same IP", func() {
+			BeforeEach(func() {
+				otherReader.GetReturns(blob(2, "other", "10.0.0.2"), nil)
+			})
+
+			It("serves both records and reports the conflict", func() {
+				Expect(ips()).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.0.0.2"}))
+				Expect(recordSet.Conflicts()).To(Equal([]record.Conflict{
+					{Kind: record.IPConflict, Value: "10.0.0.2", Sources: []string{"/primary.json", "/other.json"}},
+				}))
+			})
+		})
+	})
+
 	Context("when FileReader returns JSON", func() {
 		Context("the records json contains invalid info lines", func() {
 			DescribeTable("one of the info lines contains an object",
@@ -572,7 +1207,7 @@ var _ = Describe("RecordSet", func() {
 					fileReader.GetReturns(jsonBytes, nil)
 
 					var err error
-					recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+					recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 					Expect(err).ToNot(HaveOccurred())
 
 					_, err = recordSet.Resolve("q-s0.my-group.my-network.my-deployment.my-domain.")
@@ -619,7 +1254,7 @@ var _ = Describe("RecordSet", func() {
 					fileReader.GetReturns(jsonBytes, nil)
 
 					var err error
-					recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+					recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 
 					Expect(err).ToNot(HaveOccurred())
 				})
@@ -669,7 +1304,7 @@ var _ = Describe("RecordSet", func() {
 				fileReader.GetReturns(jsonBytes, nil)
 
 				var err error
-				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 				Expect(err).ToNot(HaveOccurred())
 
 				_, err = recordSet.ResolveRecords([]string{"dummy.my-group.my-network.my-deployment.bosh."}, true)
@@ -686,6 +1321,63 @@ var _ = Describe("RecordSet", func() {
 				Entry("missing domain", "domain"),
 			)
 
+			Context("the records json contains bootstrap, lifecycle and instance_state", func() {
+				BeforeEach(func() {
+					jsonBytes := []byte(`{
+						"record_keys": ["id", "instance_group", "group_ids", "network", "deployment", "ip", "domain", "bootstrap", "lifecycle", "instance_state"],
+						"record_infos": [
+							["instance0", "my-group", ["1"], "my-network", "my-deployment", "10.0.0.1", "my-domain", true, "service", "started"],
+							["instance1", "my-group", ["1"], "my-network", "my-deployment", "10.0.0.2", "my-domain", false, "service", "stopped"],
+							["instance2", "my-group", ["1"], "my-network", "my-deployment", "10.0.0.3", "my-domain", null, "errand", "started"],
+							["instance3", "my-group", ["1"], "my-network", "my-deployment", "10.0.0.4", "my-domain", null, null, null],
+							["instance4", "my-group", ["1"], "my-network", "my-deployment", "10.0.0.5", "my-domain", "yes", "service", "started"]
+						]
+					}`)
+					fileReader.GetReturns(jsonBytes, nil)
+					fakeQueryFilterer.FilterStub = (&records.QueryFilter{}).Filter
+					fakeHealthFilterer.FilterStub = func(mm criteria.MatchMaker, recs []record.Record) []record.Record {
+						return recs
+					}
+
+					var err error
+					recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("parses the fields of every record", func() {
+					recs := recordSet.AllRecords()
+					Expect(recs).To(HaveLen(4))
+
+					Expect(recs[0].Bootstrap).To(BeTrue())
+					Expect(recs[0].Lifecycle).To(Equal(record.LifecycleService))
+					Expect(recs[0].InstanceState).To(Equal(record.InstanceStateStarted))
+					Expect(recs[1].Bootstrap).To(BeFalse())
+					Expect(recs[1].InstanceState).To(Equal(record.InstanceStateStopped))
+					Expect(recs[2].Lifecycle).To(Equal(record.LifecycleErrand))
+					Expect(recs[3].Bootstrap).To(BeFalse())
+					Expect(recs[3].Lifecycle).To(BeEmpty())
+					Expect(recs[3].InstanceState).To(BeEmpty())
+				})
+
+				It("logs records with a bootstrap value that is not a boolean", func() {
+					Expect(fakeLogger.WarnCallCount()).To(Equal(1))
+					logTag, _, logArgs := fakeLogger.WarnArgsForCall(0)
+					Expect(logTag).To(Equal("RecordSet"))
+					Expect(logArgs[0]).To(Equal(7))
+					Expect(logArgs[1]).To(Equal("bootstrap"))
+					Expect(logArgs[2]).To(Equal(4))
+					Expect(logArgs[3]).To(Equal("boolean"))
+				})
+
+				It("selects the bootstrap instance of a group", func() {
+					Expect(recordSet.Resolve("q-b1s0.q-g1.my-domain.")).To(Equal([]string{"10.0.0.1"}))
+				})
+
+				It("excludes stopped and errand instances", func() {
+					Expect(recordSet.Resolve("q-e0l0s0.q-g1.my-domain.")).To(Equal([]string{"10.0.0.1", "10.0.0.4"}))
+				})
+			})
+
 			It("includes records that are well-formed but missing individual group_ids values", func() {
 				jsonBytes := []byte(`{
 					"record_keys": ["id", "instance_group", "group_ids", "network", "deployment", "ip", "domain"],
@@ -696,7 +1388,7 @@ var _ = Describe("RecordSet", func() {
 				fileReader.GetReturns(jsonBytes, nil)
 
 				var err error
-				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 				Expect(err).NotTo(HaveOccurred())
 
 				_, err = recordSet.ResolveRecords([]string{"dummy.my-group.my-network.my-deployment.bosh."}, true)
@@ -738,7 +1430,7 @@ var _ = Describe("RecordSet", func() {
 				fileReader.GetReturns(jsonBytes, nil)
 
 				var err error
-				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 				Expect(err).ToNot(HaveOccurred())
 
 				_, err = recordSet.ResolveRecords([]string{"dummy.my-group.my-network.my-deployment.bosh."}, true)
@@ -781,7 +1473,7 @@ var _ = Describe("RecordSet", func() {
 				fileReader.GetReturns(jsonBytes, nil)
 
 				var err error
-				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 				Expect(err).ToNot(HaveOccurred())
 
 				_, err = recordSet.ResolveRecords([]string{"dummy.my-group.my-network.my-deployment.bosh."}, true)
@@ -823,7 +1515,7 @@ var _ = Describe("RecordSet", func() {
 				fileReader.GetReturns(jsonBytes, nil)
 
 				var err error
-				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 				Expect(err).ToNot(HaveOccurred())
 				_, err = recordSet.ResolveRecords([]string{"dummy.my-group.my-network.my-deployment.bosh."}, true)
 				Expect(err).ToNot(HaveOccurred())
@@ -834,6 +1526,111 @@ var _ = Describe("RecordSet", func() {
 				Expect(recs[0].GroupIDs).To(BeEmpty())
 			})
 		})
+
+		Context("the records json is parsed row by row", func() {
+			newRecordSet := func(jsonBytes string) {
+				fileReader.GetReturns([]byte(jsonBytes), nil)
+
+				var err error
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			warnings := func() []string {
+				messages := []string{}
+				for i := 0; i < fakeLogger.WarnCallCount(); i++ {
+					_, msg, args := fakeLogger.WarnArgsForCall(i)
+					messages = append(messages, fmt.Sprintf(msg, args...))
+				}
+				return messages
+			}
+
+			It("reports the line of malformed records", func() {
+				newRecordSet(`{
+					"record_keys": ["id", "instance_group", "network", "deployment", "ip", "domain"],
+					"record_infos": [
+						["instance0", "my-group", "my-network", "my-deployment", "123.123.123.123", "my-domain"],
+						["instance1", "my-group", "my-network", "my-deployment", "123.123.123.124"],
+						["instance2", "my-group", "my-network", 5, "123.123.123.125", "my-domain"]
+					]
+				}`)
+
+				Expect(recordSet.AllRecords()).To(HaveLen(1))
+				Expect(warnings()).To(Equal([]string{
+					"Unbalanced records structure. Found 5 fields of an expected 6 at record #1 (line 5)",
+					"Value 3 (deployment) of record 2 is not expected type of string: 5 (line 6)",
+				}))
+			})
+
+			It("accepts record_keys after record_infos", func() {
+				newRecordSet(`{
+					"record_infos": [
+						["instance0", "my-group", "my-network", "my-deployment", "123.123.123.123", "my-domain"],
+						["instance1", "my-group", "my-network", "my-deployment"]
+					],
+					"Version": 3,
+					"record_keys": ["id", "instance_group", "network", "deployment", "ip", "domain"]
+				}`)
+
+				Expect(recordSet.AllRecords()).To(Equal([]record.Record{{
+					ID: "instance0", Group: "my-group", Network: "my-network", Deployment: "my-deployment", IP: "123.123.123.123", Domain: "my-domain.",
+				}}))
+				Expect(recordSet.History()[0].Version).To(Equal(uint64(3)))
+				Expect(warnings()).To(Equal([]string{
+					"Unbalanced records structure. Found 4 fields of an expected 6 at record #1 (line 4)",
+				}))
+			})
+
+			It("keeps the last record_infos when the key is repeated", func() {
+				newRecordSet(`{
+					"record_infos": [
+						["instance0", "my-group", "my-network", "my-deployment", "123.123.123.123", "my-domain"]
+					],
+					"record_keys": ["id", "instance_group", "network", "deployment", "ip", "domain"],
+					"record_infos": [
+						["instance1", "my-group", "my-network", "my-deployment", "123.123.123.124", "my-domain"]
+					],
+					"RECORD_INFOS": [
+						["instance2", "my-group", "my-network", "my-deployment", "123.123.123.125", "my-domain"]
+					]
+				}`)
+
+				Expect(recordSet.AllRecords()).To(Equal([]record.Record{{
+					ID: "instance2", Group: "my-group", Network: "my-network", Deployment: "my-deployment", IP: "123.123.123.125", Domain: "my-domain.",
+				}}))
+			})
+
+			It("reports the line of invalid json", func() {
+				newRecordSet(`{
+					"record_keys": ["id", "instance_group", "network", "deployment", "ip", "domain"],
+					"record_infos": [
+						["instance0", "my-group", "my-network", "my-deployment", "123.123.123.123", "my-domain"],
+						["instance1", "my-group", "my-network", "my-deployment", "123.123.123.124", "my-domain"]]
+					]
+				}`)
+
+				Expect(recordSet.AllRecords()).To(BeEmpty())
+				Expect(warnings()).To(ContainElement(HavePrefix("Unable to parse records file at line 6. Error: ")))
+			})
+
+			It("shares repeated values between records", func() {
+				newRecordSet(`{
+					"record_keys": ["id", "instance_group", "network", "deployment", "ip", "domain", "az"],
+					"record_infos": [
+						["instance0", "my-group", "my-network", "my-deployment", "123.123.123.123", "my-domain", "z1"],
+						["instance1", "my-group", "my-network", "my-deployment", "123.123.123.124", "my-domain", "z1"]
+					]
+				}`)
+
+				recs := recordSet.AllRecords()
+				Expect(recs).To(HaveLen(2))
+				Expect(unsafe.StringData(recs[0].Deployment)).To(Equal(unsafe.StringData(recs[1].Deployment)))
+				Expect(unsafe.StringData(recs[0].Network)).To(Equal(unsafe.StringData(recs[1].Network)))
+				Expect(unsafe.StringData(recs[0].Group)).To(Equal(unsafe.StringData(recs[1].Group)))
+				Expect(unsafe.StringData(recs[0].Domain)).To(Equal(unsafe.StringData(recs[1].Domain)))
+				Expect(unsafe.StringData(recs[0].AZ)).To(Equal(unsafe.StringData(recs[1].AZ)))
+			})
+		})
 	})
 
 	Describe("Resolve", func() {
@@ -847,7 +1644,7 @@ var _ = Describe("RecordSet", func() {
 			fileReader.GetReturns(jsonBytes, nil)
 
 			var err error
-			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
@@ -903,7 +1700,7 @@ var _ = Describe("RecordSet", func() {
 				fileReader.GetReturns(jsonBytes, nil)
 
 				var err error
-				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -939,6 +1736,142 @@ var _ = Describe("RecordSet", func() {
 			})
 		})
 
+		Context("when the records are indexed", func() {
+			BeforeEach(func() {
+				jsonBytes := []byte(`{
+					"record_keys":
+						["id", "num_id", "instance_group", "group_ids", "az", "az_id", "network", "network_id", "deployment", "ip", "domain", "agent_id"],
+					"record_infos": [
+						["instance0", "0", "my-group", ["1"], "az1", "1", "my-network", "1", "my-deployment", "10.0.0.1", "my-domain", "agent0"],
+						["instance1", "1", "other-group", ["2", "2"], "az1", "1", "my-network", "1", "my-deployment", "10.0.0.2", "my-domain", "agent1"],
+						["instance2", "2", "my-group", ["1"], "az1", "1", "my-network", "1", "other-deployment", "10.0.0.3", "my-domain", "agent2"],
+						["instance3", "3", "my-group", ["1"], "az1", "1", "my-network", "1", "my-deployment", "10.0.0.4", "other-domain", "agent3"]
+					]
+				}`)
+				fileReader.GetReturns(jsonBytes, nil)
+
+				var err error
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			candidateIPs := func(fqdn string) []string {
+				_, err := recordSet.ResolveRecords([]string{fqdn}, false)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, recs := fakeQueryFilterer.FilterArgsForCall(fakeQueryFilterer.FilterCallCount() - 1)
+				ips := []string{}
+				for _, rec := range recs {
+					ips = append(ips, rec.IP)
+				}
+
+				return ips
+			}
+
+			It("only filters the records of the most selective indexed field", func() {
+				Expect(candidateIPs("q-s0.my-group.my-network.other-deployment.my-domain.")).To(Equal([]string{"10.0.0.3"}))
+				Expect(candidateIPs("q-s0.other-group.my-network.my-deployment.my-domain.")).To(Equal([]string{"10.0.0.2"}))
+				Expect(candidateIPs("q-s0.q-g1.other-domain.")).To(Equal([]string{"10.0.0.4"}))
+				Expect(candidateIPs("q-s0.q-g2.my-domain.")).To(Equal([]string{"10.0.0.2"}))
+				Expect(candidateIPs("agent2.bosh-agent-id.")).To(Equal([]string{"10.0.0.3"}))
+			})
+
+			It("filters all records of the domain when only globs are given for other fields", func() {
+				Expect(candidateIPs("q-s0.*.my-network.*.my-domain.")).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}))
+				Expect(candidateIPs("q-s0.my-gro?p.my-network.my-*ment.my-domain.")).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}))
+			})
+
+			It("returns DomainError when no record has an indexed value", func() {
+				_, err := recordSet.ResolveRecords([]string{"q-s0.my-group.my-network.missing-deployment.my-domain."}, false)
+				Expect(err).To(MatchError(records.DomainError))
+			})
+		})
+
+		Context("when the query uses the readable form", func() {
+			BeforeEach(func() {
+				jsonBytes := []byte(`{
+					"record_keys":
+						["id", "num_id", "instance_group", "group_ids", "az", "az_id", "network", "network_id", "deployment", "ip", "domain", "instance_index"],
+					"record_infos": [
+						["instance0", "0", "my-group", ["1"], "z1", "1", "my-network", "1", "my-deployment", "10.0.0.1", "my-domain", 0],
+						["instance1", "1", "my-group", ["1"], "z1", "1", "my-network", "1", "my-deployment", "10.0.0.2", "my-domain", 1],
+						["all", "2", "my-group", ["1"], "z2", "2", "my-network", "1", "my-deployment", "10.0.0.3", "my-domain", 2]
+					]
+				}`)
+				fileReader.GetReturns(jsonBytes, nil)
+				fakeQueryFilterer.FilterStub = (&records.QueryFilter{}).Filter
+				fakeHealthFilterer.FilterStub = func(mm criteria.MatchMaker, recs []record.Record) []record.Record {
+					return recs
+				}
+
+				var err error
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("filters by az name and instance index", func() {
+				Expect(recordSet.Resolve("_filter.az-z1.my-group.my-network.my-deployment.my-domain.")).To(Equal([]string{"10.0.0.1", "10.0.0.2"}))
+				Expect(recordSet.Resolve("_filter.az-z1.index-1.my-group.my-network.my-deployment.my-domain.")).To(Equal([]string{"10.0.0.2"}))
+				Expect(recordSet.Resolve("_filter.index-2.q-g1.my-domain.")).To(Equal([]string{"10.0.0.3"}))
+			})
+
+			It("resolves instances named like a health filter", func() {
+				Expect(recordSet.Resolve("all.my-group.my-network.my-deployment.my-domain.")).To(Equal([]string{"10.0.0.3"}))
+			})
+
+			It("reads labels without the marker as instance names", func() {
+				_, err := recordSet.Resolve("az-z1.my-group.my-network.my-deployment.my-domain.")
+				Expect(err).To(MatchError("no records match requested domain"))
+			})
+
+			It("passes the named health filter to the health filterer", func() {
+				_, err := recordSet.Resolve("_filter.health-healthy.my-group.my-network.my-deployment.my-domain.")
+				Expect(err).NotTo(HaveOccurred())
+
+				mm, _ := fakeHealthFilterer.FilterArgsForCall(0)
+				Expect(mm.(criteria.Criteria)["s"]).To(Equal([]string{"3"}))
+			})
+		})
+
+		Context("when checking whether answers are ordered", func() {
+			BeforeEach(func() {
+				aliasList = mustNewConfigFromMap(map[string][]string{
+					"ordered-alias":    {"q-s2.my-group.my-network.my-deployment.my-domain."},
+					"mixed-alias":      {"q-s2.my-group.my-network.my-deployment.my-domain.", "q-s0.my-group.my-network.my-deployment.my-domain."},
+					"with-ip-alias":    {"q-s2.my-group.my-network.my-deployment.my-domain.", "5.5.5.5"},
+					"only-an-ip-alias": {"5.5.5.5"},
+				})
+
+				jsonBytes := []byte(`{
+					"record_keys": ["id", "instance_group", "network", "deployment", "ip", "domain"],
+					"record_infos": [
+						["instance0", "my-group", "my-network", "my-deployment", "10.0.0.1", "my-domain"]
+					]
+				}`)
+				fileReader.GetReturns(jsonBytes, nil)
+
+				var err error
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("orders the answers of queries using the s2 health strategy", func() {
+				Expect(recordSet.OrderedAnswers("q-s2.my-group.my-network.my-deployment.my-domain.")).To(BeTrue())
+				Expect(recordSet.OrderedAnswers("Q-S2.my-group.my-network.my-deployment.my-domain.")).To(BeTrue())
+				Expect(recordSet.OrderedAnswers("_filter.health-ordered.my-group.my-network.my-deployment.my-domain.")).To(BeTrue())
+				Expect(recordSet.OrderedAnswers("ordered-alias.")).To(BeTrue())
+				Expect(recordSet.OrderedAnswers("with-ip-alias.")).To(BeTrue())
+			})
+
+			It("does not order the answers of other queries", func() {
+				Expect(recordSet.OrderedAnswers("q-s0.my-group.my-network.my-deployment.my-domain.")).To(BeFalse())
+				Expect(recordSet.OrderedAnswers("my-group.my-network.my-deployment.my-domain.")).To(BeFalse())
+				Expect(recordSet.OrderedAnswers("mixed-alias.")).To(BeFalse())
+				Expect(recordSet.OrderedAnswers("only-an-ip-alias.")).To(BeFalse())
+				Expect(recordSet.OrderedAnswers("not-a-query")).To(BeFalse())
+			})
+		})
+
 		Context("when there are no records matching the domain", func() {
 			BeforeEach(func() {
 				jsonBytes := []byte(`{
@@ -949,7 +1882,7 @@ var _ = Describe("RecordSet", func() {
 				fileReader.GetReturns(jsonBytes, nil)
 
 				var err error
-				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -971,7 +1904,7 @@ var _ = Describe("RecordSet", func() {
 				fileReader.GetReturns(jsonBytes, nil)
 
 				var err error
-				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -994,7 +1927,7 @@ var _ = Describe("RecordSet", func() {
 				fileReader.GetReturns(jsonBytes, nil)
 
 				var err error
-				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -1029,24 +1962,25 @@ var _ = Describe("RecordSet", func() {
 				}`)
 				fileReader.GetReturns(jsonBytes, nil)
 
+				fakeQueryFilterer.FilterStub = (&records.QueryFilter{}).Filter
 				fakeHealthFilterer.FilterStub = func(mm criteria.MatchMaker, recs []record.Record) []record.Record {
 					crit := mm.(criteria.Criteria)
 
 					switch crit["fqdn"][0] {
 					case "q-s0.my-group.my-network.my-deployment.a1_domain1.":
-						return []record.Record{recs[2]}
+						return recordsInDomain(recs, "a1_domain1.")
 					case "q-s0.my-group.my-network.my-deployment.a1_domain2.":
-						return []record.Record{recs[3]}
+						return recordsInDomain(recs, "a1_domain2.")
 					case "q-s0.my-group.my-network.my-deployment.a2_domain1.":
-						return []record.Record{recs[0]}
+						return recordsInDomain(recs, "a2_domain1.")
 					case "q-s0.my-group.my-network.my-deployment.b2_domain1.":
-						return []record.Record{recs[1]}
+						return recordsInDomain(recs, "b2_domain1.")
 					}
 					return []record.Record{}
 				}
 
 				var err error
-				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+				recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 
 				Expect(err).ToNot(HaveOccurred())
 			})
@@ -1146,26 +2080,27 @@ var _ = Describe("RecordSet", func() {
 							},
 						)
 
+						fakeQueryFilterer.FilterStub = (&records.QueryFilter{}).Filter
 						fakeHealthFilterer.FilterStub = func(mm criteria.MatchMaker, recs []record.Record) []record.Record {
 							crit := mm.(criteria.Criteria)
 
 							switch crit["fqdn"][0] {
 							case "q-s0.my-group.my-network.my-deployment.a1_domain1.":
-								return []record.Record{recs[2]}
+								return recordsInDomain(recs, "a1_domain1.")
 							case "q-s0.my-group.my-network.my-deployment.a1_domain2.":
-								return []record.Record{recs[3]}
+								return recordsInDomain(recs, "a1_domain2.")
 							case "q-s0.my-group.my-network.my-deployment.a2_domain1.":
-								return []record.Record{recs[0]}
+								return recordsInDomain(recs, "a2_domain1.")
 							case "q-s0.my-group.my-network.my-deployment.b2_domain1.":
-								return []record.Record{recs[1]}
+								return recordsInDomain(recs, "b2_domain1.")
 							case "q-s0.q-g1.a2_domain1.":
-								return []record.Record{recs[0]}
+								return recordsInDomain(recs, "a2_domain1.")
 							}
 							return []record.Record{}
 						}
 
 						var err error
-						recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+						recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 
 						Expect(err).ToNot(HaveOccurred())
 					})
@@ -1219,7 +2154,7 @@ var _ = Describe("RecordSet", func() {
 
 									switch crit["fqdn"][0] {
 									case "q-s0.q-g1.a2_domain1.":
-										return []record.Record{recs[0]}
+										return recordsInDomain(recs, "a2_domain1.")
 									}
 									return []record.Record{}
 								}
@@ -1261,6 +2196,30 @@ var _ = Describe("RecordSet", func() {
 
 						})
 					})
+
+					Describe("UpdateAliases", func() {
+						BeforeEach(func() {
+							recordSet.UpdateAliases(mustNewConfigFromMap(map[string][]string{
+								"newalias": {"q-s0.my-group.my-network.my-deployment.a1_domain2."},
+							}))
+						})
+
+						It("replaces the aliases from the alias files", func() {
+							resolutions, err := recordSet.Resolve("newalias.")
+							Expect(err).ToNot(HaveOccurred())
+							Expect(resolutions).To(Equal([]string{"4.4.4.4"}))
+
+							Expect(recordSet.ExpandAliases("alias1.")).To(Equal([]string{"alias1."}))
+							Expect(recordSet.Domains()).To(ContainElement("newalias."))
+							Expect(recordSet.Domains()).NotTo(ContainElement("alias1."))
+						})
+
+						It("keeps the aliases from the records file", func() {
+							resolutions, err := recordSet.Resolve("globalalias.")
+							Expect(err).ToNot(HaveOccurred())
+							Expect(resolutions).To(Equal([]string{"1.1.1.1"}))
+						})
+					})
 				})
 			})
 		})
@@ -1307,7 +2266,7 @@ var _ = Describe("RecordSet", func() {
 			}
 
 			var err error
-			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeFiltererFactory, fakeAliasQueryEncoder)
+			recordSet, err = records.NewRecordSet(fileReader, aliasList, fakeHealthWatcher, uint(5), shutdownChan, fakeLogger, fakeClock, fakeFiltererFactory, fakeAliasQueryEncoder)
 
 			Expect(err).ToNot(HaveOccurred())
 		})
@@ -1401,3 +2360,14 @@ var _ = Describe("RecordSet", func() {
 		}, NodeTimeout(10*time.Second))
 	})
 })
+
+func recordsInDomain(recs []record.Record, domain string) []record.Record {
+	domainRecords := []record.Record{}
+	for _, rec := range recs {
+		if rec.Domain == domain {
+			domainRecords = append(domainRecords, rec)
+		}
+	}
+
+	return domainRecords
+}
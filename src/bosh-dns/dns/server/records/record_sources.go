@@ -0,0 +1,233 @@
+package records
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cloudfoundry/bosh-utils/system"
+	"github.com/miekg/dns"
+
+	"bosh-dns/dns/config"
+	"bosh-dns/dns/server/aliases"
+	"bosh-dns/dns/server/record"
+)
+
+// Source is a records file served by a RecordSet. When Domains is set, only
+// records under those domains are taken from it. When several sources provide
+// records for the same domain, only the source with the highest Precedence is
+// used for it; sources with the same precedence keep their given order.
+// DeltaReader optionally provides deltas that are applied on top of the
+// records file.
+type Source struct {
+	Name        string
+	Reader      FileReader
+	DeltaReader FileReader
+	Domains     []string
+	Precedence  int
+}
+
+// NewSources creates a source for every file matching the configured paths.
+// Paths without glob patterns are used even if the file does not exist yet,
+// globs are expanded once.
+func NewSources(sourceConfigs []config.RecordsSource, fs system.FileSystem, clock clock.Clock, logger boshlog.Logger, shutdownChan chan struct{}) ([]Source, error) {
+	sources := []Source{}
+
+	for _, sourceConfig := range sourceConfigs {
+		paths := []string{sourceConfig.Path}
+		if strings.ContainsAny(sourceConfig.Path, "*?[") {
+			var err error
+			paths, err = fs.Glob(sourceConfig.Path)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Globbing records files '%s'", sourceConfig.Path)
+			}
+
+			if len(paths) == 0 {
+				logger.Warn(logTag, "No records files match '%s'", sourceConfig.Path)
+			}
+		}
+
+		for _, path := range paths {
+			sources = append(sources, Source{
+				Name:       filepath.Clean(path),
+				Reader:     NewFileReader(path, fs, clock, logger, shutdownChan),
+				Domains:    sourceConfig.Domains,
+				Precedence: sourceConfig.Precedence,
+			})
+		}
+	}
+
+	return sources, nil
+}
+
+type sourceState struct {
+	Source
+
+	version          uint64
+	applied          *record.BlobVersion
+	records          []record.Record
+	hosts            []record.Host
+	aliasDefinitions map[string][]AliasDefinition
+	aliasQueries     map[string][]string
+	aliases          aliases.Config
+	problems         []record.Problem
+	deltaProblems    []record.Problem
+}
+
+func newSourceStates(sources []Source) []*sourceState {
+	states := make([]*sourceState, len(sources))
+	for i, source := range sources {
+		domains := make([]string, len(source.Domains))
+		for j, domain := range source.Domains {
+			domains[j] = dns.Fqdn(strings.ToLower(domain))
+		}
+		source.Domains = domains
+
+		states[i] = &sourceState{Source: source, aliases: aliases.NewConfig()}
+	}
+
+	sort.SliceStable(states, func(i, j int) bool {
+		return states[i].Precedence > states[j].Precedence
+	})
+
+	return states
+}
+
+func (s *sourceState) inScope(records []record.Record, hosts []record.Host, logger boshlog.Logger) ([]record.Record, []record.Host) {
+	if len(s.Domains) == 0 {
+		return records, hosts
+	}
+
+	scopedRecords := make([]record.Record, 0, len(records))
+	for _, rec := range records {
+		if s.coversDomain(rec.Domain) {
+			scopedRecords = append(scopedRecords, rec)
+		}
+	}
+
+	scopedHosts := make([]record.Host, 0, len(hosts))
+	for _, host := range hosts {
+		if s.coversDomain(host.FQDN) {
+			scopedHosts = append(scopedHosts, host)
+		}
+	}
+
+	if ignored := len(records) - len(scopedRecords); ignored > 0 {
+		logger.Warn("RecordSet", "Ignoring %d records from %s outside of %s", ignored, s.Name, strings.Join(s.Domains, ", "))
+	}
+
+	return scopedRecords, scopedHosts
+}
+
+func (s *sourceState) coversDomain(domain string) bool {
+	domain = dns.Fqdn(strings.ToLower(domain))
+	for _, scope := range s.Domains {
+		if dns.IsSubDomain(scope, domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type mergedSources struct {
+	records   []record.Record
+	hosts     []record.Host
+	aliases   aliases.Config
+	conflicts []record.Conflict
+}
+
+// mergeSources expects the sources ordered by precedence, highest first.
+func mergeSources(sources []*sourceState) mergedSources {
+	merged := mergedSources{
+		records: []record.Record{},
+		hosts:   []record.Host{},
+		aliases: aliases.NewConfig(),
+	}
+
+	domainOwners := map[string]*sourceState{}
+	domainConflicts := map[string]*record.Conflict{}
+	domainOrder := []string{}
+	ipSources := map[string][]string{}
+	ipOrder := []string{}
+
+	for _, source := range sources {
+		for _, rec := range source.records {
+			owner, found := domainOwners[rec.Domain]
+			if !found {
+				domainOwners[rec.Domain] = source
+				owner = source
+			}
+
+			if owner != source {
+				conflict, found := domainConflicts[rec.Domain]
+				if !found {
+					conflict = &record.Conflict{Kind: record.DomainConflict, Value: rec.Domain, Sources: []string{owner.Name}}
+					domainConflicts[rec.Domain] = conflict
+					domainOrder = append(domainOrder, rec.Domain)
+				}
+				if conflict.Sources[len(conflict.Sources)-1] != source.Name {
+					conflict.Sources = append(conflict.Sources, source.Name)
+				}
+				continue
+			}
+
+			merged.records = append(merged.records, rec)
+
+			names, found := ipSources[rec.IP]
+			if !found {
+				ipOrder = append(ipOrder, rec.IP)
+			}
+			if len(names) == 0 || names[len(names)-1] != source.Name {
+				ipSources[rec.IP] = append(names, source.Name)
+			}
+		}
+
+		for _, host := range source.hosts {
+			if owner := hostOwner(domainOwners, host.FQDN); owner != nil && owner != source {
+				continue
+			}
+
+			merged.hosts = append(merged.hosts, host)
+		}
+
+		merged.aliases = merged.aliases.Merge(source.aliases)
+	}
+
+	for _, domain := range domainOrder {
+		merged.conflicts = append(merged.conflicts, *domainConflicts[domain])
+	}
+
+	for _, ip := range ipOrder {
+		if len(ipSources[ip]) > 1 {
+			merged.conflicts = append(merged.conflicts, record.Conflict{Kind: record.IPConflict, Value: ip, Sources: ipSources[ip]})
+		}
+	}
+
+	return merged
+}
+
+func hostOwner(domainOwners map[string]*sourceState, fqdn string) *sourceState {
+	fqdn = dns.Fqdn(strings.ToLower(fqdn))
+	for domain, owner := range domainOwners {
+		if dns.IsSubDomain(domain, fqdn) {
+			return owner
+		}
+	}
+
+	return nil
+}
+
+func containsConflict(conflicts []record.Conflict, conflict record.Conflict) bool {
+	for _, c := range conflicts {
+		if reflect.DeepEqual(c, conflict) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,76 @@
+package records_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+	"github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bosh-dns/dns/config"
+	"bosh-dns/dns/server/records"
+)
+
+var _ = Describe("NewSources", func() {
+	var (
+		shutdownChan   chan struct{}
+		fakeFileSystem *fakes.FakeFileSystem
+		fakeLogger     *loggerfakes.FakeLogger
+		fakeClock      *fakeclock.FakeClock
+	)
+
+	BeforeEach(func() {
+		shutdownChan = make(chan struct{})
+		fakeFileSystem = fakes.NewFakeFileSystem()
+		fakeLogger = &loggerfakes.FakeLogger{}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+
+		for _, path := range []string{"/director-a/records.json", "/director-b/records.json", "/other/records.json"} {
+			Expect(fakeFileSystem.WriteFileString(path, `{"Version": 1}`)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		close(shutdownChan)
+	})
+
+	It("creates a source for every file matching a glob", func() {
+		fakeFileSystem.SetGlob("/director-*/records.json", []string{"/director-a/records.json", "/director-b/records.json"})
+
+		sources, err := records.NewSources([]config.RecordsSource{
+			{Path: "/director-*/records.json", Domains: []string{"directors"}, Precedence: 5},
+			{Path: "/other/records.json"},
+		}, fakeFileSystem, fakeClock, fakeLogger, shutdownChan)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sources).To(HaveLen(3))
+		Expect(sources[0].Name).To(Equal("/director-a/records.json"))
+		Expect(sources[0].Domains).To(Equal([]string{"directors"}))
+		Expect(sources[0].Precedence).To(Equal(5))
+		Expect(sources[1].Name).To(Equal("/director-b/records.json"))
+		Expect(sources[2].Name).To(Equal("/other/records.json"))
+		Expect(sources[2].Precedence).To(Equal(0))
+
+		contents, err := sources[1].Reader.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal(`{"Version": 1}`))
+	})
+
+	It("warns when a glob does not match anything", func() {
+		sources, err := records.NewSources([]config.RecordsSource{{Path: "/missing/*.json"}}, fakeFileSystem, fakeClock, fakeLogger, shutdownChan)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sources).To(BeEmpty())
+
+		Expect(fakeLogger.WarnCallCount()).To(Equal(1))
+	})
+
+	It("returns an error when a glob cannot be expanded", func() {
+		fakeFileSystem.GlobErr = errors.New("fake-glob-err")
+
+		_, err := records.NewSources([]config.RecordsSource{{Path: "/director-*/records.json"}}, fakeFileSystem, fakeClock, fakeLogger, shutdownChan)
+		Expect(err).To(MatchError(ContainSubstring("fake-glob-err")))
+	})
+})
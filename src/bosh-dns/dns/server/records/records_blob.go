@@ -0,0 +1,139 @@
+package records
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"bosh-dns/dns/server/record"
+)
+
+// recordsBlob is a parsed records file. A delta only holds the changes
+// since its BaseVersion: Records are added or replace the record with the
+// same identity, RemovedRecords and RemovedHosts are dropped, and
+// AliasDefinitions replace the aliases unless they are nil.
+type recordsBlob struct {
+	Version          uint64
+	BaseVersion      *uint64
+	Records          []record.Record
+	Hosts            []record.Host
+	AliasDefinitions map[string][]AliasDefinition
+	RemovedRecords   []recordIdentity
+	RemovedHosts     []record.Host
+	Problems         []record.Problem
+}
+
+func (b recordsBlob) isDelta() bool {
+	return b.BaseVersion != nil
+}
+
+// recordIdentity identifies the record of an instance on a network, its IP
+// and other details may change between versions.
+type recordIdentity struct {
+	id, group, network, deployment, domain string
+}
+
+func identityOf(rec record.Record) recordIdentity {
+	return recordIdentity{rec.ID, rec.Group, rec.Network, rec.Deployment, rec.Domain}
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// MaxRecordsSize is the largest size in bytes of the contents of a records
+// file after decompression, and of a records file that is downloaded. It
+// stops a small compressed file from expanding without bound.
+const MaxRecordsSize = 256 << 20
+
+// cappedReader fails once more than limit bytes are read from it.
+type cappedReader struct {
+	reader io.Reader
+	limit  int64
+	read   int64
+}
+
+func newCappedReader(reader io.Reader, limit int64) *cappedReader {
+	return &cappedReader{reader: io.LimitReader(reader, limit+1), limit: limit}
+}
+
+func (r *cappedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		return n - int(r.read-r.limit), bosherr.Errorf("Records exceed the maximum size of %d bytes", r.limit)
+	}
+
+	return n, err
+}
+
+// readRecordsBlob reads and parses a records file that may be gzip
+// compressed. The contents come from the cache of the FileReader, so the
+// raw file stays in memory next to the parsed records.
+func readRecordsBlob(reader FileReader, logger boshlog.Logger) (recordsBlob, error) {
+	contents, err := reader.Get()
+	if err != nil {
+		return recordsBlob{}, bosherr.WrapError(err, "Reading records")
+	}
+
+	var blobReader io.Reader = bytes.NewReader(contents)
+	if bytes.HasPrefix(contents, gzipMagic) {
+		var decompressed io.Reader
+		decompressed, err = gzip.NewReader(blobReader)
+		if err != nil {
+			return recordsBlob{}, bosherr.WrapError(err, "Decompressing records")
+		}
+		blobReader = newCappedReader(decompressed, MaxRecordsSize)
+	}
+
+	blob, err := parseRecordsBlob(blobReader, logger)
+	if err != nil {
+		return recordsBlob{}, bosherr.WrapError(err, "Parsing records")
+	}
+
+	return blob, nil
+}
+
+// applyDelta returns the records, hosts and alias definitions of the source
+// after the delta has been applied, the source itself is left untouched.
+func applyDelta(source *sourceState, delta recordsBlob) ([]record.Record, []record.Host, map[string][]AliasDefinition) {
+	removed := make(map[recordIdentity]struct{}, len(delta.RemovedRecords)+len(delta.Records))
+	for _, identity := range delta.RemovedRecords {
+		removed[identity] = struct{}{}
+	}
+	for _, rec := range delta.Records {
+		removed[identityOf(rec)] = struct{}{}
+	}
+
+	records := make([]record.Record, 0, len(source.records)+len(delta.Records))
+	for _, rec := range source.records {
+		if _, found := removed[identityOf(rec)]; !found {
+			records = append(records, rec)
+		}
+	}
+	records = append(records, delta.Records...)
+
+	removedHosts := make(map[record.Host]struct{}, len(delta.RemovedHosts)+len(delta.Hosts))
+	for _, host := range delta.RemovedHosts {
+		removedHosts[host] = struct{}{}
+	}
+	for _, host := range delta.Hosts {
+		removedHosts[host] = struct{}{}
+	}
+
+	hosts := make([]record.Host, 0, len(source.hosts)+len(delta.Hosts))
+	for _, host := range source.hosts {
+		if _, found := removedHosts[host]; !found {
+			hosts = append(hosts, host)
+		}
+	}
+	hosts = append(hosts, delta.Hosts...)
+
+	aliasDefinitions := source.aliasDefinitions
+	if delta.AliasDefinitions != nil {
+		aliasDefinitions = delta.AliasDefinitions
+	}
+
+	return records, hosts, aliasDefinitions
+}
@@ -0,0 +1,633 @@
+package records
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/miekg/dns"
+
+	"bosh-dns/dns/server/record"
+)
+
+// recordRow locates a row of record_infos or removed_record_infos in the
+// records file.
+type recordRow struct {
+	index int
+	line  int
+}
+
+type recordColumns struct {
+	id, numID, group, groupIDs, network, networkID, deployment, ip, domain, az, azID, instanceIndex, agentID int
+	bootstrap, lifecycle, instanceState                                                                      int
+}
+
+func newRecordColumns(keys []string) recordColumns {
+	columns := recordColumns{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}
+
+	for i, k := range keys {
+		switch k {
+		case "id":
+			columns.id = i
+		case "num_id":
+			columns.numID = i
+		case "instance_group":
+			columns.group = i
+		case "group_ids":
+			columns.groupIDs = i
+		case "network":
+			columns.network = i
+		case "network_id":
+			columns.networkID = i
+		case "deployment":
+			columns.deployment = i
+		case "ip":
+			columns.ip = i
+		case "domain":
+			columns.domain = i
+		case "az":
+			columns.az = i
+		case "az_id":
+			columns.azID = i
+		case "instance_index":
+			columns.instanceIndex = i
+		case "agent_id":
+			columns.agentID = i
+		case "bootstrap":
+			columns.bootstrap = i
+		case "lifecycle":
+			columns.lifecycle = i
+		case "instance_state":
+			columns.instanceState = i
+		}
+	}
+
+	return columns
+}
+
+// bufferedRow is a row that was read before record_keys and can only be
+// parsed once the columns are known.
+type bufferedRow struct {
+	contents json.RawMessage
+	row      recordRow
+	removed  bool
+}
+
+// recordsParser decodes a records file row by row and builds the records
+// directly, without decoding the whole document into generic values first.
+// It does not bound the memory of a read on its own: the FileReader still
+// holds the raw contents of the file, which it keeps serving when the file
+// becomes unreadable. Values repeated across records, such as deployments
+// and networks, share a single string.
+type recordsParser struct {
+	decoder *json.Decoder
+	lines   *lineCounter
+	logger  boshlog.Logger
+
+	keys     []string
+	columns  recordColumns
+	buffered []bufferedRow
+	strings  map[string]string
+	info     []interface{}
+	rows     int
+	problems []record.Problem
+}
+
+func parseRecordsBlob(reader io.Reader, logger boshlog.Logger) (recordsBlob, error) {
+	lines := &lineCounter{reader: reader}
+	p := &recordsParser{
+		decoder: json.NewDecoder(lines),
+		lines:   lines,
+		logger:  logger,
+		columns: newRecordColumns(nil),
+		strings: map[string]string{},
+	}
+
+	blob, err := p.parse()
+	if err != nil {
+		line := p.errorLine(err)
+		logger.Warn("RecordSet", "Unable to parse records file at line %d. Error: %v", line, err)
+		return recordsBlob{}, bosherr.WrapErrorf(err, "Line %d", line)
+	}
+	logger.Debug("RecordSet", "Read DNS blob version %d", blob.Version)
+
+	return blob, nil
+}
+
+func (p *recordsParser) parse() (recordsBlob, error) {
+	blob := recordsBlob{
+		Records: []record.Record{},
+		Hosts:   []record.Host{},
+	}
+
+	if err := p.expectDelim('{'); err != nil {
+		return recordsBlob{}, err
+	}
+
+	for p.decoder.More() {
+		token, err := p.decoder.Token()
+		if err != nil {
+			return recordsBlob{}, err
+		}
+
+		key, _ := token.(string)
+
+		// Keys are matched case insensitively like json.Unmarshal does.
+		switch strings.ToLower(key) {
+		case "record_keys":
+			if err = p.decoder.Decode(&p.keys); err == nil {
+				p.columns = newRecordColumns(p.keys)
+				err = p.parseBufferedRows(&blob)
+			}
+		case "record_infos":
+			err = p.parseRows(&blob, false)
+		case "removed_record_infos":
+			err = p.parseRows(&blob, true)
+		case "records":
+			blob.Hosts, err = p.parseHosts()
+		case "removed_records":
+			blob.RemovedHosts, err = p.parseHosts()
+		case "aliases":
+			err = p.decoder.Decode(&blob.AliasDefinitions)
+		case "version":
+			err = p.decoder.Decode(&blob.Version)
+		case "base_version":
+			err = p.decoder.Decode(&blob.BaseVersion)
+		default:
+			var skipped json.RawMessage
+			err = p.decoder.Decode(&skipped)
+		}
+
+		if err != nil {
+			return recordsBlob{}, err
+		}
+	}
+
+	if err := p.expectDelim('}'); err != nil {
+		return recordsBlob{}, err
+	}
+
+	// Without record_keys no row has its required columns.
+	if err := p.parseBufferedRows(&blob); err != nil {
+		return recordsBlob{}, err
+	}
+
+	if p.rows > 0 {
+		p.checkColumns()
+	}
+	blob.Problems = p.problems
+
+	return blob, nil
+}
+
+func (p *recordsParser) parseRows(blob *recordsBlob, removed bool) error {
+	p.discardRows(blob, removed)
+
+	token, err := p.decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if token != json.Delim('[') {
+		return bosherr.Errorf("Expected an array of records but found %v", token)
+	}
+
+	for index := 0; p.decoder.More(); index++ {
+		p.rows++
+
+		if p.keys == nil {
+			var contents json.RawMessage
+			if err := p.decoder.Decode(&contents); err != nil {
+				return err
+			}
+
+			start := p.decoder.InputOffset() - int64(len(contents))
+			p.buffered = append(p.buffered, bufferedRow{
+				contents: contents,
+				row:      recordRow{index: index, line: p.lines.lineAt(start)},
+				removed:  removed,
+			})
+
+			continue
+		}
+
+		info, row, err := p.decodeRow(index)
+		if err != nil {
+			return err
+		}
+
+		p.appendRow(blob, info, row, removed)
+	}
+
+	return p.expectDelim(']')
+}
+
+// discardRows drops the rows of an earlier record_infos or
+// removed_record_infos key, so that a repeated key replaces them like it does
+// with json.Unmarshal.
+func (p *recordsParser) discardRows(blob *recordsBlob, removed bool) {
+	if removed {
+		blob.RemovedRecords = nil
+	} else {
+		blob.Records = []record.Record{}
+	}
+
+	kept := p.buffered[:0]
+	for _, buffered := range p.buffered {
+		if buffered.removed != removed {
+			kept = append(kept, buffered)
+		}
+	}
+	p.buffered = kept
+}
+
+func (p *recordsParser) parseBufferedRows(blob *recordsBlob) error {
+	for _, buffered := range p.buffered {
+		var info []interface{}
+		if err := json.Unmarshal(buffered.contents, &info); err != nil {
+			return bosherr.WrapErrorf(err, "Record %d at line %d", buffered.row.index, buffered.row.line)
+		}
+
+		p.appendRow(blob, info, buffered.row, buffered.removed)
+	}
+
+	p.buffered = nil
+
+	return nil
+}
+
+// decodeRow decodes the values of a row like json.Unmarshal into
+// []interface{} would, reusing the slice of the previous row.
+func (p *recordsParser) decodeRow(index int) ([]interface{}, recordRow, error) {
+	p.info = p.info[:0]
+
+	token, err := p.decoder.Token()
+	if err != nil {
+		return nil, recordRow{}, err
+	}
+
+	row := recordRow{index: index, line: p.lines.lineAt(p.decoder.InputOffset() - 1)}
+
+	if token == nil {
+		return p.info, row, nil
+	}
+	if token != json.Delim('[') {
+		return nil, recordRow{}, bosherr.Errorf("Expected record %d at line %d to be an array but found %v", index, row.line, token)
+	}
+
+	for p.decoder.More() {
+		var value interface{}
+		if err := p.decoder.Decode(&value); err != nil {
+			return nil, recordRow{}, err
+		}
+
+		p.info = append(p.info, value)
+	}
+
+	if err := p.expectDelim(']'); err != nil {
+		return nil, recordRow{}, err
+	}
+
+	return p.info, row, nil
+}
+
+func (p *recordsParser) appendRow(blob *recordsBlob, info []interface{}, row recordRow, removed bool) {
+	if len(info) != len(p.keys) {
+		kind := "record"
+		if removed {
+			kind = "removed record"
+		}
+
+		p.logger.Warn("RecordSet", "Unbalanced records structure. Found %d fields of an expected %d at "+kind+" #%d (line %d)", len(info), len(p.keys), row.index, row.line)
+		p.problems = append(p.problems, record.Problem{
+			Kind:    record.UnbalancedRowProblem,
+			Line:    row.line,
+			Record:  row.index,
+			Message: fmt.Sprintf("Found %d fields of an expected %d at %s #%d", len(info), len(p.keys), kind, row.index),
+		})
+		return
+	}
+
+	if removed {
+		if identity, ok := p.identity(info, row); ok {
+			blob.RemovedRecords = append(blob.RemovedRecords, identity)
+		}
+		return
+	}
+
+	if rec, ok := p.record(info, row); ok {
+		blob.Records = append(blob.Records, rec)
+	}
+}
+
+func (p *recordsParser) record(info []interface{}, row recordRow) (record.Record, bool) {
+	columns := p.columns
+
+	var domain string
+	if !p.requiredStringValue(&domain, info, columns.domain, "domain", row) {
+		return record.Record{}, false
+	}
+
+	newRecord := record.Record{Domain: p.intern(dns.Fqdn(domain))}
+
+	if !p.requiredStringValue(&newRecord.ID, info, columns.id, "id", row) {
+		return record.Record{}, false
+	} else if !p.requiredStringValue(&newRecord.Group, info, columns.group, "group", row) {
+		return record.Record{}, false
+	} else if !p.requiredStringValue(&newRecord.Network, info, columns.network, "network", row) {
+		return record.Record{}, false
+	} else if !p.requiredStringValue(&newRecord.Deployment, info, columns.deployment, "deployment", row) {
+		return record.Record{}, false
+	} else if !p.requiredStringValue(&newRecord.IP, info, columns.ip, "ip", row) {
+		return record.Record{}, false
+	} else if !p.optionalStringValue(&newRecord.AZ, info, columns.az, "az", row) {
+		return record.Record{}, false
+	} else if !p.optionalStringValue(&newRecord.AZID, info, columns.azID, "az_id", row) {
+		return record.Record{}, false
+	} else if !p.optionalStringValue(&newRecord.NetworkID, info, columns.networkID, "network_id", row) {
+		return record.Record{}, false
+	} else if !p.optionalStringValue(&newRecord.NumID, info, columns.numID, "num_id", row) {
+		return record.Record{}, false
+	} else if !p.optionalStringValue(&newRecord.AgentID, info, columns.agentID, "agent_id", row) {
+		return record.Record{}, false
+	} else if !p.optionalStringValue(&newRecord.Lifecycle, info, columns.lifecycle, "lifecycle", row) {
+		return record.Record{}, false
+	} else if !p.optionalStringValue(&newRecord.InstanceState, info, columns.instanceState, "instance_state", row) {
+		return record.Record{}, false
+	} else if !p.optionalBoolValue(&newRecord.Bootstrap, info, columns.bootstrap, "bootstrap", row) {
+		return record.Record{}, false
+	} else if columns.groupIDs >= 0 && !p.assertStringArrayOfStringValue(&newRecord.GroupIDs, info, columns.groupIDs, "group_ids", row) {
+		return record.Record{}, false
+	}
+
+	p.assertStringIntegerValue(&newRecord.InstanceIndex, info, columns.instanceIndex, "instance_index", row)
+
+	if domain == "" {
+		p.missingValue("domain", row)
+	}
+	if newRecord.IP == "" {
+		p.missingValue("ip", row)
+	}
+
+	newRecord.Group = p.intern(newRecord.Group)
+	newRecord.Network = p.intern(newRecord.Network)
+	newRecord.Deployment = p.intern(newRecord.Deployment)
+	newRecord.AZ = p.intern(newRecord.AZ)
+	newRecord.AZID = p.intern(newRecord.AZID)
+	newRecord.NetworkID = p.intern(newRecord.NetworkID)
+	newRecord.Lifecycle = p.intern(newRecord.Lifecycle)
+	newRecord.InstanceState = p.intern(newRecord.InstanceState)
+	for i, groupID := range newRecord.GroupIDs {
+		newRecord.GroupIDs[i] = p.intern(groupID)
+	}
+
+	return newRecord, true
+}
+
+func (p *recordsParser) identity(info []interface{}, row recordRow) (recordIdentity, bool) {
+	columns := p.columns
+
+	var identity recordIdentity
+	var domain string
+	if !p.requiredStringValue(&domain, info, columns.domain, "domain", row) ||
+		!p.requiredStringValue(&identity.id, info, columns.id, "id", row) ||
+		!p.requiredStringValue(&identity.group, info, columns.group, "group", row) ||
+		!p.requiredStringValue(&identity.network, info, columns.network, "network", row) ||
+		!p.requiredStringValue(&identity.deployment, info, columns.deployment, "deployment", row) {
+		return recordIdentity{}, false
+	}
+	identity.domain = dns.Fqdn(domain)
+
+	return identity, true
+}
+
+// checkColumns reports required columns that are missing from record_keys,
+// rows without them are skipped.
+func (p *recordsParser) checkColumns() {
+	required := []struct {
+		name   string
+		column int
+	}{
+		{"id", p.columns.id},
+		{"instance_group", p.columns.group},
+		{"network", p.columns.network},
+		{"deployment", p.columns.deployment},
+		{"ip", p.columns.ip},
+		{"domain", p.columns.domain},
+	}
+
+	for _, column := range required {
+		if column.column < 0 {
+			p.logger.Warn("RecordSet", "Records are missing the required column %s", column.name)
+			p.problems = append(p.problems, record.Problem{
+				Kind:    record.MissingColumnProblem,
+				Field:   column.name,
+				Message: fmt.Sprintf("record_keys does not include %s", column.name),
+			})
+		}
+	}
+}
+
+func (p *recordsParser) parseHosts() ([]record.Host, error) {
+	token, err := p.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, nil
+	}
+	if token != json.Delim('[') {
+		return nil, bosherr.Errorf("Expected an array of records but found %v", token)
+	}
+
+	hosts := []record.Host{}
+	for p.decoder.More() {
+		var host [2]string // ip -> domain
+		if err := p.decoder.Decode(&host); err != nil {
+			return nil, err
+		}
+
+		hosts = append(hosts, record.Host{IP: host[0], FQDN: host[1]})
+	}
+
+	return hosts, p.expectDelim(']')
+}
+
+func (p *recordsParser) expectDelim(delim json.Delim) error {
+	token, err := p.decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != delim {
+		return bosherr.Errorf("Expected '%v' but found %v", delim, token)
+	}
+
+	return nil
+}
+
+func (p *recordsParser) intern(value string) string {
+	if interned, found := p.strings[value]; found {
+		return interned
+	}
+
+	p.strings[value] = value
+
+	return value
+}
+
+func (p *recordsParser) errorLine(err error) int {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return p.lines.lineAt(syntaxErr.Offset)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return p.lines.lineAt(typeErr.Offset)
+	}
+
+	return p.lines.lineAt(p.decoder.InputOffset())
+}
+
+// lineCounter remembers where the lines it reads end, so that the line of
+// any offset read since the previous lookup can be found.
+type lineCounter struct {
+	reader   io.Reader
+	read     int64
+	line     int
+	newlines []int64
+}
+
+func (c *lineCounter) Read(b []byte) (int, error) {
+	n, err := c.reader.Read(b)
+
+	for i, char := range b[:n] {
+		if char == '\n' {
+			c.newlines = append(c.newlines, c.read+int64(i))
+		}
+	}
+	c.read += int64(n)
+
+	return n, err
+}
+
+// lineAt returns the 1-based line of the byte at offset. Offsets must not
+// decrease between calls.
+func (c *lineCounter) lineAt(offset int64) int {
+	for len(c.newlines) > 0 && c.newlines[0] < offset {
+		c.line++
+		c.newlines = c.newlines[1:]
+	}
+
+	return c.line + 1
+}
+
+func (p *recordsParser) assertStringIntegerValue(field *string, info []interface{}, fieldIdx int, fieldName string, row recordRow) bool {
+	if fieldIdx < 0 {
+		return false
+	}
+
+	float64Value, ok := info[fieldIdx].(float64) // golang default type for numeric fields
+	if !ok {
+		p.invalidValue(info, fieldIdx, fieldName, row, "numeric")
+	}
+
+	*field = strconv.Itoa(int(float64Value))
+	return ok
+}
+
+func (p *recordsParser) convertToStringValue(field *string, info []interface{}, fieldIdx int, fieldName string, row recordRow) bool {
+	var ok bool
+	*field, ok = info[fieldIdx].(string)
+
+	if !ok {
+		p.invalidValue(info, fieldIdx, fieldName, row, "string")
+	}
+
+	return ok
+}
+
+func (p *recordsParser) optionalStringValue(field *string, info []interface{}, fieldIdx int, fieldName string, row recordRow) bool {
+	if fieldIdx >= 0 {
+		if info[fieldIdx] == nil {
+			info[fieldIdx] = ""
+			return true
+		}
+		return p.convertToStringValue(field, info, fieldIdx, fieldName, row)
+	}
+
+	return true
+}
+
+func (p *recordsParser) optionalBoolValue(field *bool, info []interface{}, fieldIdx int, fieldName string, row recordRow) bool {
+	if fieldIdx < 0 || info[fieldIdx] == nil {
+		return true
+	}
+
+	var ok bool
+	*field, ok = info[fieldIdx].(bool)
+	if !ok {
+		p.invalidValue(info, fieldIdx, fieldName, row, "boolean")
+	}
+
+	return ok
+}
+
+func (p *recordsParser) requiredStringValue(field *string, info []interface{}, fieldIdx int, fieldName string, row recordRow) bool {
+	if fieldIdx < 0 {
+		return false
+	}
+
+	return p.convertToStringValue(field, info, fieldIdx, fieldName, row)
+}
+
+func (p *recordsParser) assertStringArrayOfStringValue(field *[]string, info []interface{}, fieldIdx int, fieldName string, row recordRow) bool {
+	var ok bool
+	var intermediateField []interface{}
+
+	intermediateField, ok = info[fieldIdx].([]interface{})
+	if !ok {
+		p.invalidValue(info, fieldIdx, fieldName, row, "array of string")
+	}
+	out := make([]string, len(intermediateField))
+	for i, v := range intermediateField {
+		out[i], ok = v.(string)
+		if !ok {
+			p.invalidValue(info, fieldIdx, fieldName, row, "array of string")
+			return ok
+		}
+	}
+
+	*field = out
+
+	return ok
+}
+
+func (p *recordsParser) missingValue(fieldName string, row recordRow) {
+	p.problems = append(p.problems, record.Problem{
+		Kind:    record.MissingValueProblem,
+		Line:    row.line,
+		Record:  row.index,
+		Field:   fieldName,
+		Message: fmt.Sprintf("Record %d has an empty %s", row.index, fieldName),
+	})
+}
+
+func (p *recordsParser) invalidValue(info []interface{}, fieldIdx int, fieldName string, row recordRow, expectedType string) {
+	p.logger.Warn("RecordSet", "Value %d (%s) of record %d is not expected type of %s: %#+v (line %d)", fieldIdx, fieldName, row.index, expectedType, info[fieldIdx], row.line)
+	p.problems = append(p.problems, record.Problem{
+		Kind:    record.InvalidValueProblem,
+		Line:    row.line,
+		Record:  row.index,
+		Field:   fieldName,
+		Message: fmt.Sprintf("Value %d (%s) is not expected type of %s: %#+v", fieldIdx, fieldName, expectedType, info[fieldIdx]),
+	})
+}
@@ -0,0 +1,135 @@
+package records
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"bosh-dns/dns/server/criteria"
+	"bosh-dns/dns/server/record"
+)
+
+// validateSources reports the problems found while parsing the records files
+// of the sources together with the problems of the records they provide:
+// IPs and instance IDs that are used by more than one record, and aliases
+// that do not match any record of the snapshot.
+func validateSources(sources []*sourceState, snapshot *recordSnapshot) []record.Problem {
+	problems := []record.Problem{}
+
+	for _, source := range sources {
+		for _, sourceProblems := range [][]record.Problem{source.problems, source.deltaProblems} {
+			for _, problem := range sourceProblems {
+				problem.Source = source.Name
+				problems = append(problems, problem)
+			}
+		}
+
+		problems = append(problems, duplicateIPProblems(source)...)
+		problems = append(problems, duplicateInstanceIDProblems(source)...)
+		problems = append(problems, danglingAliasProblems(source, snapshot)...)
+	}
+
+	return problems
+}
+
+func duplicateIPProblems(source *sourceState) []record.Problem {
+	ips := []string{}
+	instances := map[string][]string{}
+
+	for _, rec := range source.records {
+		if _, found := instances[rec.IP]; !found {
+			ips = append(ips, rec.IP)
+		}
+
+		if !containsString(instances[rec.IP], rec.ID) {
+			instances[rec.IP] = append(instances[rec.IP], rec.ID)
+		}
+	}
+
+	problems := []record.Problem{}
+	for _, ip := range ips {
+		if len(instances[ip]) > 1 {
+			problems = append(problems, record.Problem{
+				Kind:    record.DuplicateIPProblem,
+				Source:  source.Name,
+				Field:   "ip",
+				Message: fmt.Sprintf("IP %s is used by instances %s", ip, strings.Join(instances[ip], ", ")),
+			})
+		}
+	}
+
+	return problems
+}
+
+func duplicateInstanceIDProblems(source *sourceState) []record.Problem {
+	type instanceKey struct {
+		id, network, domain string
+	}
+
+	keys := []instanceKey{}
+	counts := map[instanceKey]int{}
+
+	for _, rec := range source.records {
+		key := instanceKey{rec.ID, rec.Network, rec.Domain}
+		if counts[key] == 0 {
+			keys = append(keys, key)
+		}
+		counts[key]++
+	}
+
+	problems := []record.Problem{}
+	for _, key := range keys {
+		if counts[key] > 1 {
+			problems = append(problems, record.Problem{
+				Kind:    record.DuplicateInstanceIDProblem,
+				Source:  source.Name,
+				Field:   "id",
+				Message: fmt.Sprintf("Instance %s has %d records on network %s in %s", key.id, counts[key], key.network, key.domain),
+			})
+		}
+	}
+
+	return problems
+}
+
+func danglingAliasProblems(source *sourceState, snapshot *recordSnapshot) []record.Problem {
+	aliasDomains := make([]string, 0, len(source.aliasQueries))
+	for alias := range source.aliasQueries {
+		aliasDomains = append(aliasDomains, alias)
+	}
+	sort.Strings(aliasDomains)
+
+	problems := []record.Problem{}
+	for _, alias := range aliasDomains {
+		queries := source.aliasQueries[alias]
+		if !snapshot.matchesAnyQuery(queries) {
+			problems = append(problems, record.Problem{
+				Kind:    record.DanglingAliasProblem,
+				Source:  source.Name,
+				Field:   "aliases",
+				Message: fmt.Sprintf("Alias %s does not match any records with %s", alias, strings.Join(queries, ", ")),
+			})
+		}
+	}
+
+	return problems
+}
+
+// matchesAnyQuery ignores health, it only reports whether any record matches
+// one of the queries.
+func (s *recordSnapshot) matchesAnyQuery(queries []string) bool {
+	filter := &QueryFilter{}
+
+	for _, query := range queries {
+		crit, err := criteria.NewCriteria(query, s.domains)
+		if err != nil || len(crit) == 0 {
+			continue
+		}
+
+		if len(filter.Filter(crit, s.index.candidates(crit, s.records))) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
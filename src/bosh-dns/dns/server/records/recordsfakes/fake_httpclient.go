@@ -0,0 +1,116 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package recordsfakes
+
+import (
+	"bosh-dns/dns/server/records"
+	"net/http"
+	"sync"
+)
+
+type FakeHTTPClient struct {
+	GetCustomizedStub        func(string, func(*http.Request)) (*http.Response, error)
+	getCustomizedMutex       sync.RWMutex
+	getCustomizedArgsForCall []struct {
+		arg1 string
+		arg2 func(*http.Request)
+	}
+	getCustomizedReturns struct {
+		result1 *http.Response
+		result2 error
+	}
+	getCustomizedReturnsOnCall map[int]struct {
+		result1 *http.Response
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeHTTPClient) GetCustomized(arg1 string, arg2 func(*http.Request)) (*http.Response, error) {
+	fake.getCustomizedMutex.Lock()
+	ret, specificReturn := fake.getCustomizedReturnsOnCall[len(fake.getCustomizedArgsForCall)]
+	fake.getCustomizedArgsForCall = append(fake.getCustomizedArgsForCall, struct {
+		arg1 string
+		arg2 func(*http.Request)
+	}{arg1, arg2})
+	stub := fake.GetCustomizedStub
+	fakeReturns := fake.getCustomizedReturns
+	fake.recordInvocation("GetCustomized", []interface{}{arg1, arg2})
+	fake.getCustomizedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHTTPClient) GetCustomizedCallCount() int {
+	fake.getCustomizedMutex.RLock()
+	defer fake.getCustomizedMutex.RUnlock()
+	return len(fake.getCustomizedArgsForCall)
+}
+
+func (fake *FakeHTTPClient) GetCustomizedCalls(stub func(string, func(*http.Request)) (*http.Response, error)) {
+	fake.getCustomizedMutex.Lock()
+	defer fake.getCustomizedMutex.Unlock()
+	fake.GetCustomizedStub = stub
+}
+
+func (fake *FakeHTTPClient) GetCustomizedArgsForCall(i int) (string, func(*http.Request)) {
+	fake.getCustomizedMutex.RLock()
+	defer fake.getCustomizedMutex.RUnlock()
+	argsForCall := fake.getCustomizedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeHTTPClient) GetCustomizedReturns(result1 *http.Response, result2 error) {
+	fake.getCustomizedMutex.Lock()
+	defer fake.getCustomizedMutex.Unlock()
+	fake.GetCustomizedStub = nil
+	fake.getCustomizedReturns = struct {
+		result1 *http.Response
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHTTPClient) GetCustomizedReturnsOnCall(i int, result1 *http.Response, result2 error) {
+	fake.getCustomizedMutex.Lock()
+	defer fake.getCustomizedMutex.Unlock()
+	fake.GetCustomizedStub = nil
+	if fake.getCustomizedReturnsOnCall == nil {
+		fake.getCustomizedReturnsOnCall = make(map[int]struct {
+			result1 *http.Response
+			result2 error
+		})
+	}
+	fake.getCustomizedReturnsOnCall[i] = struct {
+		result1 *http.Response
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHTTPClient) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeHTTPClient) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ records.HTTPClient = new(FakeHTTPClient)
@@ -0,0 +1,64 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package recordsfakes
+
+import (
+	"bosh-dns/dns/server/records"
+	"sync"
+)
+
+type FakeRejectionCounter struct {
+	IncrementRejectedCounterStub        func()
+	incrementRejectedCounterMutex       sync.RWMutex
+	incrementRejectedCounterArgsForCall []struct {
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRejectionCounter) IncrementRejectedCounter() {
+	fake.incrementRejectedCounterMutex.Lock()
+	fake.incrementRejectedCounterArgsForCall = append(fake.incrementRejectedCounterArgsForCall, struct {
+	}{})
+	stub := fake.IncrementRejectedCounterStub
+	fake.recordInvocation("IncrementRejectedCounter", []interface{}{})
+	fake.incrementRejectedCounterMutex.Unlock()
+	if stub != nil {
+		fake.IncrementRejectedCounterStub()
+	}
+}
+
+func (fake *FakeRejectionCounter) IncrementRejectedCounterCallCount() int {
+	fake.incrementRejectedCounterMutex.RLock()
+	defer fake.incrementRejectedCounterMutex.RUnlock()
+	return len(fake.incrementRejectedCounterArgsForCall)
+}
+
+func (fake *FakeRejectionCounter) IncrementRejectedCounterCalls(stub func()) {
+	fake.incrementRejectedCounterMutex.Lock()
+	defer fake.incrementRejectedCounterMutex.Unlock()
+	fake.IncrementRejectedCounterStub = stub
+}
+
+func (fake *FakeRejectionCounter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRejectionCounter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ records.RejectionCounter = new(FakeRejectionCounter)
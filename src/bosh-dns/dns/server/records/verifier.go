@@ -0,0 +1,153 @@
+package records
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cloudfoundry/bosh-utils/system"
+
+	"bosh-dns/dns/config"
+)
+
+//counterfeiter:generate . Verifier
+
+// Verifier checks the contents of a records file against a detached
+// signature or checksum file before they are applied.
+type Verifier interface {
+	Verify(contents []byte) error
+	Path() string
+}
+
+//counterfeiter:generate . RejectionCounter
+
+type RejectionCounter interface {
+	IncrementRejectedCounter()
+}
+
+// NewVerifier returns nil when verification is not configured.
+func NewVerifier(verification config.RecordsVerification, fs system.FileSystem) (Verifier, error) {
+	switch {
+	case verification.SignatureFile != "":
+		publicKey, err := fs.ReadFile(verification.PublicKeyFile)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading records public key '%s'", verification.PublicKeyFile)
+		}
+
+		return NewSignatureVerifier(verification.SignatureFile, publicKey, fs)
+	case verification.ChecksumFile != "":
+		return NewChecksumVerifier(verification.ChecksumFile, fs), nil
+	default:
+		return nil, nil
+	}
+}
+
+type checksumVerifier struct {
+	path string
+	fs   system.FileSystem
+}
+
+// NewChecksumVerifier accepts files in the format written by sha256sum.
+func NewChecksumVerifier(path string, fs system.FileSystem) Verifier {
+	return checksumVerifier{path: path, fs: fs}
+}
+
+func (v checksumVerifier) Path() string {
+	return v.path
+}
+
+func (v checksumVerifier) Verify(contents []byte) error {
+	checksumFile, err := v.fs.ReadFileString(v.path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading records checksum '%s'", v.path)
+	}
+
+	fields := strings.Fields(checksumFile)
+	if len(fields) == 0 {
+		return bosherr.Errorf("Records checksum '%s' is empty", v.path)
+	}
+
+	expected, err := hex.DecodeString(fields[0])
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Decoding records checksum '%s'", v.path)
+	}
+
+	actual := sha256.Sum256(contents)
+	if !bytes.Equal(expected, actual[:]) {
+		return bosherr.Errorf("Records checksum mismatch: expected %x, got %x", expected, actual)
+	}
+
+	return nil
+}
+
+type signatureVerifier struct {
+	path      string
+	publicKey crypto.PublicKey
+	fs        system.FileSystem
+}
+
+// NewSignatureVerifier accepts a PEM encoded Ed25519, ECDSA or RSA public key.
+// ECDSA and RSA signatures are expected over the SHA-256 digest of the
+// records file, using ASN.1 and PKCS #1 v1.5 respectively.
+func NewSignatureVerifier(path string, publicKeyPEM []byte, fs system.FileSystem) (Verifier, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, bosherr.Error("Parsing records public key: no PEM data found")
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing records public key")
+	}
+
+	switch publicKey.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey:
+	default:
+		return nil, bosherr.Errorf("Parsing records public key: unsupported key type %T", publicKey)
+	}
+
+	return signatureVerifier{path: path, publicKey: publicKey, fs: fs}, nil
+}
+
+func (v signatureVerifier) Path() string {
+	return v.path
+}
+
+func (v signatureVerifier) Verify(contents []byte) error {
+	encoded, err := v.fs.ReadFileString(v.path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading records signature '%s'", v.path)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Decoding records signature '%s'", v.path)
+	}
+
+	digest := sha256.Sum256(contents)
+
+	valid := false
+	switch publicKey := v.publicKey.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(publicKey, contents, signature)
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(publicKey, digest[:], signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature) == nil
+	}
+
+	if !valid {
+		return bosherr.Errorf("Records signature '%s' does not match", v.path)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package records_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bosh-dns/dns/config"
+	"bosh-dns/dns/server/records"
+)
+
+var _ = Describe("Verifier", func() {
+	var (
+		fakeFileSystem *fakes.FakeFileSystem
+		contents       []byte
+	)
+
+	encodePublicKey := func(publicKey crypto.PublicKey) []byte {
+		der, err := x509.MarshalPKIXPublicKey(publicKey)
+		Expect(err).NotTo(HaveOccurred())
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	}
+
+	BeforeEach(func() {
+		fakeFileSystem = fakes.NewFakeFileSystem()
+		contents = []byte(`{"record_keys": [], "record_infos": []}`)
+	})
+
+	Describe("NewVerifier", func() {
+		It("returns no verifier when verification is not configured", func() {
+			verifier, err := records.NewVerifier(config.RecordsVerification{}, fakeFileSystem)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(verifier).To(BeNil())
+		})
+
+		It("returns a checksum verifier", func() {
+			verifier, err := records.NewVerifier(config.RecordsVerification{ChecksumFile: "/records.sha256"}, fakeFileSystem)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(verifier.Path()).To(Equal("/records.sha256"))
+		})
+
+		It("returns an error when the public key cannot be read", func() {
+			_, err := records.NewVerifier(config.RecordsVerification{SignatureFile: "/records.sig", PublicKeyFile: "/missing.pem"}, fakeFileSystem)
+			Expect(err).To(MatchError(ContainSubstring("Reading records public key '/missing.pem'")))
+		})
+	})
+
+	Describe("checksum verification", func() {
+		var verifier records.Verifier
+
+		BeforeEach(func() {
+			verifier = records.NewChecksumVerifier("/records.sha256", fakeFileSystem)
+		})
+
+		It("accepts contents matching the checksum", func() {
+			Expect(fakeFileSystem.WriteFileString("/records.sha256", fmt.Sprintf("%x  records.json\n", sha256.Sum256(contents)))).To(Succeed())
+			Expect(verifier.Verify(contents)).To(Succeed())
+		})
+
+		It("rejects contents that do not match", func() {
+			Expect(fakeFileSystem.WriteFileString("/records.sha256", fmt.Sprintf("%x", sha256.Sum256(contents)))).To(Succeed())
+			Expect(verifier.Verify(contents[:10])).To(MatchError(ContainSubstring("Records checksum mismatch")))
+		})
+
+		It("rejects contents when the checksum is missing", func() {
+			Expect(verifier.Verify(contents)).To(MatchError(ContainSubstring("Reading records checksum '/records.sha256'")))
+		})
+
+		It("rejects contents when the checksum is empty", func() {
+			Expect(fakeFileSystem.WriteFileString("/records.sha256", "\n")).To(Succeed())
+			Expect(verifier.Verify(contents)).To(MatchError("Records checksum '/records.sha256' is empty"))
+		})
+	})
+
+	Describe("signature verification", func() {
+		writeSignature := func(signature []byte) {
+			Expect(fakeFileSystem.WriteFileString("/records.sig", base64.StdEncoding.EncodeToString(signature)+"\n")).To(Succeed())
+		}
+
+		Context("with an Ed25519 key", func() {
+			var (
+				verifier   records.Verifier
+				privateKey ed25519.PrivateKey
+			)
+
+			BeforeEach(func() {
+				publicKey, key, err := ed25519.GenerateKey(rand.Reader)
+				Expect(err).NotTo(HaveOccurred())
+				privateKey = key
+
+				verifier, err = records.NewSignatureVerifier("/records.sig", encodePublicKey(publicKey), fakeFileSystem)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("accepts signed contents", func() {
+				writeSignature(ed25519.Sign(privateKey, contents))
+				Expect(verifier.Verify(contents)).To(Succeed())
+			})
+
+			It("rejects tampered contents", func() {
+				writeSignature(ed25519.Sign(privateKey, contents))
+				Expect(verifier.Verify(append(contents, ' '))).To(MatchError("Records signature '/records.sig' does not match"))
+			})
+
+			It("rejects signatures that are not base64", func() {
+				Expect(fakeFileSystem.WriteFileString("/records.sig", "!!!")).To(Succeed())
+				Expect(verifier.Verify(contents)).To(MatchError(ContainSubstring("Decoding records signature '/records.sig'")))
+			})
+		})
+
+		Context("with an ECDSA key", func() {
+			It("verifies the signature over the SHA-256 digest", func() {
+				privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+				Expect(err).NotTo(HaveOccurred())
+
+				verifier, err := records.NewSignatureVerifier("/records.sig", encodePublicKey(&privateKey.PublicKey), fakeFileSystem)
+				Expect(err).NotTo(HaveOccurred())
+
+				digest := sha256.Sum256(contents)
+				signature, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+				Expect(err).NotTo(HaveOccurred())
+				writeSignature(signature)
+
+				Expect(verifier.Verify(contents)).To(Succeed())
+				Expect(verifier.Verify(contents[1:])).NotTo(Succeed())
+			})
+		})
+
+		Context("with an RSA key", func() {
+			It("verifies the PKCS #1 v1.5 signature over the SHA-256 digest", func() {
+				privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+				Expect(err).NotTo(HaveOccurred())
+
+				verifier, err := records.NewSignatureVerifier("/records.sig", encodePublicKey(&privateKey.PublicKey), fakeFileSystem)
+				Expect(err).NotTo(HaveOccurred())
+
+				digest := sha256.Sum256(contents)
+				signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+				Expect(err).NotTo(HaveOccurred())
+				writeSignature(signature)
+
+				Expect(verifier.Verify(contents)).To(Succeed())
+				Expect(verifier.Verify(contents[1:])).NotTo(Succeed())
+			})
+		})
+
+		It("returns an error for a malformed public key", func() {
+			_, err := records.NewSignatureVerifier("/records.sig", []byte("not a key"), fakeFileSystem)
+			Expect(err).To(MatchError("Parsing records public key: no PEM data found"))
+		})
+	})
+})
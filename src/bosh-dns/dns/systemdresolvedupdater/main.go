@@ -35,8 +35,19 @@ func main() {
 	logr := logger.NewLogger(level)
 
 	shutdown := make(chan struct{})
-	fileReader := records.NewFileReader(boshDnsConfig.RecordsFile, system.NewOsFileSystem(logr), clock.NewClock(), logr, shutdown)
 	fs := system.NewOsFileSystem(logr)
+
+	recordsSourceName := boshDnsConfig.RecordsFile
+	var fileReader records.FileReader
+	if boshDnsConfig.RemoteRecords.Enabled() {
+		recordsSourceName = boshDnsConfig.RemoteRecords.URL
+		fileReader, err = records.NewRemoteFileReader(boshDnsConfig.RemoteRecords, time.Duration(boshDnsConfig.RequestTimeout), fs, clock.NewClock(), logr, shutdown)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		fileReader = records.NewFileReader(boshDnsConfig.RecordsFile, fs, clock.NewClock(), logr, shutdown)
+	}
 	healthWatcher := healthiness.NewNopHealthWatcher()
 
 	aliasConfiguration, err := aliases.ConfigFromGlob(
@@ -50,7 +61,19 @@ func main() {
 
 	filtererFactory := records.NewHealthFiltererFactory(healthWatcher, time.Duration(boshDnsConfig.Health.SynchronousCheckTimeout))
 
-	recordSet, err := records.NewRecordSet(fileReader, aliasConfiguration, healthWatcher, uint(boshDnsConfig.Health.MaxTrackedQueries), shutdown, logr, filtererFactory, records.NewAliasEncoder())
+	additionalSources, err := records.NewSources(boshDnsConfig.RecordsSources, fs, clock.NewClock(), logr, shutdown)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mainSource := records.Source{Name: recordsSourceName, Reader: fileReader}
+	if boshDnsConfig.RecordsDeltaFile != "" {
+		mainSource.DeltaReader = records.NewFileReader(boshDnsConfig.RecordsDeltaFile, fs, clock.NewClock(), logr, shutdown)
+	}
+
+	recordsSources := append([]records.Source{mainSource}, additionalSources...)
+
+	recordSet, err := records.NewMultiSourceRecordSet(recordsSources, aliasConfiguration, healthWatcher, uint(boshDnsConfig.Health.MaxTrackedQueries), shutdown, logr, clock.NewClock(), filtererFactory, records.NewAliasEncoder())
 	if err != nil {
 		log.Fatal(err)
 	}
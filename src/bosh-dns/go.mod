@@ -9,6 +9,7 @@ require (
 	github.com/cloudfoundry/bosh-utils v0.0.636
 	github.com/cloudfoundry/gosigar v1.3.126
 	github.com/coredns/coredns v1.14.6
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/maxbrunsfeld/counterfeiter/v6 v6.12.2
 	github.com/miekg/dns v1.1.72
 	github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d
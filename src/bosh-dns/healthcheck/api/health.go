@@ -1,5 +1,7 @@
 package api
 
+import "time"
+
 type HealthStatus string
 
 const (
@@ -10,4 +12,30 @@ const (
 type HealthResult struct {
 	State      HealthStatus            `json:"state"`
 	GroupState map[string]HealthStatus `json:"group_state,omitempty"`
+
+	// GroupReason explains the state of groups whose health executable timed
+	// out or reported a reason on stdout.
+	GroupReason map[string]string `json:"group_reason,omitempty"`
+}
+
+// HealthTransition is a change of the health state of an instance, or of one
+// of its groups when Group is set.
+type HealthTransition struct {
+	Group string       `json:"group,omitempty"`
+	From  HealthStatus `json:"from"`
+	To    HealthStatus `json:"to"`
+	At    time.Time    `json:"at"`
 }
+
+// HealthCheckSchedule describes when the health of an instance was last
+// checked and when it is checked next.
+type HealthCheckSchedule struct {
+	LastCheck    time.Time     `json:"last_check"`
+	NextCheck    time.Time     `json:"next_check"`
+	Interval     time.Duration `json:"interval"`
+	StableChecks int           `json:"stable_checks"`
+}
+
+// HealthStreamHeartbeat is how often the health stream repeats the current
+// health result while it does not change.
+const HealthStreamHeartbeat = 10 * time.Second
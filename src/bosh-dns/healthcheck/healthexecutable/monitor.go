@@ -2,7 +2,9 @@ package healthexecutable
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"reflect"
 	"sync"
 	"time"
 
@@ -11,13 +13,37 @@ import (
 	"github.com/cloudfoundry/bosh-utils/system"
 
 	"bosh-dns/healthcheck/api"
+	"bosh-dns/healthcheck/healthprobe"
 	"bosh-dns/healthconfig"
 )
 
+const (
+	// DefaultExecutableTimeout is how long a health executable may run when no
+	// timeout is configured.
+	DefaultExecutableTimeout = 10 * time.Second
+
+	// ExecutableKillGracePeriod is how long a health executable that timed out
+	// gets to exit after being asked to terminate before it is killed.
+	ExecutableKillGracePeriod = 2 * time.Second
+)
+
 type agentHealth struct {
 	State api.HealthStatus `json:"state"`
 }
 
+// executableOutput is the optional JSON a health executable may print on
+// stdout to report its status with a reason.
+type executableOutput struct {
+	Status api.HealthStatus `json:"status"`
+	Reason string           `json:"reason"`
+}
+
+// checkResult is the outcome of the health executable or the probes of a job.
+type checkResult struct {
+	status api.HealthStatus
+	reason string
+}
+
 type Monitor struct {
 	clock          clock.Clock
 	cmdRunner      system.CmdRunner
@@ -25,20 +51,29 @@ type Monitor struct {
 	interval       time.Duration
 	jobs           []healthconfig.Job
 	logger         logger.Logger
+	prober         healthprobe.Prober
 	mutex          *sync.Mutex
 	shutdown       chan struct{}
 	status         api.HealthResult
+	subscribers    map[chan struct{}]struct{}
+	timeout        time.Duration
 }
 
 func NewMonitor(
 	healthFilePath string,
 	jobs []healthconfig.Job,
 	cmdRunner system.CmdRunner,
+	prober healthprobe.Prober,
 	clock clock.Clock,
 	interval time.Duration,
+	timeout time.Duration,
 	shutdown chan struct{},
 	logger logger.Logger,
 ) *Monitor {
+	if timeout <= 0 {
+		timeout = DefaultExecutableTimeout
+	}
+
 	monitor := &Monitor{
 		clock:          clock,
 		cmdRunner:      cmdRunner,
@@ -46,8 +81,11 @@ func NewMonitor(
 		interval:       interval,
 		jobs:           jobs,
 		logger:         logger,
+		prober:         prober,
 		mutex:          &sync.Mutex{},
 		shutdown:       shutdown,
+		subscribers:    map[chan struct{}]struct{}{},
+		timeout:        timeout,
 		status: api.HealthResult{
 			State: api.StatusFailing,
 		},
@@ -65,6 +103,23 @@ func (m *Monitor) Status() api.HealthResult {
 	return m.status
 }
 
+// Subscribe returns a channel that receives a value whenever the status
+// changes. Changes that happen before the previous one was received are
+// coalesced. The returned function ends the subscription.
+func (m *Monitor) Subscribe() (<-chan struct{}, func()) {
+	changes := make(chan struct{}, 1)
+
+	m.mutex.Lock()
+	m.subscribers[changes] = struct{}{}
+	m.mutex.Unlock()
+
+	return changes, func() {
+		m.mutex.Lock()
+		delete(m.subscribers, changes)
+		m.mutex.Unlock()
+	}
+}
+
 func (m *Monitor) run() {
 	timer := m.clock.NewTimer(m.interval)
 	m.logger.Debug("Monitor", "starting monitor with interval %v", m.interval)
@@ -86,27 +141,29 @@ func (m *Monitor) runChecks() {
 	agentStatus := m.readAgentHealth()
 
 	groupState := make(map[string]api.HealthStatus)
+	var groupReason map[string]string
 	groupsWithoutExecutable := []healthconfig.LinkMetadata{}
-	checkedResults := make(map[string]api.HealthStatus)
+	checkedResults := m.runJobChecks()
 
 	allStatus := agentStatus
-	for _, job := range m.jobs {
-		if job.HealthExecutablePath == "" {
+	for i, job := range m.jobs {
+		if job.HealthExecutablePath == "" && len(job.Probes) == 0 && job.MetadataError == "" {
 			groupsWithoutExecutable = append(groupsWithoutExecutable, job.Groups...)
 			continue
 		}
 
-		var executableStatus api.HealthStatus
-		var ok bool
-		if executableStatus, ok = checkedResults[job.HealthExecutablePath]; !ok {
-			executableStatus = m.executableStatus(job.HealthExecutablePath)
-			checkedResults[job.HealthExecutablePath] = executableStatus
-		}
+		jobResult := checkedResults[i]
+		setStateForGroupIDs(groupState, job.Groups, jobResult.status)
 
-		setStateForGroupIDs(groupState, job.Groups, executableStatus)
+		if jobResult.reason != "" {
+			if groupReason == nil {
+				groupReason = make(map[string]string)
+			}
+			setReasonForGroupIDs(groupReason, job.Groups, jobResult.reason)
+		}
 
-		if notRunning(executableStatus) {
-			allStatus = executableStatus
+		if notRunning(jobResult.status) {
+			allStatus = jobResult.status
 		}
 	}
 
@@ -115,34 +172,152 @@ func (m *Monitor) runChecks() {
 	m.logger.Debug("Monitor", "Health status: %+v", allStatus)
 	m.logger.Debug("Monitor", "Group state: %+v", groupState)
 	oldStatus := m.status
-	m.setHealthResult(allStatus, groupState)
+	m.setHealthResult(api.HealthResult{State: allStatus, GroupState: groupState, GroupReason: groupReason})
 	if oldStatus.State != m.status.State {
 		m.logger.Info("Monitor", "Status changed from %s to %s", oldStatus.State, m.status.State)
 	}
 }
 
-func (m *Monitor) setHealthResult(status api.HealthStatus, groupState map[string]api.HealthStatus) {
+// runJobChecks runs every distinct health executable and the probes of every
+// job concurrently, so that a slow check does not hold up the checks of the
+// other jobs. The result of a job fails when either its executable or one of
+// its probes fails, or when its health metadata could not be parsed.
+func (m *Monitor) runJobChecks() []checkResult {
+	executableResults := make(map[string]checkResult)
+	probeResults := make([]checkResult, len(m.jobs))
+	mutex := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+
+	started := make(map[string]bool)
+	for i, job := range m.jobs {
+		if len(job.Probes) > 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				probeResults[i] = m.probesStatus(job.Probes)
+			}()
+		}
+
+		executablePath := job.HealthExecutablePath
+		if executablePath == "" || started[executablePath] {
+			continue
+		}
+		started[executablePath] = true
+
+		timeout := m.timeout
+		if job.HealthExecutableTimeout > 0 {
+			timeout = job.HealthExecutableTimeout
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := m.executableStatus(executablePath, timeout)
+
+			mutex.Lock()
+			executableResults[executablePath] = result
+			mutex.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	results := make([]checkResult, len(m.jobs))
+	for i, job := range m.jobs {
+		results[i] = checkResult{status: api.StatusRunning}
+
+		if job.HealthExecutablePath != "" {
+			results[i] = executableResults[job.HealthExecutablePath]
+		}
+
+		if len(job.Probes) > 0 && !notRunning(results[i].status) {
+			results[i] = probeResults[i]
+		}
+
+		if job.MetadataError != "" && !notRunning(results[i].status) {
+			m.logger.Warn("Monitor", "Invalid health metadata: %s", job.MetadataError)
+			results[i] = checkResult{status: api.StatusFailing, reason: job.MetadataError}
+		}
+	}
+
+	return results
+}
+
+func (m *Monitor) probesStatus(probes []healthconfig.Probe) checkResult {
+	for _, probe := range probes {
+		if err := m.prober.Probe(probe); err != nil {
+			m.logger.Warn("Monitor", "Probe %+v failed: %s", probe, err.Error())
+			return checkResult{status: api.StatusFailing, reason: err.Error()}
+		}
+	}
+
+	return checkResult{status: api.StatusRunning}
+}
+
+func (m *Monitor) setHealthResult(newStatus api.HealthResult) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
-	m.status = api.HealthResult{State: status, GroupState: groupState}
+
+	if reflect.DeepEqual(m.status, newStatus) {
+		return
+	}
+	m.status = newStatus
+
+	for changes := range m.subscribers {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
 }
 
-func (m *Monitor) executableStatus(executablePath string) api.HealthStatus {
-	stdout, stderr, exitStatus, err := m.runExecutable(executablePath)
-	m.logger.Debug("Monitor", "Script %s stdout: %s", executablePath, stdout)
-	m.logger.Debug("Monitor", "Script %s stderr: %s", executablePath, stderr)
+func (m *Monitor) executableStatus(executablePath string, timeout time.Duration) checkResult {
+	process, err := m.cmdRunner.RunComplexCommandAsync(m.executableCommand(executablePath))
 	if err != nil {
 		m.logger.Warn("Monitor", "Error occurred executing '%s': %s", executablePath, err.Error())
-		return api.StatusFailing
+		return checkResult{status: api.StatusFailing}
 	}
 
-	if exitStatus != 0 {
-		m.logger.Warn("Monitor", "Error occurred executing '%s': %s", executablePath, exitStatus)
-		return api.StatusFailing
+	timer := m.clock.NewTimer(timeout)
+	defer timer.Stop()
+
+	var result system.Result
+	select {
+	case result = <-process.Wait():
+	case <-timer.C():
+		m.logger.Warn("Monitor", "Script %s timed out after %s", executablePath, timeout)
+		if err := process.TerminateNicely(ExecutableKillGracePeriod); err != nil {
+			m.logger.Warn("Monitor", "Error occurred terminating '%s': %s", executablePath, err.Error())
+		}
+		return checkResult{status: api.StatusFailing, reason: fmt.Sprintf("timed out after %s", timeout)}
+	}
+
+	m.logger.Debug("Monitor", "Script %s stdout: %s", executablePath, result.Stdout)
+	m.logger.Debug("Monitor", "Script %s stderr: %s", executablePath, result.Stderr)
+
+	var output executableOutput
+	if err := json.Unmarshal([]byte(result.Stdout), &output); err != nil {
+		output = executableOutput{}
+	}
+
+	if result.Error != nil {
+		m.logger.Warn("Monitor", "Error occurred executing '%s': %s", executablePath, result.Error.Error())
+		return checkResult{status: api.StatusFailing, reason: output.Reason}
+	}
+
+	if result.ExitStatus != 0 {
+		m.logger.Warn("Monitor", "Error occurred executing '%s': %d", executablePath, result.ExitStatus)
+		return checkResult{status: api.StatusFailing, reason: output.Reason}
+	}
+
+	if output.Status != "" && notRunning(output.Status) {
+		m.logger.Warn("Monitor", "Script %s reports %s: %s", executablePath, output.Status, output.Reason)
+		return checkResult{status: api.StatusFailing, reason: output.Reason}
 	}
 
 	m.logger.Debug("Monitor", "Script %s completed successfully", executablePath)
-	return api.StatusRunning
+	return checkResult{status: api.StatusRunning}
 }
 
 func (m *Monitor) readAgentHealth() api.HealthStatus {
@@ -174,6 +349,12 @@ func setStateForGroupIDs(groupState map[string]api.HealthStatus, linkMetadata []
 	}
 }
 
+func setReasonForGroupIDs(groupReason map[string]string, linkMetadata []healthconfig.LinkMetadata, reason string) {
+	for _, linkMetadatum := range linkMetadata {
+		groupReason[linkMetadatum.Group] = reason
+	}
+}
+
 func notRunning(status api.HealthStatus) bool {
 	return status != api.StatusRunning
 }
@@ -5,16 +5,19 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"code.cloudfoundry.org/clock/fakeclock"
 	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	sysfakes "github.com/cloudfoundry/bosh-utils/system/fakes"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"bosh-dns/healthcheck/api"
 	"bosh-dns/healthcheck/healthexecutable"
+	"bosh-dns/healthcheck/healthprobe/healthprobefakes"
 	"bosh-dns/healthconfig"
 )
 
@@ -22,10 +25,12 @@ var _ = Describe("Monitor", func() {
 	var (
 		clock                  *fakeclock.FakeClock
 		cmdRunner              *sysfakes.FakeCmdRunner
+		prober                 *healthprobefakes.FakeProber
 		jobs                   []healthconfig.Job
 		healthExecutablePrefix string
 		healthFile             *os.File
 		interval               time.Duration
+		timeout                time.Duration
 		logger                 *loggerfakes.FakeLogger
 		monitor                *healthexecutable.Monitor
 		signal                 chan struct{}
@@ -46,7 +51,9 @@ var _ = Describe("Monitor", func() {
 		logger = &loggerfakes.FakeLogger{}
 		clock = fakeclock.NewFakeClock(time.Now())
 		cmdRunner = sysfakes.NewFakeCmdRunner()
+		prober = &healthprobefakes.FakeProber{}
 		interval = time.Millisecond
+		timeout = time.Minute
 
 		healthFile, err = os.CreateTemp("", "health-executable-state")
 		Expect(err).NotTo(HaveOccurred())
@@ -68,8 +75,10 @@ var _ = Describe("Monitor", func() {
 			healthFile.Name(),
 			jobs,
 			cmdRunner,
+			prober,
 			clock,
 			interval,
+			timeout,
 			signal,
 			logger,
 		)
@@ -83,8 +92,8 @@ var _ = Describe("Monitor", func() {
 		}
 	})
 
-	addCmdResult := func(executablePath string, result sysfakes.FakeCmdResult) {
-		cmdRunner.AddCmdResult(healthExecutablePrefix+executablePath, result)
+	addProcess := func(executablePath string, result boshsys.Result) {
+		cmdRunner.AddProcess(healthExecutablePrefix+executablePath, &sysfakes.FakeProcess{WaitResult: result})
 	}
 
 	It("returns status true", func() {
@@ -125,6 +134,37 @@ var _ = Describe("Monitor", func() {
 		})
 	})
 
+	Describe("Subscribe", func() {
+		It("notifies subscribers when the status changes", func() {
+			changes, unsubscribe := monitor.Subscribe()
+			defer unsubscribe()
+
+			clock.WaitForWatcherAndIncrement(interval)
+			Consistently(changes).ShouldNot(Receive())
+
+			writeState("failing")
+			clock.WaitForWatcherAndIncrement(interval)
+			Eventually(changes).Should(Receive())
+			Expect(monitor.Status().State).To(Equal(api.StatusFailing))
+
+			clock.WaitForWatcherAndIncrement(interval)
+			Consistently(changes).ShouldNot(Receive())
+		})
+
+		It("stops notifying after unsubscribing", func() {
+			changes, unsubscribe := monitor.Subscribe()
+			unsubscribe()
+
+			writeState("failing")
+			clock.WaitForWatcherAndIncrement(interval)
+			Eventually(monitor.Status).Should(Equal(api.HealthResult{
+				State:      api.StatusFailing,
+				GroupState: make(map[string]api.HealthStatus),
+			}))
+			Consistently(changes).ShouldNot(Receive())
+		})
+	})
+
 	Context("when the agent's health file is invalid", func() {
 		Context("with invalid json", func() {
 			BeforeEach(func() {
@@ -165,25 +205,25 @@ var _ = Describe("Monitor", func() {
 
 		Context("when some executables go unhealthy and they become healthy again", func() {
 			BeforeEach(func() {
-				addCmdResult(jobs[0].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
-				addCmdResult(jobs[1].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
-				addCmdResult(jobs[2].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
+				addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+				addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+				addProcess(jobs[2].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
 
-				addCmdResult(jobs[0].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
-				addCmdResult(jobs[1].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 1})
-				addCmdResult(jobs[2].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
+				addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+				addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 1})
+				addProcess(jobs[2].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
 
-				addCmdResult(jobs[0].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
-				addCmdResult(jobs[1].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
-				addCmdResult(jobs[2].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
+				addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+				addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+				addProcess(jobs[2].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
 
-				addCmdResult(jobs[0].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
-				addCmdResult(jobs[1].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
-				addCmdResult(jobs[2].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 1})
+				addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+				addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+				addProcess(jobs[2].HealthExecutablePath, boshsys.Result{ExitStatus: 1})
 			})
 
 			It("starts with the result of the first set of commands", func() {
-				Expect(cmdRunner.RunCommands).To(HaveLen(3))
+				Expect(cmdRunner.RunComplexCommands).To(HaveLen(3))
 				Expect(monitor.Status()).To(Equal(api.HealthResult{
 					State:      api.StatusRunning,
 					GroupState: make(map[string]api.HealthStatus),
@@ -196,29 +236,29 @@ var _ = Describe("Monitor", func() {
 					State:      api.StatusFailing,
 					GroupState: make(map[string]api.HealthStatus),
 				}))
-				Eventually(cmdRunner.RunCommands).Should(HaveLen(6))
+				Eventually(cmdRunner.RunComplexCommands).Should(HaveLen(6))
 
 				clock.WaitForWatcherAndIncrement(interval)
 				Eventually(monitor.Status).Should(Equal(api.HealthResult{
 					State:      api.StatusRunning,
 					GroupState: make(map[string]api.HealthStatus),
 				}))
-				Eventually(cmdRunner.RunCommands).Should(HaveLen(9))
+				Eventually(cmdRunner.RunComplexCommands).Should(HaveLen(9))
 
 				clock.WaitForWatcherAndIncrement(interval)
 				Eventually(monitor.Status).Should(Equal(api.HealthResult{
 					State:      api.StatusFailing,
 					GroupState: make(map[string]api.HealthStatus),
 				}))
-				Eventually(cmdRunner.RunCommands).Should(HaveLen(12))
+				Eventually(cmdRunner.RunComplexCommands).Should(HaveLen(12))
 			})
 		})
 
 		Context("when executing an executable returns an error", func() {
 			BeforeEach(func() {
-				addCmdResult(jobs[0].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
-				addCmdResult(jobs[1].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0, Error: errors.New("can't do that")})
-				addCmdResult(jobs[2].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
+				addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+				addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 0, Error: errors.New("can't do that")})
+				addProcess(jobs[2].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
 			})
 
 			It("logs an error", func() {
@@ -234,8 +274,14 @@ var _ = Describe("Monitor", func() {
 		})
 
 		Context("when shutting down", func() {
+			BeforeEach(func() {
+				addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 1})
+				addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 1})
+				addProcess(jobs[2].HealthExecutablePath, boshsys.Result{ExitStatus: 1})
+			})
+
 			It("stops calling the executables", func() {
-				Eventually(cmdRunner.RunCommands).Should(HaveLen(3))
+				Eventually(cmdRunner.RunComplexCommands).Should(HaveLen(3))
 				Eventually(monitor.Status).Should(Equal(api.HealthResult{
 					State:      api.StatusFailing,
 					GroupState: make(map[string]api.HealthStatus),
@@ -247,7 +293,7 @@ var _ = Describe("Monitor", func() {
 
 				Eventually(clock.WatcherCount).Should(Equal(0))
 				clock.Increment(interval * 2)
-				Consistently(cmdRunner.RunCommands).Should(HaveLen(3))
+				Consistently(cmdRunner.RunComplexCommands).Should(HaveLen(3))
 				Consistently(monitor.Status).Should(Equal(api.HealthResult{
 					State:      api.StatusFailing,
 					GroupState: make(map[string]api.HealthStatus),
@@ -256,6 +302,212 @@ var _ = Describe("Monitor", func() {
 		})
 	})
 
+	Context("when executables report their status on stdout", func() {
+		BeforeEach(func() {
+			jobs = []healthconfig.Job{
+				{HealthExecutablePath: "e1", Groups: []healthconfig.LinkMetadata{{Group: "1"}}},
+				{HealthExecutablePath: "e2", Groups: []healthconfig.LinkMetadata{{Group: "2"}}},
+				{HealthExecutablePath: "e3", Groups: []healthconfig.LinkMetadata{{Group: "3"}}},
+			}
+
+			addProcess(jobs[0].HealthExecutablePath, boshsys.Result{Stdout: `{"status":"failing","reason":"database unreachable"}`})
+			addProcess(jobs[1].HealthExecutablePath, boshsys.Result{Stdout: `{"reason":"disk full"}`, ExitStatus: 1})
+			addProcess(jobs[2].HealthExecutablePath, boshsys.Result{Stdout: "all good"})
+		})
+
+		It("reports the status and the reason for each group", func() {
+			Expect(monitor.Status()).To(Equal(api.HealthResult{
+				State: api.StatusFailing,
+				GroupState: map[string]api.HealthStatus{
+					"1": api.StatusFailing,
+					"2": api.StatusFailing,
+					"3": api.StatusRunning,
+				},
+				GroupReason: map[string]string{
+					"1": "database unreachable",
+					"2": "disk full",
+				},
+			}))
+		})
+	})
+
+	Context("when an executable does not finish in time", func() {
+		var (
+			started    chan struct{}
+			terminated chan struct{}
+		)
+
+		BeforeEach(func() {
+			jobs = []healthconfig.Job{
+				{HealthExecutablePath: "e1", Groups: []healthconfig.LinkMetadata{{Group: "1"}}},
+				{HealthExecutablePath: "e2", Groups: []healthconfig.LinkMetadata{{Group: "2"}}},
+			}
+
+			addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+			addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+
+			terminated = make(chan struct{})
+			cmdRunner.AddProcess(healthExecutablePrefix+jobs[0].HealthExecutablePath, &sysfakes.FakeProcess{
+				TerminatedNicelyCallBack: func(process *sysfakes.FakeProcess) {
+					close(terminated)
+					process.WaitCh <- boshsys.Result{ExitStatus: 143}
+				},
+			})
+			addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+
+			started = make(chan struct{}, 2)
+			cmdRunner.SetCmdCallback(healthExecutablePrefix+jobs[1].HealthExecutablePath, func() {
+				started <- struct{}{}
+			})
+		})
+
+		It("runs the other executables meanwhile and kills it after the timeout", func() {
+			clock.WaitForWatcherAndIncrement(interval)
+			Eventually(started).Should(HaveLen(2))
+			Eventually(clock.WatcherCount).Should(Equal(1))
+			Consistently(terminated).ShouldNot(BeClosed())
+
+			clock.Increment(timeout)
+			Eventually(terminated).Should(BeClosed())
+			Eventually(monitor.Status).Should(Equal(api.HealthResult{
+				State: api.StatusFailing,
+				GroupState: map[string]api.HealthStatus{
+					"1": api.StatusFailing,
+					"2": api.StatusRunning,
+				},
+				GroupReason: map[string]string{
+					"1": "timed out after 1m0s",
+				},
+			}))
+		})
+
+		Context("when the job overrides the timeout", func() {
+			BeforeEach(func() {
+				jobs[0].HealthExecutableTimeout = 3 * time.Minute
+			})
+
+			It("kills the executable after the timeout of the job", func() {
+				clock.WaitForWatcherAndIncrement(interval)
+				Eventually(started).Should(HaveLen(2))
+				Eventually(clock.WatcherCount).Should(Equal(1))
+
+				clock.Increment(timeout)
+				Consistently(terminated).ShouldNot(BeClosed())
+
+				clock.Increment(2 * time.Minute)
+				Eventually(terminated).Should(BeClosed())
+				Eventually(monitor.Status).Should(Equal(api.HealthResult{
+					State: api.StatusFailing,
+					GroupState: map[string]api.HealthStatus{
+						"1": api.StatusFailing,
+						"2": api.StatusRunning,
+					},
+					GroupReason: map[string]string{
+						"1": "timed out after 3m0s",
+					},
+				}))
+			})
+		})
+	})
+
+	Context("when jobs declare probes", func() {
+		var (
+			healthProbe   healthconfig.Probe
+			databaseProbe healthconfig.Probe
+			databaseDown  *atomic.Bool
+		)
+
+		BeforeEach(func() {
+			healthProbe = healthconfig.Probe{Type: healthconfig.ProbeTypeHTTP, URL: "http://127.0.0.1:8080/health"}
+			databaseProbe = healthconfig.Probe{Type: healthconfig.ProbeTypeTCP, Address: "127.0.0.1:5432"}
+
+			databaseDown = &atomic.Bool{}
+			prober.ProbeStub = func(probe healthconfig.Probe) error {
+				if probe == databaseProbe && databaseDown.Load() {
+					return errors.New("connection refused")
+				}
+				return nil
+			}
+
+			jobs = []healthconfig.Job{
+				{Probes: []healthconfig.Probe{healthProbe}, Groups: []healthconfig.LinkMetadata{{Group: "1"}}},
+				{HealthExecutablePath: "e2", Probes: []healthconfig.Probe{databaseProbe}, Groups: []healthconfig.LinkMetadata{{Group: "2"}}},
+				{Groups: []healthconfig.LinkMetadata{{Group: "3"}}},
+			}
+
+			addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+			addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+			addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 1})
+		})
+
+		It("reports the probe results for the groups of the job", func() {
+			Expect(monitor.Status()).To(Equal(api.HealthResult{
+				State: api.StatusRunning,
+				GroupState: map[string]api.HealthStatus{
+					"1": api.StatusRunning,
+					"2": api.StatusRunning,
+					"3": api.StatusRunning,
+				},
+			}))
+			Expect(prober.ProbeCallCount()).To(Equal(2))
+
+			databaseDown.Store(true)
+			clock.WaitForWatcherAndIncrement(interval)
+			Eventually(monitor.Status).Should(Equal(api.HealthResult{
+				State: api.StatusFailing,
+				GroupState: map[string]api.HealthStatus{
+					"1": api.StatusRunning,
+					"2": api.StatusFailing,
+					"3": api.StatusFailing,
+				},
+				GroupReason: map[string]string{
+					"2": "connection refused",
+				},
+			}))
+		})
+
+		It("reports the executable result when the executable fails", func() {
+			clock.WaitForWatcherAndIncrement(interval)
+			Eventually(prober.ProbeCallCount).Should(Equal(4))
+
+			clock.WaitForWatcherAndIncrement(interval)
+			Eventually(monitor.Status).Should(Equal(api.HealthResult{
+				State: api.StatusFailing,
+				GroupState: map[string]api.HealthStatus{
+					"1": api.StatusRunning,
+					"2": api.StatusFailing,
+					"3": api.StatusFailing,
+				},
+			}))
+			Expect(prober.ProbeCallCount()).To(Equal(6))
+		})
+	})
+
+	Context("when the health metadata of a job is invalid", func() {
+		BeforeEach(func() {
+			jobs = []healthconfig.Job{
+				{MetadataError: "Validating health probes of job 'job-a': Missing address for tcp probe", Groups: []healthconfig.LinkMetadata{{Group: "1"}}},
+				{Probes: []healthconfig.Probe{{Type: healthconfig.ProbeTypeTCP, Address: "127.0.0.1:5432"}}, Groups: []healthconfig.LinkMetadata{{Group: "2"}}},
+				{Groups: []healthconfig.LinkMetadata{{Group: "3"}}},
+			}
+		})
+
+		It("reports the job as failing and keeps checking the other jobs", func() {
+			Expect(monitor.Status()).To(Equal(api.HealthResult{
+				State: api.StatusFailing,
+				GroupState: map[string]api.HealthStatus{
+					"1": api.StatusFailing,
+					"2": api.StatusRunning,
+					"3": api.StatusFailing,
+				},
+				GroupReason: map[string]string{
+					"1": "Validating health probes of job 'job-a': Missing address for tcp probe",
+				},
+			}))
+			Expect(prober.ProbeCallCount()).To(Equal(1))
+		})
+	})
+
 	Context("when there are groups present", func() {
 		Context("and the groups have no executables", func() {
 			BeforeEach(func() {
@@ -319,17 +571,17 @@ var _ = Describe("Monitor", func() {
 					{HealthExecutablePath: "", Groups: []healthconfig.LinkMetadata{{Group: "3"}}},
 				}
 
-				addCmdResult(jobs[0].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
-				addCmdResult(jobs[1].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
+				addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+				addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
 
-				addCmdResult(jobs[0].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
-				addCmdResult(jobs[1].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 1})
+				addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+				addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 1})
 
-				addCmdResult(jobs[0].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
-				addCmdResult(jobs[1].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
+				addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+				addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
 
-				addCmdResult(jobs[0].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 1})
-				addCmdResult(jobs[1].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
+				addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 1})
+				addProcess(jobs[1].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
 			})
 
 			It("reports the executable status for each group", func() {
@@ -380,9 +632,9 @@ var _ = Describe("Monitor", func() {
 						{HealthExecutablePath: "duplicate-executable", Groups: []healthconfig.LinkMetadata{{Group: "2"}}},
 					}
 
-					addCmdResult(jobs[0].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
-					addCmdResult(jobs[0].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 1})
-					addCmdResult(jobs[0].HealthExecutablePath, sysfakes.FakeCmdResult{ExitStatus: 0})
+					addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
+					addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 1})
+					addProcess(jobs[0].HealthExecutablePath, boshsys.Result{ExitStatus: 0})
 				})
 
 				It("only executes the executable once", func() {
@@ -2,6 +2,11 @@
 
 package healthexecutable
 
-func (m *Monitor) runExecutable(executable string) (stdout, stderr string, exitStatus int, err error) {
-	return m.cmdRunner.RunCommand(executable)
+import "github.com/cloudfoundry/bosh-utils/system"
+
+// executableCommand runs the executable in its own process group, so that
+// terminating a timed out executable also terminates the processes it
+// started.
+func (m *Monitor) executableCommand(executable string) system.Command {
+	return system.Command{Name: executable, KeepAttached: false}
 }
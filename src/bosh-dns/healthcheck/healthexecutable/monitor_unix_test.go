@@ -0,0 +1,95 @@
+//go:build !windows
+
+package healthexecutable_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bosh-dns/healthcheck/healthexecutable"
+	"bosh-dns/healthcheck/healthprobe/healthprobefakes"
+	"bosh-dns/healthconfig"
+)
+
+var _ = Describe("Monitor running executables", func() {
+	var (
+		clock     *fakeclock.FakeClock
+		logger    *loggerfakes.FakeLogger
+		scriptDir string
+		pidFile   string
+		jobs      []healthconfig.Job
+		signal    chan struct{}
+	)
+
+	BeforeEach(func() {
+		logger = &loggerfakes.FakeLogger{}
+		clock = fakeclock.NewFakeClock(time.Now())
+		signal = make(chan struct{})
+
+		var err error
+		scriptDir, err = os.MkdirTemp("", "health-executable")
+		Expect(err).NotTo(HaveOccurred())
+
+		pidFile = filepath.Join(scriptDir, "child.pid")
+		script := filepath.Join(scriptDir, "hanging")
+		Expect(os.WriteFile(script, []byte(fmt.Sprintf("#!/bin/sh\nsleep 300 &\necho $! > %s\nwait\n", pidFile)), 0755)).To(Succeed())
+
+		jobs = []healthconfig.Job{
+			{HealthExecutablePath: script, Groups: []healthconfig.LinkMetadata{{Group: "1"}}},
+		}
+	})
+
+	AfterEach(func() {
+		close(signal)
+		Expect(os.RemoveAll(scriptDir)).To(Succeed())
+	})
+
+	It("kills the processes started by an executable that timed out", func() {
+		monitors := make(chan *healthexecutable.Monitor, 1)
+		go func() {
+			defer GinkgoRecover()
+			monitors <- healthexecutable.NewMonitor(
+				filepath.Join(scriptDir, "health.json"),
+				jobs,
+				boshsys.NewExecCmdRunner(logger),
+				&healthprobefakes.FakeProber{},
+				clock,
+				time.Hour,
+				time.Minute,
+				signal,
+				logger,
+			)
+		}()
+
+		var childPid int
+		Eventually(func() error {
+			contents, err := os.ReadFile(pidFile)
+			if err != nil {
+				return err
+			}
+			childPid, err = strconv.Atoi(strings.TrimSpace(string(contents)))
+			return err
+		}).Should(Succeed())
+		Expect(syscall.Kill(childPid, 0)).To(Succeed())
+
+		clock.WaitForWatcherAndIncrement(time.Minute)
+
+		var monitor *healthexecutable.Monitor
+		Eventually(monitors, 5*time.Second).Should(Receive(&monitor))
+		Expect(monitor.Status().GroupReason).To(Equal(map[string]string{"1": "timed out after 1m0s"}))
+		Eventually(func() error {
+			return syscall.Kill(childPid, 0)
+		}).Should(MatchError(syscall.ESRCH))
+	})
+})
@@ -1,5 +1,7 @@
 package healthexecutable
 
-func (m *Monitor) runExecutable(executable string) (stdout, stderr string, exitStatus int, err error) {
-	return m.cmdRunner.RunCommand("powershell.exe", executable)
+import "github.com/cloudfoundry/bosh-utils/system"
+
+func (m *Monitor) executableCommand(executable string) system.Command {
+	return system.Command{Name: "powershell.exe", Args: []string{executable}}
 }
@@ -0,0 +1,109 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package healthprobefakes
+
+import (
+	"bosh-dns/healthcheck/healthprobe"
+	"bosh-dns/healthconfig"
+	"sync"
+)
+
+type FakeProber struct {
+	ProbeStub        func(healthconfig.Probe) error
+	probeMutex       sync.RWMutex
+	probeArgsForCall []struct {
+		arg1 healthconfig.Probe
+	}
+	probeReturns struct {
+		result1 error
+	}
+	probeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeProber) Probe(arg1 healthconfig.Probe) error {
+	fake.probeMutex.Lock()
+	ret, specificReturn := fake.probeReturnsOnCall[len(fake.probeArgsForCall)]
+	fake.probeArgsForCall = append(fake.probeArgsForCall, struct {
+		arg1 healthconfig.Probe
+	}{arg1})
+	stub := fake.ProbeStub
+	fakeReturns := fake.probeReturns
+	fake.recordInvocation("Probe", []interface{}{arg1})
+	fake.probeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeProber) ProbeCallCount() int {
+	fake.probeMutex.RLock()
+	defer fake.probeMutex.RUnlock()
+	return len(fake.probeArgsForCall)
+}
+
+func (fake *FakeProber) ProbeCalls(stub func(healthconfig.Probe) error) {
+	fake.probeMutex.Lock()
+	defer fake.probeMutex.Unlock()
+	fake.ProbeStub = stub
+}
+
+func (fake *FakeProber) ProbeArgsForCall(i int) healthconfig.Probe {
+	fake.probeMutex.RLock()
+	defer fake.probeMutex.RUnlock()
+	argsForCall := fake.probeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeProber) ProbeReturns(result1 error) {
+	fake.probeMutex.Lock()
+	defer fake.probeMutex.Unlock()
+	fake.ProbeStub = nil
+	fake.probeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProber) ProbeReturnsOnCall(i int, result1 error) {
+	fake.probeMutex.Lock()
+	defer fake.probeMutex.Unlock()
+	fake.ProbeStub = nil
+	if fake.probeReturnsOnCall == nil {
+		fake.probeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.probeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProber) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeProber) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ healthprobe.Prober = new(FakeProber)
@@ -0,0 +1,13 @@
+package healthprobe_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestHealthProbe(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "healthcheck/healthprobe")
+}
@@ -0,0 +1,123 @@
+package healthprobe
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/miekg/dns"
+
+	"bosh-dns/healthconfig"
+)
+
+const (
+	// DefaultTimeout is how long a probe may take when neither the probe nor
+	// the prober configures a timeout.
+	DefaultTimeout = 5 * time.Second
+
+	maxBodySize = 64 * 1024
+)
+
+//counterfeiter:generate . Prober
+
+type Prober interface {
+	Probe(probe healthconfig.Probe) error
+}
+
+type prober struct {
+	timeout time.Duration
+}
+
+func NewProber(timeout time.Duration) Prober {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &prober{timeout: timeout}
+}
+
+// Probe returns an error describing why the probe failed, or nil when it
+// succeeded.
+func (p *prober) Probe(probe healthconfig.Probe) error {
+	timeout := p.timeout
+	if probe.Timeout > 0 {
+		timeout = time.Duration(probe.Timeout)
+	}
+
+	switch probe.Type {
+	case healthconfig.ProbeTypeHTTP:
+		return probeHTTP(probe, timeout)
+	case healthconfig.ProbeTypeTCP:
+		return probeTCP(probe, timeout)
+	case healthconfig.ProbeTypeDNS:
+		return probeDNS(probe, timeout)
+	}
+
+	return bosherr.Errorf("Unknown probe type '%s'", probe.Type)
+}
+
+func probeHTTP(probe healthconfig.Probe, timeout time.Duration) error {
+	client := &http.Client{Timeout: timeout}
+
+	resp, err := client.Get(probe.URL)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Probing %s", probe.URL)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	expectedStatus := probe.ExpectedStatus
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+
+	if resp.StatusCode != expectedStatus {
+		return bosherr.Errorf("Probing %s: expected status %d, got %d", probe.URL, expectedStatus, resp.StatusCode)
+	}
+
+	if probe.ExpectedBody == "" {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading body of %s", probe.URL)
+	}
+
+	if !strings.Contains(string(body), probe.ExpectedBody) {
+		return bosherr.Errorf("Probing %s: body does not contain '%s'", probe.URL, probe.ExpectedBody)
+	}
+
+	return nil
+}
+
+func probeTCP(probe healthconfig.Probe, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", probe.Address, timeout)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Connecting to %s", probe.Address)
+	}
+
+	return conn.Close()
+}
+
+func probeDNS(probe healthconfig.Probe, timeout time.Duration) error {
+	client := &dns.Client{Timeout: timeout}
+
+	msg := &dns.Msg{}
+	msg.SetQuestion(dns.Fqdn(probe.Domain), dns.TypeA)
+
+	resp, _, err := client.Exchange(msg, probe.Address)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Resolving %s with %s", probe.Domain, probe.Address)
+	}
+
+	if resp.Rcode != dns.RcodeSuccess {
+		return bosherr.Errorf("Resolving %s with %s: %s", probe.Domain, probe.Address, dns.RcodeToString[resp.Rcode])
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package healthprobe_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bosh-dns/dns/config"
+	"bosh-dns/healthcheck/healthprobe"
+	"bosh-dns/healthconfig"
+)
+
+var _ = Describe("Prober", func() {
+	var prober healthprobe.Prober
+
+	BeforeEach(func() {
+		prober = healthprobe.NewProber(time.Second)
+	})
+
+	Describe("http probes", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/health":
+					_, _ = w.Write([]byte(`{"database":"ok"}`)) //nolint:errcheck
+				case "/created":
+					w.WriteHeader(http.StatusCreated)
+				case "/slow":
+					time.Sleep(200 * time.Millisecond)
+				default:
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("succeeds when the status is 200", func() {
+			Expect(prober.Probe(healthconfig.Probe{Type: healthconfig.ProbeTypeHTTP, URL: server.URL + "/health"})).To(Succeed())
+		})
+
+		It("fails when the status is not the expected one", func() {
+			err := prober.Probe(healthconfig.Probe{Type: healthconfig.ProbeTypeHTTP, URL: server.URL + "/down"})
+			Expect(err).To(MatchError(ContainSubstring("expected status 200, got 503")))
+
+			Expect(prober.Probe(healthconfig.Probe{Type: healthconfig.ProbeTypeHTTP, URL: server.URL + "/created", ExpectedStatus: http.StatusCreated})).To(Succeed())
+		})
+
+		It("checks the body when a body is expected", func() {
+			Expect(prober.Probe(healthconfig.Probe{Type: healthconfig.ProbeTypeHTTP, URL: server.URL + "/health", ExpectedBody: `"database":"ok"`})).To(Succeed())
+
+			err := prober.Probe(healthconfig.Probe{Type: healthconfig.ProbeTypeHTTP, URL: server.URL + "/health", ExpectedBody: "cache"})
+			Expect(err).To(MatchError(ContainSubstring("body does not contain 'cache'")))
+		})
+
+		It("fails when the probe times out", func() {
+			probe := healthconfig.Probe{
+				Type:    healthconfig.ProbeTypeHTTP,
+				URL:     server.URL + "/slow",
+				Timeout: config.DurationJSON(50 * time.Millisecond),
+			}
+			Expect(prober.Probe(probe)).To(MatchError(ContainSubstring("Probing " + server.URL + "/slow")))
+		})
+	})
+
+	Describe("tcp probes", func() {
+		It("succeeds when it connects", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer listener.Close() //nolint:errcheck
+
+			Expect(prober.Probe(healthconfig.Probe{Type: healthconfig.ProbeTypeTCP, Address: listener.Addr().String()})).To(Succeed())
+		})
+
+		It("fails when nothing listens", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			address := listener.Addr().String()
+			Expect(listener.Close()).To(Succeed())
+
+			Expect(prober.Probe(healthconfig.Probe{Type: healthconfig.ProbeTypeTCP, Address: address})).To(MatchError(ContainSubstring("Connecting to " + address)))
+		})
+	})
+
+	Describe("dns probes", func() {
+		var (
+			server  *dns.Server
+			address string
+		)
+
+		BeforeEach(func() {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			address = conn.LocalAddr().String()
+
+			mux := dns.NewServeMux()
+			mux.HandleFunc("example.com.", func(w dns.ResponseWriter, r *dns.Msg) {
+				m := &dns.Msg{}
+				m.SetReply(r)
+				_ = w.WriteMsg(m) //nolint:errcheck
+			})
+			mux.HandleFunc(".", func(w dns.ResponseWriter, r *dns.Msg) {
+				m := &dns.Msg{}
+				m.SetRcode(r, dns.RcodeNameError)
+				_ = w.WriteMsg(m) //nolint:errcheck
+			})
+
+			started := make(chan struct{})
+			server = &dns.Server{PacketConn: conn, Handler: mux, NotifyStartedFunc: func() { close(started) }}
+			go server.ActivateAndServe() //nolint:errcheck
+			Eventually(started).Should(BeClosed())
+		})
+
+		AfterEach(func() {
+			Expect(server.Shutdown()).To(Succeed())
+		})
+
+		It("succeeds when the domain resolves", func() {
+			Expect(prober.Probe(healthconfig.Probe{Type: healthconfig.ProbeTypeDNS, Address: address, Domain: "example.com"})).To(Succeed())
+		})
+
+		It("fails when the server does not answer successfully", func() {
+			err := prober.Probe(healthconfig.Probe{Type: healthconfig.ProbeTypeDNS, Address: address, Domain: "missing.example.org."})
+			Expect(err).To(MatchError(ContainSubstring("NXDOMAIN")))
+		})
+	})
+})
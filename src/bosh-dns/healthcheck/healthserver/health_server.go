@@ -23,6 +23,7 @@ type HealthServer interface {
 
 type HealthExecutable interface {
 	Status() api.HealthResult
+	Subscribe() (<-chan struct{}, func())
 }
 
 type concreteHealthServer struct {
@@ -45,6 +46,7 @@ func NewHealthServer(logger boshlog.Logger, healthFileName string, healthExecuta
 
 func (c *concreteHealthServer) Serve(config *healthconfig.HealthCheckConfig) {
 	http.HandleFunc("/health", c.healthEntryPoint)
+	http.HandleFunc("/health/stream", c.healthStreamEntryPoint)
 
 	tlsConfig, err := tlsconfig.Build(
 		tlsconfig.WithIdentityFromFile(config.CertificateFile, config.PrivateKeyFile),
@@ -87,17 +89,7 @@ func (c *concreteHealthServer) Serve(config *healthconfig.HealthCheckConfig) {
 func (c *concreteHealthServer) healthEntryPoint(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close() //nolint:errcheck
 
-	// Should not be possible to get here without having a peer certificate
-	cn := r.TLS.PeerCertificates[0].Subject.CommonName
-	if cn != CN {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Header().Add("Content-Type", "text/plain")
-		_, err := w.Write([]byte("TLS certificate common name does not match"))
-		if err != nil {
-			c.logger.Error(logTag, "failed to write healthcheck status data: %s", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+	if !c.authorized(w, r) {
 		return
 	}
 
@@ -118,3 +110,71 @@ func (c *concreteHealthServer) healthEntryPoint(w http.ResponseWriter, r *http.R
 		return
 	}
 }
+
+// healthStreamEntryPoint writes the health status as a JSON line right away,
+// whenever it changes and every api.HealthStreamHeartbeat until the client
+// goes away or the server shuts down.
+func (c *concreteHealthServer) healthStreamEntryPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close() //nolint:errcheck
+
+	if !c.authorized(w, r) {
+		return
+	}
+
+	changes, unsubscribe := c.healthExecutable.Subscribe()
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(api.HealthStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	w.Header().Add("Content-Type", "application/x-ndjson")
+
+	controller := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+
+	for {
+		if c.timeout > 0 {
+			err := controller.SetWriteDeadline(time.Now().Add(c.timeout))
+			if err != nil {
+				c.logger.Error(logTag, "failed to set healthcheck stream write deadline: %s", err)
+				return
+			}
+		}
+
+		err := encoder.Encode(c.healthExecutable.Status())
+		if err == nil {
+			err = controller.Flush()
+		}
+		if err != nil {
+			c.logger.Debug(logTag, "healthcheck stream ended: %s", err)
+			return
+		}
+
+		select {
+		case <-changes:
+		case <-heartbeat.C:
+		case <-r.Context().Done():
+			return
+		case <-c.shutdown:
+			return
+		}
+	}
+}
+
+func (c *concreteHealthServer) authorized(w http.ResponseWriter, r *http.Request) bool {
+	// Should not be possible to get here without having a peer certificate
+	cn := r.TLS.PeerCertificates[0].Subject.CommonName
+	if cn == CN {
+		return true
+	}
+
+	w.WriteHeader(http.StatusBadRequest)
+	w.Header().Add("Content-Type", "text/plain")
+	_, err := w.Write([]byte("TLS certificate common name does not match"))
+	if err != nil {
+		c.logger.Error(logTag, "failed to write healthcheck status data: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	return false
+}
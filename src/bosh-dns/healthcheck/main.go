@@ -14,6 +14,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	"bosh-dns/healthcheck/healthexecutable"
+	"bosh-dns/healthcheck/healthprobe"
 	"bosh-dns/healthcheck/healthserver"
 	"bosh-dns/healthconfig"
 )
@@ -63,6 +64,11 @@ func mainExitCode() int {
 		return 1
 	}
 	logger.Info(logTag, fmt.Sprintf("Monitored jobs: %+v", jobs))
+	for _, job := range jobs {
+		if job.MetadataError != "" {
+			logger.Error(logTag, fmt.Sprintf("job will be reported as failing: %s", job.MetadataError))
+		}
+	}
 
 	sigterm := make(chan os.Signal, 1)
 	signal.Notify(sigterm, syscall.SIGTERM)
@@ -76,8 +82,10 @@ func mainExitCode() int {
 		config.HealthFileName,
 		jobs,
 		cmdRunner,
+		healthprobe.NewProber(time.Duration(config.HealthExecutableTimeout)),
 		clock.NewClock(),
 		interval,
+		time.Duration(config.HealthExecutableTimeout),
 		shutdown,
 		logger,
 	)
@@ -187,6 +187,52 @@ var _ = Describe("HealthCheck server", func() {
 	})
 })
 
+var _ = Describe("HealthCheck stream", func() {
+	var client *httpclient.HTTPClient
+
+	BeforeEach(func() {
+		healthRaw, err := json.Marshal(Health{State: "running"})
+		Expect(err).ToNot(HaveOccurred())
+
+		err = os.WriteFile(healthFile.Name(), healthRaw, 0777)
+		Expect(err).ToNot(HaveOccurred())
+
+		startServer()
+
+		client, err = tlsclient.NewFromFiles(
+			"health.bosh-dns",
+			"assets/test_certs/test_ca.pem",
+			"assets/test_certs/test_client.pem",
+			"assets/test_certs/test_client.key",
+			5*time.Second,
+			boshlog.NewAsyncWriterLogger(boshlog.LevelDebug, io.Discard),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("streams the health status whenever it changes", func() {
+		resp, err := client.Get(fmt.Sprintf("https://127.0.0.1:%d/health/stream", configPort))
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close() //nolint:errcheck
+
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/x-ndjson"))
+
+		decoder := json.NewDecoder(resp.Body)
+
+		var health Health
+		Expect(decoder.Decode(&health)).To(Succeed())
+		Expect(health.State).To(Equal("running"))
+
+		healthRaw, err := json.Marshal(Health{State: "failing"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(healthFile.Name(), healthRaw, 0777)).To(Succeed())
+
+		Expect(decoder.Decode(&health)).To(Succeed())
+		Expect(health.State).To(Equal("failing"))
+	})
+})
+
 func secureGetRespBody(client *httpclient.HTTPClient, port int) Health {
 	resp, err := secureGet(client, port)
 	Expect(err).NotTo(HaveOccurred())
@@ -12,6 +12,7 @@ type HealthCheckConfig struct {
 
 	HealthExecutableInterval config.DurationJSON `json:"health_executable_interval"`
 	HealthExecutablePath     string              `json:"health_executable_path"`
+	HealthExecutableTimeout  config.DurationJSON `json:"health_executable_timeout"`
 	HealthFileName           string              `json:"health_file_name"`
 
 	JobsDir string `json:"jobs_dir"`
@@ -5,11 +5,58 @@ import (
 	"io/ioutil" //nolint:staticcheck
 	"os"
 	"path/filepath"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-dns/dns/config"
+)
+
+const (
+	ProbeTypeHTTP = "http"
+	ProbeTypeTCP  = "tcp"
+	ProbeTypeDNS  = "dns"
 )
 
 type Job struct {
 	HealthExecutablePath string
+	Probes               []Probe
 	Groups               []LinkMetadata
+
+	// HealthExecutableTimeout overrides the global health executable timeout
+	// for the job when it is set in .bosh/health.json.
+	HealthExecutableTimeout time.Duration
+
+	// MetadataError is set when the health metadata of the job in .bosh
+	// cannot be used. Its probes are skipped and it is reported as failing
+	// with this reason instead of failing to parse the other jobs.
+	MetadataError string
+}
+
+// Probe is a health check declared by a job in .bosh/health_probes.json that
+// the health server runs natively instead of a health executable.
+type Probe struct {
+	Type string `json:"type"`
+
+	// URL, ExpectedStatus and ExpectedBody apply to http probes. The probe
+	// expects status 200 when no status is given, and a body containing
+	// ExpectedBody when it is set.
+	URL            string `json:"url,omitempty"`
+	ExpectedStatus int    `json:"expected_status,omitempty"`
+	ExpectedBody   string `json:"expected_body,omitempty"`
+
+	// Address is the host and port tcp probes connect to and dns probes
+	// query, Domain is the name dns probes resolve.
+	Address string `json:"address,omitempty"`
+	Domain  string `json:"domain,omitempty"`
+
+	Timeout config.DurationJSON `json:"timeout,omitempty"`
+}
+
+// HealthMetadata holds the health settings a job declares in
+// .bosh/health.json.
+type HealthMetadata struct {
+	Timeout config.DurationJSON `json:"timeout,omitempty"`
 }
 
 type LinkMetadata struct {
@@ -45,6 +92,17 @@ func ParseJobs(jobsDir string, executablePath string) ([]Job, error) {
 
 		job := Job{Groups: groups}
 
+		job.Probes, err = parseProbes(jobsDir, jobDir.Name())
+		if err != nil {
+			job.MetadataError = err.Error()
+		}
+
+		metadata, err := parseHealthMetadata(jobsDir, jobDir.Name())
+		if err != nil && job.MetadataError == "" {
+			job.MetadataError = err.Error()
+		}
+		job.HealthExecutableTimeout = time.Duration(metadata.Timeout)
+
 		jobExecutablePath := filepath.Join(jobsDir, jobDir.Name(), executablePath)
 		exists, err := fileExists(jobExecutablePath)
 		if err != nil {
@@ -96,6 +154,82 @@ func parseLinkGroups(jobsDir, jobName string) ([]LinkMetadata, error) {
 	return links, nil
 }
 
+func parseProbes(jobsDir, jobName string) ([]Probe, error) {
+	probesPath := filepath.Join(jobsDir, jobName, ".bosh", "health_probes.json")
+	f, err := os.Open(probesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, bosherr.WrapErrorf(err, "Reading health probes of job '%s'", jobName)
+	}
+	defer f.Close() //nolint:errcheck
+
+	var probes []Probe
+	decoder := json.NewDecoder(f)
+	err = decoder.Decode(&probes)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Parsing health probes of job '%s'", jobName)
+	}
+
+	for _, probe := range probes {
+		err = probe.validate()
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Validating health probes of job '%s'", jobName)
+		}
+	}
+
+	return probes, nil
+}
+
+func parseHealthMetadata(jobsDir, jobName string) (HealthMetadata, error) {
+	metadataPath := filepath.Join(jobsDir, jobName, ".bosh", "health.json")
+	f, err := os.Open(metadataPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return HealthMetadata{}, nil
+		}
+
+		return HealthMetadata{}, bosherr.WrapErrorf(err, "Reading health metadata of job '%s'", jobName)
+	}
+	defer f.Close() //nolint:errcheck
+
+	var metadata HealthMetadata
+	decoder := json.NewDecoder(f)
+	err = decoder.Decode(&metadata)
+	if err != nil {
+		return HealthMetadata{}, bosherr.WrapErrorf(err, "Parsing health metadata of job '%s'", jobName)
+	}
+
+	if metadata.Timeout < 0 {
+		return HealthMetadata{}, bosherr.Errorf("Negative health executable timeout of job '%s'", jobName)
+	}
+
+	return metadata, nil
+}
+
+func (p Probe) validate() error {
+	switch p.Type {
+	case ProbeTypeHTTP:
+		if p.URL == "" {
+			return bosherr.Error("Missing url for http probe")
+		}
+	case ProbeTypeTCP:
+		if p.Address == "" {
+			return bosherr.Error("Missing address for tcp probe")
+		}
+	case ProbeTypeDNS:
+		if p.Address == "" || p.Domain == "" {
+			return bosherr.Error("Missing address or domain for dns probe")
+		}
+	default:
+		return bosherr.Errorf("Unknown probe type '%s'", p.Type)
+	}
+
+	return nil
+}
+
 func fileExists(path string) (bool, error) {
 	_, err := os.Stat(path)
 	if err != nil {
@@ -3,10 +3,12 @@ package healthconfig_test
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"bosh-dns/dns/config"
 	"bosh-dns/healthconfig"
 )
 
@@ -107,6 +109,160 @@ var _ = Describe("ParseJobs", func() {
 		})
 	})
 
+	Context("when a job declares health probes", func() {
+		var probes string
+
+		BeforeEach(func() {
+			probes = `[
+				{"type":"http","url":"http://127.0.0.1:8080/health","expected_status":204,"expected_body":"ok","timeout":"2s"},
+				{"type":"tcp","address":"127.0.0.1:5432"},
+				{"type":"dns","address":"127.0.0.1:53","domain":"example.com."}
+			]`
+		})
+
+		JustBeforeEach(func() {
+			Expect(os.WriteFile(filepath.Join(jobsDir, "job-a", ".bosh", "health_probes.json"), []byte(probes), 0644)).To(Succeed())
+		})
+
+		It("parses the probes of the job", func() {
+			jobs, err := healthconfig.ParseJobs(jobsDir, "bin/dns/healthy")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(jobs).To(HaveLen(2))
+			Expect(jobs).To(ContainElement(healthconfig.Job{
+				HealthExecutablePath: "",
+				Probes: []healthconfig.Probe{
+					{
+						Type:           healthconfig.ProbeTypeHTTP,
+						URL:            "http://127.0.0.1:8080/health",
+						ExpectedStatus: 204,
+						ExpectedBody:   "ok",
+						Timeout:        config.DurationJSON(2 * time.Second),
+					},
+					{Type: healthconfig.ProbeTypeTCP, Address: "127.0.0.1:5432"},
+					{Type: healthconfig.ProbeTypeDNS, Address: "127.0.0.1:53", Domain: "example.com."},
+				},
+				Groups: []healthconfig.LinkMetadata{{
+					Group:   "1",
+					Name:    "service",
+					Type:    "connection",
+					JobName: "job-a",
+				}},
+			}))
+		})
+
+		Context("when the probes of one job are invalid", func() {
+			BeforeEach(func() {
+				jobCDir := filepath.Join(jobsDir, "job-c", ".bosh")
+				Expect(os.MkdirAll(jobCDir, 0777)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(jobCDir, "health_probes.json"), []byte(`[{"type":"tcp"}]`), 0644)).To(Succeed())
+			})
+
+			It("still parses the other jobs", func() {
+				jobs, err := healthconfig.ParseJobs(jobsDir, "bin/dns/healthy")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(jobs).To(HaveLen(3))
+				Expect(jobs[0].Probes).To(HaveLen(3))
+				Expect(jobs[0].MetadataError).To(BeEmpty())
+				Expect(jobs[1].HealthExecutablePath).To(Equal(filepath.Join(jobsDir, "job-b", "bin/dns/healthy")))
+				Expect(jobs[1].MetadataError).To(BeEmpty())
+				Expect(jobs[2].Probes).To(BeNil())
+				Expect(jobs[2].MetadataError).To(Equal("Validating health probes of job 'job-c': Missing address for tcp probe"))
+			})
+		})
+
+		Context("when the probes have invalid json", func() {
+			BeforeEach(func() {
+				probes = `{{`
+			})
+
+			It("reports the job as invalid without probes", func() {
+				jobs, err := healthconfig.ParseJobs(jobsDir, "bin/dns/healthy")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(jobs[0].Probes).To(BeNil())
+				Expect(jobs[0].MetadataError).To(ContainSubstring("Parsing health probes of job 'job-a'"))
+			})
+		})
+
+		Context("when a probe has an unknown type", func() {
+			BeforeEach(func() {
+				probes = `[{"type":"icmp","address":"127.0.0.1"}]`
+			})
+
+			It("reports the job as invalid without probes", func() {
+				jobs, err := healthconfig.ParseJobs(jobsDir, "bin/dns/healthy")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(jobs[0].Probes).To(BeNil())
+				Expect(jobs[0].MetadataError).To(ContainSubstring("Unknown probe type 'icmp'"))
+			})
+		})
+
+		Context("when a probe is missing its target", func() {
+			BeforeEach(func() {
+				probes = `[{"type":"dns","address":"127.0.0.1:53"}]`
+			})
+
+			It("reports the job as invalid without probes", func() {
+				jobs, err := healthconfig.ParseJobs(jobsDir, "bin/dns/healthy")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(jobs[0].Probes).To(BeNil())
+				Expect(jobs[0].MetadataError).To(ContainSubstring("Missing address or domain for dns probe"))
+			})
+		})
+	})
+
+	Context("when a job declares health metadata", func() {
+		var metadata string
+
+		BeforeEach(func() {
+			metadata = `{"timeout":"30s"}`
+		})
+
+		JustBeforeEach(func() {
+			Expect(os.WriteFile(filepath.Join(jobsDir, "job-b", ".bosh", "health.json"), []byte(metadata), 0644)).To(Succeed())
+		})
+
+		It("parses the health executable timeout of the job", func() {
+			jobs, err := healthconfig.ParseJobs(jobsDir, "bin/dns/healthy")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(jobs).To(HaveLen(2))
+			Expect(jobs[0].HealthExecutableTimeout).To(BeZero())
+			Expect(jobs[1].HealthExecutableTimeout).To(Equal(30 * time.Second))
+		})
+
+		Context("when the metadata has invalid json", func() {
+			BeforeEach(func() {
+				metadata = `{{`
+			})
+
+			It("reports the job as invalid", func() {
+				jobs, err := healthconfig.ParseJobs(jobsDir, "bin/dns/healthy")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(jobs[1].HealthExecutableTimeout).To(BeZero())
+				Expect(jobs[1].MetadataError).To(ContainSubstring("Parsing health metadata of job 'job-b'"))
+			})
+		})
+
+		Context("when the timeout is negative", func() {
+			BeforeEach(func() {
+				metadata = `{"timeout":"-1s"}`
+			})
+
+			It("reports the job as invalid", func() {
+				jobs, err := healthconfig.ParseJobs(jobsDir, "bin/dns/healthy")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(jobs[1].MetadataError).To(Equal("Negative health executable timeout of job 'job-b'"))
+			})
+		})
+	})
+
 	Context("when the link metatdata has invalid json", func() {
 		BeforeEach(func() {
 			jobCDir := filepath.Join(jobsDir, "job-c")
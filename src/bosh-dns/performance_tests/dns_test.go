@@ -122,7 +122,7 @@ var _ = Describe("DNS", func() {
 			fakeFiltererFactory.NewQueryFiltererReturns(fakeQueryFilterer)
 			fakeFiltererFactory.NewHealthFiltererReturns(fakeHealthFilterer)
 			fakeAliasQueryEncoder := &recordsfakes.FakeAliasQueryEncoder{}
-			recordSet, err := records.NewRecordSet(recordSetReader, aliases.NewConfig(), healthWatcher, uint(5), shutdown, logger, fakeFiltererFactory, fakeAliasQueryEncoder)
+			recordSet, err := records.NewRecordSet(recordSetReader, aliases.NewConfig(), healthWatcher, uint(5), shutdown, logger, clock.NewClock(), fakeFiltererFactory, fakeAliasQueryEncoder)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(recordSet.AllRecords()).To(HaveLen(102))
 
@@ -0,0 +1,182 @@
+package performance_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-dns/dns/server/aliases"
+	"bosh-dns/dns/server/healthiness/healthinessfakes"
+	"bosh-dns/dns/server/records"
+	"bosh-dns/dns/server/records/recordsfakes"
+	"bosh-dns/healthcheck/api"
+)
+
+const (
+	benchmarkDeployments         = 100
+	benchmarkGroupsPerDeployment = 20
+	benchmarkInstancesPerGroup   = 10
+)
+
+func BenchmarkResolveLongForm(b *testing.B) {
+	recordSet := newBenchmarkRecordSet(b)
+
+	benchmarkResolve(b, recordSet, func(i int) string {
+		deployment, group, instance := benchmarkInstance(i)
+		return fmt.Sprintf("instance-%d.group-%d.default.deployment-%d.bosh.", instance, group, deployment)
+	})
+}
+
+func BenchmarkResolveShortFormGroup(b *testing.B) {
+	recordSet := newBenchmarkRecordSet(b)
+
+	benchmarkResolve(b, recordSet, func(i int) string {
+		deployment, group, _ := benchmarkInstance(i)
+		return fmt.Sprintf("q-s0.q-g%d.bosh.", deployment*benchmarkGroupsPerDeployment+group)
+	})
+}
+
+func BenchmarkResolveAgentID(b *testing.B) {
+	recordSet := newBenchmarkRecordSet(b)
+
+	benchmarkResolve(b, recordSet, func(i int) string {
+		return fmt.Sprintf("agent-%d.bosh-agent-id.", i%benchmarkRecordCount())
+	})
+}
+
+func BenchmarkHasIP(b *testing.B) {
+	recordSet := newBenchmarkRecordSet(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !recordSet.HasIP(benchmarkIP(i % benchmarkRecordCount())) {
+			b.Fatalf("expected IP %s to be known", benchmarkIP(i%benchmarkRecordCount()))
+		}
+	}
+}
+
+func BenchmarkGetFQDNs(b *testing.B) {
+	recordSet := newBenchmarkRecordSet(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if len(recordSet.GetFQDNs(benchmarkIP(i%benchmarkRecordCount()))) == 0 {
+			b.Fatalf("expected FQDNs for IP %s", benchmarkIP(i%benchmarkRecordCount()))
+		}
+	}
+}
+
+func BenchmarkLoadRecords(b *testing.B) {
+	blob := benchmarkRecordsBlob(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		newBenchmarkRecordSetFromBlob(b, blob)
+	}
+}
+
+func benchmarkResolve(b *testing.B, recordSet *records.RecordSet, fqdn func(i int) string) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ips, err := recordSet.Resolve(fqdn(i))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(ips) == 0 {
+			b.Fatalf("no IPs for %s", fqdn(i))
+		}
+	}
+}
+
+func benchmarkRecordCount() int {
+	return benchmarkDeployments * benchmarkGroupsPerDeployment * benchmarkInstancesPerGroup
+}
+
+func benchmarkInstance(i int) (deployment, group, instance int) {
+	i = i % benchmarkRecordCount()
+	instance = i % benchmarkInstancesPerGroup
+	group = (i / benchmarkInstancesPerGroup) % benchmarkGroupsPerDeployment
+	deployment = i / (benchmarkInstancesPerGroup * benchmarkGroupsPerDeployment)
+
+	return deployment, group, instance
+}
+
+func benchmarkIP(i int) string {
+	return fmt.Sprintf("10.%d.%d.%d", i/65536, (i/256)%256, i%256)
+}
+
+func newBenchmarkRecordSet(b *testing.B) *records.RecordSet {
+	return newBenchmarkRecordSetFromBlob(b, benchmarkRecordsBlob(b))
+}
+
+func benchmarkRecordsBlob(b *testing.B) []byte {
+	recordInfos := [][]interface{}{}
+	hosts := [][]string{}
+
+	for i := 0; i < benchmarkRecordCount(); i++ {
+		deployment, group, instance := benchmarkInstance(i)
+		recordInfos = append(recordInfos, []interface{}{
+			fmt.Sprintf("instance-%d", instance),
+			fmt.Sprintf("%d", instance),
+			fmt.Sprintf("group-%d", group),
+			[]string{fmt.Sprintf("%d", deployment*benchmarkGroupsPerDeployment+group)},
+			fmt.Sprintf("az%d", instance%3),
+			fmt.Sprintf("%d", instance%3),
+			"default",
+			"1",
+			fmt.Sprintf("deployment-%d", deployment),
+			benchmarkIP(i),
+			"bosh",
+			fmt.Sprintf("agent-%d", i),
+		})
+		hosts = append(hosts, []string{
+			benchmarkIP(i),
+			fmt.Sprintf("instance-%d.group-%d.default.deployment-%d.bosh", instance, group, deployment),
+		})
+	}
+
+	blob, err := json.Marshal(map[string]interface{}{
+		"record_keys":  []string{"id", "num_id", "instance_group", "group_ids", "az", "az_id", "network", "network_id", "deployment", "ip", "domain", "agent_id"},
+		"record_infos": recordInfos,
+		"records":      hosts,
+		"Version":      1,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return blob
+}
+
+func newBenchmarkRecordSetFromBlob(b *testing.B, blob []byte) *records.RecordSet {
+	fileReader := &recordsfakes.FakeFileReader{}
+	fileReader.GetReturns(blob, nil)
+
+	healthWatcher := &healthinessfakes.FakeHealthWatcher{}
+	healthWatcher.HealthStateReturns(api.HealthResult{State: api.StatusRunning})
+
+	shutdown := make(chan struct{})
+	b.Cleanup(func() { close(shutdown) })
+
+	recordSet, err := records.NewRecordSet(
+		fileReader,
+		aliases.NewConfig(),
+		healthWatcher,
+		uint(5),
+		shutdown,
+		&loggerfakes.FakeLogger{},
+		clock.NewClock(),
+		records.NewHealthFiltererFactory(healthWatcher, time.Second),
+		records.NewAliasEncoder(),
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return recordSet
+}
@@ -3,6 +3,7 @@ package tlsclient
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"net"
 	"net/http"
 	"os"
 	"time"
@@ -13,22 +14,48 @@ import (
 )
 
 func NewFromFiles(dnsName string, caFile, clientCertFile, clientKeyFile string, timeout time.Duration, logger boshlog.Logger) (*httpclient.HTTPClient, error) {
+	caCert, cert, err := loadFiles(caFile, clientCertFile, clientKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(dnsName, caCert, cert, timeout, logger)
+}
+
+// NewStreamingFromFiles returns a client for responses that are read for as
+// long as the server keeps sending them. Reading the response body does not
+// time out, but dialing, the TLS handshake and waiting for the response
+// headers each time out after connectTimeout.
+func NewStreamingFromFiles(dnsName string, caFile, clientCertFile, clientKeyFile string, connectTimeout time.Duration, logger boshlog.Logger) (*httpclient.HTTPClient, error) {
+	caCert, cert, err := loadFiles(caFile, clientCertFile, clientKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(dnsName, caCert, cert, 0, connectTimeout, logger)
+}
+
+func New(dnsName string, caCert []byte, cert tls.Certificate, timeout time.Duration, logger boshlog.Logger) (*httpclient.HTTPClient, error) {
+	return newClient(dnsName, caCert, cert, timeout, 0, logger)
+}
+
+func loadFiles(caFile, clientCertFile, clientKeyFile string) ([]byte, tls.Certificate, error) {
 	// Load client cert
 	cert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
 	if err != nil {
-		return nil, err
+		return nil, tls.Certificate{}, err
 	}
 
 	// Load CA cert
 	caCert, err := os.ReadFile(caFile)
 	if err != nil {
-		return nil, err
+		return nil, tls.Certificate{}, err
 	}
 
-	return New(dnsName, caCert, cert, timeout, logger)
+	return caCert, cert, nil
 }
 
-func New(dnsName string, caCert []byte, cert tls.Certificate, timeout time.Duration, logger boshlog.Logger) (*httpclient.HTTPClient, error) {
+func newClient(dnsName string, caCert []byte, cert tls.Certificate, timeout, connectTimeout time.Duration, logger boshlog.Logger) (*httpclient.HTTPClient, error) {
 	caCertPool := x509.NewCertPool()
 	caCertPool.AppendCertsFromPEM(caCert)
 
@@ -48,6 +75,11 @@ func New(dnsName string, caCert []byte, cert tls.Certificate, timeout time.Durat
 	transport := &http.Transport{
 		TLSClientConfig: tlsConfig,
 	}
+	if connectTimeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: connectTimeout}).DialContext
+		transport.TLSHandshakeTimeout = connectTimeout
+		transport.ResponseHeaderTimeout = connectTimeout
+	}
 	client := &http.Client{Transport: transport}
 	client.Timeout = timeout
 
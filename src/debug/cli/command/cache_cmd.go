@@ -0,0 +1,96 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"bosh-dns/dns/api"
+	"bosh-dns/tlsclient"
+)
+
+type CacheCmd struct {
+	Args               CacheArgs `positional-args:"true"`
+	API                string    `long:"api" env:"DNS_API_ADDRESS" description:"API address to talk to"`
+	TLSCACertPath      string    `long:"ca-cert-path" env:"DNS_API_TLS_CA_CERT_PATH" description:"CA certificate to use for mutual LS"`
+	TLSCertificatePath string    `long:"certificate-path" env:"DNS_API_TLS_CERTIFICATE_PATH" description:"Client certificate to use for mutual LS"`
+	TLSPrivateKeyPath  string    `long:"private-key-path" env:"DNS_API_TLS_PRIVATE_KEY_PATH" description:"Client key to use for mutual LS"`
+
+	UI ui.UI
+}
+
+type CacheArgs struct {
+	Name string `positional-arg-name:"NAME" description:"Only show cached responses for this name"`
+}
+
+func (o *CacheCmd) Execute(args []string) error {
+	logger := boshlog.NewLogger(boshlog.LevelNone)
+	if o.UI == nil {
+		confUI := ui.NewConfUI(logger)
+		confUI.EnableColor()
+		o.UI = confUI
+	}
+
+	client, err := tlsclient.NewFromFiles("api.bosh-dns", o.TLSCACertPath, o.TLSCertificatePath, o.TLSPrivateKeyPath, 5*time.Second, logger)
+	if err != nil {
+		return err
+	}
+
+	requestURL := o.API + "/cache"
+
+	if o.Args.Name != "" {
+		requestURL = requestURL + "?name=" + url.QueryEscape(o.Args.Name)
+	}
+
+	response, err := client.Get(requestURL)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to retrieve cache entries: Got %s", response.Status)
+	}
+
+	table := boshtbl.Table{
+		Title: "Cached DNS responses",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Type"),
+			boshtbl.NewHeader("Rcode"),
+			boshtbl.NewHeader("TTL"),
+			boshtbl.NewHeader("Records"),
+		},
+	}
+
+	decoder := json.NewDecoder(response.Body)
+
+	for decoder.More() {
+		var jsonRow api.CacheEntry
+
+		err := decoder.Decode(&jsonRow)
+		if err != nil {
+			return err
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(jsonRow.Name),
+			boshtbl.NewValueString(jsonRow.Type),
+			boshtbl.NewValueString(jsonRow.Rcode),
+			boshtbl.NewValueString(strconv.FormatUint(uint64(jsonRow.TTL), 10)),
+			boshtbl.NewValueString(strings.Join(jsonRow.Records, "\n")),
+		})
+	}
+
+	o.UI.PrintTable(table)
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package command_test
+
+import (
+	"net/http"
+
+	uifakes "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"debug/cli/command"
+)
+
+var _ = Describe("CacheCmd", func() {
+	var (
+		server *ghttp.Server
+		ui     *uifakes.FakeUI
+		cmd    command.CacheCmd
+	)
+
+	BeforeEach(func() {
+		server = newFakeAPIServer()
+
+		ui = &uifakes.FakeUI{}
+		cmd = command.CacheCmd{
+			UI:                 ui,
+			API:                server.URL(),
+			TLSCACertPath:      "../../../bosh-dns/dns/api/assets/test_certs/test_ca.pem",
+			TLSCertificatePath: "../../../bosh-dns/dns/api/assets/test_certs/test_wrong_cn_client.pem",
+			TLSPrivateKeyPath:  "../../../bosh-dns/dns/api/assets/test_certs/test_client.key",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("when the DNS server responds with some cache entries", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/cache", "name=example.com."),
+					ghttp.RespondWith(http.StatusOK, `
+						{
+							"name":    "example.com.",
+							"type":    "A",
+							"rcode":   "NOERROR",
+							"ttl":     30,
+							"records": ["example.com.\t30\tIN\tA\t1.2.3.4", "example.com.\t30\tIN\tA\t1.2.3.5"]
+						}
+					`),
+				),
+			)
+		})
+
+		It("formats the contents like a table", func() {
+			cmd.Args = command.CacheArgs{Name: "example.com."}
+			Expect(cmd.Execute(nil)).To(Succeed())
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Title: "Cached DNS responses",
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Name"),
+					boshtbl.NewHeader("Type"),
+					boshtbl.NewHeader("Rcode"),
+					boshtbl.NewHeader("TTL"),
+					boshtbl.NewHeader("Records"),
+				},
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("example.com."),
+						boshtbl.NewValueString("A"),
+						boshtbl.NewValueString("NOERROR"),
+						boshtbl.NewValueString("30"),
+						boshtbl.NewValueString("example.com.\t30\tIN\tA\t1.2.3.4\nexample.com.\t30\tIN\tA\t1.2.3.5"),
+					},
+				},
+			}))
+		})
+	})
+
+	Context("when the server does not respond 200", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/cache"),
+					ghttp.RespondWith(http.StatusNotFound, []byte{}),
+				),
+			)
+		})
+
+		It("raises an error", func() {
+			Expect(cmd.Execute(nil)).ToNot(Succeed())
+		})
+	})
+})
+
+var _ = Describe("CacheFlushCmd", func() {
+	var (
+		server *ghttp.Server
+		ui     *uifakes.FakeUI
+		cmd    command.CacheFlushCmd
+	)
+
+	BeforeEach(func() {
+		server = newFakeAPIServer()
+
+		ui = &uifakes.FakeUI{}
+		cmd = command.CacheFlushCmd{
+			UI:                 ui,
+			API:                server.URL(),
+			TLSCACertPath:      "../../../bosh-dns/dns/api/assets/test_certs/test_ca.pem",
+			TLSCertificatePath: "../../../bosh-dns/dns/api/assets/test_certs/test_wrong_cn_client.pem",
+			TLSPrivateKeyPath:  "../../../bosh-dns/dns/api/assets/test_certs/test_client.key",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("flushes everything when no name is given", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/cache/flush", ""),
+				ghttp.RespondWith(http.StatusOK, `{"flushed": 12}`),
+			),
+		)
+
+		Expect(cmd.Execute(nil)).To(Succeed())
+		Expect(ui.Said).To(Equal([]string{"Flushed 12 cached responses"}))
+	})
+
+	It("flushes a single name", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/cache/flush", "name=example.com."),
+				ghttp.RespondWith(http.StatusOK, `{"flushed": 1}`),
+			),
+		)
+
+		cmd.Args = command.CacheFlushArgs{Name: "example.com."}
+		Expect(cmd.Execute(nil)).To(Succeed())
+		Expect(ui.Said).To(Equal([]string{"Flushed 1 cached responses"}))
+	})
+
+	It("flushes a suffix", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/cache/flush", "suffix=example.com."),
+				ghttp.RespondWith(http.StatusOK, `{"flushed": 3}`),
+			),
+		)
+
+		cmd.Args = command.CacheFlushArgs{Name: "example.com."}
+		cmd.Suffix = true
+		Expect(cmd.Execute(nil)).To(Succeed())
+	})
+
+	It("requires a name for --suffix", func() {
+		cmd.Suffix = true
+		Expect(cmd.Execute(nil)).To(MatchError("--suffix requires a NAME"))
+	})
+})
@@ -0,0 +1,77 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"bosh-dns/dns/api"
+	"bosh-dns/tlsclient"
+)
+
+type CacheFlushCmd struct {
+	Args               CacheFlushArgs `positional-args:"true"`
+	Suffix             bool           `long:"suffix" description:"Flush every name ending in NAME instead of only NAME"`
+	API                string         `long:"api" env:"DNS_API_ADDRESS" description:"API address to talk to"`
+	TLSCACertPath      string         `long:"ca-cert-path" env:"DNS_API_TLS_CA_CERT_PATH" description:"CA certificate to use for mutual LS"`
+	TLSCertificatePath string         `long:"certificate-path" env:"DNS_API_TLS_CERTIFICATE_PATH" description:"Client certificate to use for mutual LS"`
+	TLSPrivateKeyPath  string         `long:"private-key-path" env:"DNS_API_TLS_PRIVATE_KEY_PATH" description:"Client key to use for mutual LS"`
+
+	UI ui.UI
+}
+
+type CacheFlushArgs struct {
+	Name string `positional-arg-name:"NAME" description:"Name to flush; all cached responses are flushed when omitted"`
+}
+
+func (o *CacheFlushCmd) Execute(args []string) error {
+	logger := boshlog.NewLogger(boshlog.LevelNone)
+	if o.UI == nil {
+		confUI := ui.NewConfUI(logger)
+		confUI.EnableColor()
+		o.UI = confUI
+	}
+
+	if o.Suffix && o.Args.Name == "" {
+		return fmt.Errorf("--suffix requires a NAME")
+	}
+
+	client, err := tlsclient.NewFromFiles("api.bosh-dns", o.TLSCACertPath, o.TLSCertificatePath, o.TLSPrivateKeyPath, 5*time.Second, logger)
+	if err != nil {
+		return err
+	}
+
+	requestURL := o.API + "/cache/flush"
+
+	if o.Args.Name != "" {
+		param := "name"
+		if o.Suffix {
+			param = "suffix"
+		}
+		requestURL = requestURL + "?" + param + "=" + url.QueryEscape(o.Args.Name)
+	}
+
+	response, err := client.Post(requestURL, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to flush cache: Got %s", response.Status)
+	}
+
+	var result api.CacheFlushResult
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	o.UI.PrintLinef("Flushed %d cached responses", result.Flushed)
+
+	return nil
+}
@@ -5,8 +5,16 @@ import (
 )
 
 type Commands struct {
-	Instances   InstancesCmd   `command:"instances" description:"Show known instances"`
-	LocalGroups LocalGroupsCmd `command:"local-groups" description:"Show health status and link details for groups local to the current instance"`
+	Instances         InstancesCmd         `command:"instances" description:"Show known instances"`
+	HealthHistory     HealthHistoryCmd     `command:"health-history" description:"Show recent health state changes of known instances"`
+	HealthChecks      HealthChecksCmd      `command:"health-checks" description:"Show when known instances were last checked and are checked next"`
+	LocalGroups       LocalGroupsCmd       `command:"local-groups" description:"Show health status and link details for groups local to the current instance"`
+	Cache             CacheCmd             `command:"cache" description:"Show cached recursor responses"`
+	CacheFlush        CacheFlushCmd        `command:"cache-flush" description:"Remove responses from the recursor cache"`
+	RecordsHistory    RecordsHistoryCmd    `command:"records-history" description:"Show the records versions that were applied"`
+	RecordsAccept     RecordsAcceptCmd     `command:"records-accept" description:"Apply the current records files even if their version is older than the applied one"`
+	RecordsConflicts  RecordsConflictsCmd  `command:"records-conflicts" description:"Show domains and IPs provided by more than one records file"`
+	RecordsValidation RecordsValidationCmd `command:"records-validation" description:"Show problems found in the records files"`
 
 	UI ui.UI
 }
@@ -0,0 +1,89 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"bosh-dns/dns/api"
+	"bosh-dns/tlsclient"
+)
+
+type HealthHistoryCmd struct {
+	Args               InstancesArgs `positional-args:"true"`
+	API                string        `long:"api" env:"DNS_API_ADDRESS" description:"API address to talk to"`
+	TLSCACertPath      string        `long:"ca-cert-path" env:"DNS_API_TLS_CA_CERT_PATH" description:"CA certificate to use for mutual LS"`
+	TLSCertificatePath string        `long:"certificate-path" env:"DNS_API_TLS_CERTIFICATE_PATH" description:"Client certificate to use for mutual LS"`
+	TLSPrivateKeyPath  string        `long:"private-key-path" env:"DNS_API_TLS_PRIVATE_KEY_PATH" description:"Client key to use for mutual LS"`
+
+	UI ui.UI
+}
+
+func (o *HealthHistoryCmd) Execute(args []string) error {
+	logger := boshlog.NewLogger(boshlog.LevelNone)
+	if o.UI == nil {
+		confUI := ui.NewConfUI(logger)
+		confUI.EnableColor()
+		o.UI = confUI
+	}
+
+	client, err := tlsclient.NewFromFiles("api.bosh-dns", o.TLSCACertPath, o.TLSCertificatePath, o.TLSPrivateKeyPath, 5*time.Second, logger)
+	if err != nil {
+		return err
+	}
+
+	requestURL := o.API + "/instances/health-history"
+
+	if o.Args.Query != "" {
+		requestURL = requestURL + "?address=" + o.Args.Query
+	}
+
+	response, err := client.Get(requestURL)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to retrieve health history: Got %s", response.Status)
+	}
+
+	table := boshtbl.Table{
+		Title: "Health transitions",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("IP"),
+			boshtbl.NewHeader("Group"),
+			boshtbl.NewHeader("From"),
+			boshtbl.NewHeader("To"),
+			boshtbl.NewHeader("At"),
+		},
+	}
+
+	decoder := json.NewDecoder(response.Body)
+
+	for decoder.More() {
+		var jsonRow api.HealthTransition
+
+		err := decoder.Decode(&jsonRow)
+		if err != nil {
+			return err
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(jsonRow.IP),
+			boshtbl.NewValueString(jsonRow.Group),
+			boshtbl.NewValueString(jsonRow.From),
+			boshtbl.NewValueString(jsonRow.To),
+			boshtbl.NewValueTime(jsonRow.At),
+		})
+	}
+
+	o.UI.PrintTable(table)
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"bosh-dns/dns/api"
+	"bosh-dns/tlsclient"
+)
+
+type RecordsAcceptCmd struct {
+	API                string `long:"api" env:"DNS_API_ADDRESS" description:"API address to talk to"`
+	TLSCACertPath      string `long:"ca-cert-path" env:"DNS_API_TLS_CA_CERT_PATH" description:"CA certificate to use for mutual LS"`
+	TLSCertificatePath string `long:"certificate-path" env:"DNS_API_TLS_CERTIFICATE_PATH" description:"Client certificate to use for mutual LS"`
+	TLSPrivateKeyPath  string `long:"private-key-path" env:"DNS_API_TLS_PRIVATE_KEY_PATH" description:"Client key to use for mutual LS"`
+
+	UI ui.UI
+}
+
+func (o *RecordsAcceptCmd) Execute(args []string) error {
+	logger := boshlog.NewLogger(boshlog.LevelNone)
+	if o.UI == nil {
+		confUI := ui.NewConfUI(logger)
+		confUI.EnableColor()
+		o.UI = confUI
+	}
+
+	client, err := tlsclient.NewFromFiles("api.bosh-dns", o.TLSCACertPath, o.TLSCertificatePath, o.TLSPrivateKeyPath, 5*time.Second, logger)
+	if err != nil {
+		return err
+	}
+
+	response, err := client.Post(o.API+"/records/accept", nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(response.Body) //nolint:errcheck
+		return fmt.Errorf("unable to accept records: Got %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+
+	decoder := json.NewDecoder(response.Body)
+
+	for decoder.More() {
+		var result api.RecordsVersion
+		if err := decoder.Decode(&result); err != nil {
+			return err
+		}
+
+		o.UI.PrintLinef("Applied records version %d from %s with %d records", result.Version, result.Source, result.Records)
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package command_test
+
+import (
+	"net/http"
+	"time"
+
+	uifakes "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"debug/cli/command"
+)
+
+var _ = Describe("RecordsHistoryCmd", func() {
+	var (
+		server *ghttp.Server
+		ui     *uifakes.FakeUI
+		cmd    command.RecordsHistoryCmd
+	)
+
+	BeforeEach(func() {
+		server = newFakeAPIServer()
+
+		ui = &uifakes.FakeUI{}
+		cmd = command.RecordsHistoryCmd{
+			UI:                 ui,
+			API:                server.URL(),
+			TLSCACertPath:      "../../../bosh-dns/dns/api/assets/test_certs/test_ca.pem",
+			TLSCertificatePath: "../../../bosh-dns/dns/api/assets/test_certs/test_wrong_cn_client.pem",
+			TLSPrivateKeyPath:  "../../../bosh-dns/dns/api/assets/test_certs/test_client.key",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("when the DNS server responds with some versions", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/records/history"),
+					ghttp.RespondWith(http.StatusOK, `
+						{"source": "/records.json", "version": 4, "applied_at": "2026-01-02T03:04:05Z", "records": 2, "added": 0, "removed": 1, "forced": true}
+						{"source": "/records.json", "version": 5, "applied_at": "2026-01-02T03:00:00Z", "records": 3, "added": 3, "removed": 0, "forced": false}
+					`),
+				),
+			)
+		})
+
+		It("formats the contents like a table", func() {
+			Expect(cmd.Execute(nil)).To(Succeed())
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Title: "Applied records versions",
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Source"),
+					boshtbl.NewHeader("Version"),
+					boshtbl.NewHeader("Applied At"),
+					boshtbl.NewHeader("Records"),
+					boshtbl.NewHeader("Added"),
+					boshtbl.NewHeader("Removed"),
+					boshtbl.NewHeader("Forced"),
+				},
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("/records.json"),
+						boshtbl.NewValueString("4"),
+						boshtbl.NewValueTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)),
+						boshtbl.NewValueInt(2),
+						boshtbl.NewValueInt(0),
+						boshtbl.NewValueInt(1),
+						boshtbl.NewValueBool(true),
+					},
+					{
+						boshtbl.NewValueString("/records.json"),
+						boshtbl.NewValueString("5"),
+						boshtbl.NewValueTime(time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)),
+						boshtbl.NewValueInt(3),
+						boshtbl.NewValueInt(3),
+						boshtbl.NewValueInt(0),
+						boshtbl.NewValueBool(false),
+					},
+				},
+			}))
+		})
+	})
+
+	Context("when the server does not respond 200", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/records/history"),
+					ghttp.RespondWith(http.StatusNotFound, []byte{}),
+				),
+			)
+		})
+
+		It("raises an error", func() {
+			Expect(cmd.Execute(nil)).ToNot(Succeed())
+		})
+	})
+})
+
+var _ = Describe("RecordsAcceptCmd", func() {
+	var (
+		server *ghttp.Server
+		ui     *uifakes.FakeUI
+		cmd    command.RecordsAcceptCmd
+	)
+
+	BeforeEach(func() {
+		server = newFakeAPIServer()
+
+		ui = &uifakes.FakeUI{}
+		cmd = command.RecordsAcceptCmd{
+			UI:                 ui,
+			API:                server.URL(),
+			TLSCACertPath:      "../../../bosh-dns/dns/api/assets/test_certs/test_ca.pem",
+			TLSCertificatePath: "../../../bosh-dns/dns/api/assets/test_certs/test_wrong_cn_client.pem",
+			TLSPrivateKeyPath:  "../../../bosh-dns/dns/api/assets/test_certs/test_client.key",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reports the versions that were applied", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/records/accept"),
+				ghttp.RespondWith(http.StatusOK, `
+					{"source": "/records.json", "version": 4, "applied_at": "2026-01-02T03:04:05Z", "records": 2, "forced": true}
+					{"source": "/other.json", "version": 7, "applied_at": "2026-01-02T03:04:05Z", "records": 5, "forced": true}
+				`),
+			),
+		)
+
+		Expect(cmd.Execute(nil)).To(Succeed())
+		Expect(ui.Said).To(Equal([]string{
+			"Applied records version 4 from /records.json with 2 records",
+			"Applied records version 7 from /other.json with 5 records",
+		}))
+	})
+
+	It("includes the reason when the records cannot be applied", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/records/accept"),
+				ghttp.RespondWith(http.StatusUnprocessableEntity, "Parsing records file"),
+			),
+		)
+
+		Expect(cmd.Execute(nil)).To(MatchError("unable to accept records: Got 422 Unprocessable Entity: Parsing records file"))
+	})
+})
+
+var _ = Describe("RecordsConflictsCmd", func() {
+	var (
+		server *ghttp.Server
+		ui     *uifakes.FakeUI
+		cmd    command.RecordsConflictsCmd
+	)
+
+	BeforeEach(func() {
+		server = newFakeAPIServer()
+
+		ui = &uifakes.FakeUI{}
+		cmd = command.RecordsConflictsCmd{
+			UI:                 ui,
+			API:                server.URL(),
+			TLSCACertPath:      "../../../bosh-dns/dns/api/assets/test_certs/test_ca.pem",
+			TLSCertificatePath: "../../../bosh-dns/dns/api/assets/test_certs/test_wrong_cn_client.pem",
+			TLSPrivateKeyPath:  "../../../bosh-dns/dns/api/assets/test_certs/test_client.key",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("formats the conflicts like a table", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/records/conflicts"),
+				ghttp.RespondWith(http.StatusOK, `{"kind": "domain", "value": "bosh.", "sources": ["/records.json", "/other.json"]}`),
+			),
+		)
+
+		Expect(cmd.Execute(nil)).To(Succeed())
+		Expect(ui.Table).To(Equal(boshtbl.Table{
+			Title: "Records conflicts",
+			Header: []boshtbl.Header{
+				boshtbl.NewHeader("Kind"),
+				boshtbl.NewHeader("Value"),
+				boshtbl.NewHeader("Sources"),
+			},
+			Rows: [][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("domain"),
+					boshtbl.NewValueString("bosh."),
+					boshtbl.NewValueString("/records.json\n/other.json"),
+				},
+			},
+		}))
+	})
+})
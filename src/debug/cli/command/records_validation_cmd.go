@@ -0,0 +1,91 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"bosh-dns/dns/api"
+	"bosh-dns/tlsclient"
+)
+
+type RecordsValidationCmd struct {
+	API                string `long:"api" env:"DNS_API_ADDRESS" description:"API address to talk to"`
+	TLSCACertPath      string `long:"ca-cert-path" env:"DNS_API_TLS_CA_CERT_PATH" description:"CA certificate to use for mutual LS"`
+	TLSCertificatePath string `long:"certificate-path" env:"DNS_API_TLS_CERTIFICATE_PATH" description:"Client certificate to use for mutual LS"`
+	TLSPrivateKeyPath  string `long:"private-key-path" env:"DNS_API_TLS_PRIVATE_KEY_PATH" description:"Client key to use for mutual LS"`
+
+	UI ui.UI
+}
+
+func (o *RecordsValidationCmd) Execute(args []string) error {
+	logger := boshlog.NewLogger(boshlog.LevelNone)
+	if o.UI == nil {
+		confUI := ui.NewConfUI(logger)
+		confUI.EnableColor()
+		o.UI = confUI
+	}
+
+	client, err := tlsclient.NewFromFiles("api.bosh-dns", o.TLSCACertPath, o.TLSCertificatePath, o.TLSPrivateKeyPath, 5*time.Second, logger)
+	if err != nil {
+		return err
+	}
+
+	response, err := client.Get(o.API + "/records/validation")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to retrieve records validation: Got %s", response.Status)
+	}
+
+	table := boshtbl.Table{
+		Title: "Records problems",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Kind"),
+			boshtbl.NewHeader("Source"),
+			boshtbl.NewHeader("Line"),
+			boshtbl.NewHeader("Record"),
+			boshtbl.NewHeader("Field"),
+			boshtbl.NewHeader("Message"),
+		},
+	}
+
+	decoder := json.NewDecoder(response.Body)
+
+	for decoder.More() {
+		var jsonRow api.RecordsProblem
+
+		err := decoder.Decode(&jsonRow)
+		if err != nil {
+			return err
+		}
+
+		line, record := "", ""
+		if jsonRow.Line > 0 {
+			line = strconv.Itoa(jsonRow.Line)
+			record = strconv.Itoa(jsonRow.Record)
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(jsonRow.Kind),
+			boshtbl.NewValueString(jsonRow.Source),
+			boshtbl.NewValueString(line),
+			boshtbl.NewValueString(record),
+			boshtbl.NewValueString(jsonRow.Field),
+			boshtbl.NewValueString(jsonRow.Message),
+		})
+	}
+
+	o.UI.PrintTable(table)
+
+	return nil
+}